@@ -0,0 +1,92 @@
+// Command cartography generates an infrastructure diagram from Terraform state or
+// configuration files without running Terraform, e.g.
+//
+//	cartography -state terraform.tfstate -out diagram.svg -title "Production"
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ankek/terraform-provider-cartography/internal/provider"
+)
+
+// Exit codes returned by run
+const (
+	exitOK    = 0
+	exitError = 1 // Diagram generation failed
+	exitUsage = 2 // Invalid command line
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run generates the diagram described by the command line arguments, printing a summary
+// to stdout (stderr when the diagram itself goes to stdout) and errors to stderr, and
+// returns the process exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cartography", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	statePath := flags.String("state", "", "Terraform state file or URL to diagram")
+	configPath := flags.String("config", "", "directory of Terraform configuration files to diagram (used when -state is not set)")
+	outputPath := flags.String("out", "", "file to write the diagram to, or - for stdout (required)")
+	format := flags.String("format", "svg", "output format")
+	direction := flags.String("direction", "TB", "diagram direction: TB, LR, BT or RL")
+	useIcons := flags.Bool("icons", false, "use cloud provider icons where available")
+	title := flags.String("title", "", "title drawn above the diagram")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	var usageErr string
+	switch {
+	case flags.NArg() > 0:
+		usageErr = fmt.Sprintf("unexpected arguments: %v", flags.Args())
+	case *statePath == "" && *configPath == "":
+		usageErr = "one of -state or -config is required"
+	case *outputPath == "":
+		usageErr = "-out is required"
+	}
+	if usageErr != "" {
+		fmt.Fprintf(stderr, "cartography: %s\n", usageErr)
+		flags.Usage()
+		return exitUsage
+	}
+
+	generator := &provider.DiagramGenerator{}
+	result, err := generator.Generate(ctx, provider.DiagramConfig{
+		StatePath:     *statePath,
+		ConfigPath:    *configPath,
+		OutputPath:    *outputPath,
+		Format:        *format,
+		Direction:     *direction,
+		IncludeLabels: true,
+		Title:         *title,
+		UseIcons:      *useIcons,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "cartography: %v\n", err)
+		return exitError
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(stderr, "cartography: warning: %s\n", warning)
+	}
+
+	summary := stdout
+	if result.OutputPath == provider.StdoutOutputPath {
+		summary = stderr
+	}
+	fmt.Fprintf(summary, "Diagram of %d resources written to %s\n", result.ResourceCount, result.OutputPath)
+
+	return exitOK
+}
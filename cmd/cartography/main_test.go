@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "vpc-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+	outputFile := filepath.Join(tmpDir, "diagram.svg")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "generates diagram",
+			args:       []string{"-state", stateFile, "-out", outputFile, "-direction", "LR", "-title", "Smoke Test"},
+			wantCode:   exitOK,
+			wantStdout: "Diagram of 2 resources written to " + outputFile,
+		},
+		{
+			name:       "missing input",
+			args:       []string{"-out", outputFile},
+			wantCode:   exitUsage,
+			wantStderr: "one of -state or -config is required",
+		},
+		{
+			name:       "missing output",
+			args:       []string{"-state", stateFile},
+			wantCode:   exitUsage,
+			wantStderr: "-out is required",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"-state", stateFile, "-out", outputFile, "-colour"},
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name:       "missing state file",
+			args:       []string{"-state", filepath.Join(tmpDir, "missing.tfstate"), "-out", outputFile},
+			wantCode:   exitError,
+			wantStderr: "invalid state path",
+		},
+		{
+			name:       "unsupported format",
+			args:       []string{"-state", stateFile, "-out", outputFile, "-format", "gif"},
+			wantCode:   exitError,
+			wantStderr: "gif",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("diagram was not written: %v", err)
+	}
+	if !bytes.Contains(data, []byte("<svg")) || !bytes.Contains(data, []byte("Smoke Test")) {
+		t.Errorf("diagram is not a titled SVG: %.200s", data)
+	}
+}
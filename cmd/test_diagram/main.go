@@ -83,11 +83,13 @@ func main() {
 
 	// Test rendering with icons
 	opts := renderer.RenderOptions{
-		Format:        "svg",
-		Direction:     "TB",
-		IncludeLabels: true,
-		Title:         "My Infrastructure",
-		UseIcons:      true, // ENABLE ICONS
+		Format:         "svg",
+		Direction:      "TB",
+		IncludeLabels:  true,
+		Title:          "My Infrastructure",
+		UseIcons:       true, // ENABLE ICONS
+		MaxLabelLength: renderer.DefaultMaxLabelLength,
+		MaxTypeLength:  renderer.DefaultMaxTypeLength,
 	}
 
 	fmt.Println("Rendering diagram with icons enabled...")
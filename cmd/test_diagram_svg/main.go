@@ -65,11 +65,13 @@ func main() {
 
 	// Test rendering with icons - SVG OUTPUT
 	opts := renderer.RenderOptions{
-		Format:        "svg",
-		Direction:     "TB",
-		IncludeLabels: true,
-		Title:         "My Infrastructure",
-		UseIcons:      true, // ENABLE ICONS
+		Format:         "svg",
+		Direction:      "TB",
+		IncludeLabels:  true,
+		Title:          "My Infrastructure",
+		UseIcons:       true, // ENABLE ICONS
+		MaxLabelLength: renderer.DefaultMaxLabelLength,
+		MaxTypeLength:  renderer.DefaultMaxTypeLength,
 	}
 
 	// Check icon availability before rendering
@@ -5,6 +5,9 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ankek/terraform-provider-cartography/internal/parser"
@@ -19,6 +22,8 @@ type Node struct {
 	ResourceType parser.ResourceType
 	Attributes   map[string]interface{}
 	Edges        []*Edge
+	SourceFile   string // Configuration file declaring the resource (config mode only)
+	SourceLine   int    // Line of the resource block in SourceFile (0 = unknown)
 }
 
 // Edge represents a connection between two resources
@@ -39,19 +44,37 @@ type Graph struct {
 	attributeIndex map[string]map[string]*Node
 }
 
-// edgeExists checks if an edge already exists between two nodes
-func (g *Graph) edgeExists(from, to *Node) bool {
+// edgeKeyMetadata lists the metadata keys that distinguish otherwise identical edges
+// (e.g., a security group protecting the same server on port 22 and port 443)
+var edgeKeyMetadata = []string{"port", "protocol", "frontend_port", "backend_port"}
+
+// edgeExists checks if an equivalent edge already exists between two nodes.
+// Edges are equivalent when they share endpoints, relationship and key metadata.
+func (g *Graph) edgeExists(from, to *Node, relationship string, metadata map[string]string) bool {
 	for _, edge := range g.Edges {
-		if edge.From.ID == from.ID && edge.To.ID == to.ID {
+		if edge.From.ID != from.ID || edge.To.ID != to.ID || edge.Relationship != relationship {
+			continue
+		}
+		if sameKeyMetadata(edge.Metadata, metadata) {
 			return true
 		}
 	}
 	return false
 }
 
+// sameKeyMetadata reports whether two metadata maps agree on all key metadata fields
+func sameKeyMetadata(a, b map[string]string) bool {
+	for _, key := range edgeKeyMetadata {
+		if a[key] != b[key] {
+			return false
+		}
+	}
+	return true
+}
+
 // addEdge adds an edge only if it doesn't already exist
 func (g *Graph) addEdge(from, to *Node, relationship string, metadata map[string]string) {
-	if g.edgeExists(from, to) {
+	if g.edgeExists(from, to, relationship, metadata) {
 		return // Don't add duplicate
 	}
 
@@ -66,6 +89,24 @@ func (g *Graph) addEdge(from, to *Node, relationship string, metadata map[string
 	from.Edges = append(from.Edges, edge)
 }
 
+// GraphOptions controls how BuildGraphWithOptions constructs the graph
+type GraphOptions struct {
+	// DetectImplicit enables heuristic detection of connections that are not
+	// declared as Terraform dependencies (e.g., attribute references between resources)
+	DetectImplicit bool
+
+	// IncludeAssociations draws association resources (e.g. aws_route_table_association)
+	// as nodes instead of folding them into edges between the resources they link
+	IncludeAssociations bool
+}
+
+// DefaultGraphOptions returns the options used by BuildGraph
+func DefaultGraphOptions() GraphOptions {
+	return GraphOptions{
+		DetectImplicit: true,
+	}
+}
+
 // BuildGraph creates a resource dependency graph from parsed Terraform resources.
 // It filters out utility resources (TLS keys, local files, etc.) and builds
 // a directed graph showing infrastructure dependencies.
@@ -78,6 +119,13 @@ func (g *Graph) addEdge(from, to *Node, relationship string, metadata map[string
 //
 // Returns a Graph ready for visualization. Respects context for cancellation.
 func BuildGraph(ctx context.Context, resources []parser.Resource) *Graph {
+	return BuildGraphWithOptions(ctx, resources, DefaultGraphOptions())
+}
+
+// BuildGraphWithOptions creates a resource dependency graph like BuildGraph,
+// with opts controlling which optional steps are performed.
+// With DetectImplicit disabled, only explicit Terraform dependencies become edges.
+func BuildGraphWithOptions(ctx context.Context, resources []parser.Resource, opts GraphOptions) *Graph {
 	g := &Graph{
 		Nodes:          make(map[string]*Node),
 		Edges:          make([]*Edge, 0),
@@ -85,6 +133,7 @@ func BuildGraph(ctx context.Context, resources []parser.Resource) *Graph {
 	}
 
 	// Create nodes (filter out non-infrastructure resources)
+	var associations []parser.Resource
 	for _, res := range resources {
 		// Check context
 		select {
@@ -93,7 +142,12 @@ func BuildGraph(ctx context.Context, resources []parser.Resource) *Graph {
 		default:
 		}
 		// Skip non-cloud infrastructure resources (TLS keys, local files, etc.)
-		if !parser.ShouldIncludeInDiagram(res) {
+		keepAssociation := opts.IncludeAssociations && parser.IsCloudInfraResource(res.Type)
+		if !parser.ShouldIncludeInDiagram(res) && !keepAssociation {
+			// Association resources are not drawn but still describe connections
+			if parser.IsAssociationResource(res.Type) {
+				associations = append(associations, res)
+			}
 			continue
 		}
 
@@ -105,6 +159,8 @@ func BuildGraph(ctx context.Context, resources []parser.Resource) *Graph {
 			ResourceType: parser.GetResourceType(res.Type),
 			Attributes:   res.Attributes,
 			Edges:        make([]*Edge, 0),
+			SourceFile:   res.SourceFile,
+			SourceLine:   res.SourceLine,
 		}
 		g.Nodes[res.ID] = node
 	}
@@ -132,31 +188,88 @@ func BuildGraph(ctx context.Context, resources []parser.Resource) *Graph {
 				continue
 			}
 
-			g.addEdge(fromNode, toNode, inferRelationship(fromNode, toNode), extractConnectionMetadata(fromNode, toNode))
+			metadata := extractConnectionMetadata(fromNode, toNode)
+			if via := findReferencingAttribute(fromNode, toNode); via != "" {
+				metadata = withVia(metadata, via)
+			}
+			g.addEdge(fromNode, toNode, inferRelationship(fromNode, toNode), metadata)
 		}
 	}
 
 	// Detect implicit connections (e.g., NSG rules referencing load balancers)
-	g.detectImplicitConnections()
+	if opts.DetectImplicit {
+		g.detectImplicitConnections()
+		g.detectAssociationConnections(associations)
+		g.detectReferenceConnections()
+	}
 
 	return g
 }
 
+// maxIndexedListLength bounds the list attributes whose elements are indexed; longer
+// lists (e.g. large CIDR or member lists) would grow the index without identifying nodes
+const maxIndexedListLength = 64
+
 // buildAttributeIndex creates an index for fast O(1) node lookups by attribute values.
 // This optimization reduces graph traversal from O(n²) to O(n) during implicit connection detection.
+// String and number values are indexed (see scalarString), as are the elements of lists
+// of up to maxIndexedListLength of them; a list element never replaces a value that
+// another node holds directly.
 func (g *Graph) buildAttributeIndex() {
 	for _, node := range g.Nodes {
 		for attrKey, attrValue := range node.Attributes {
-			if strValue, ok := attrValue.(string); ok {
-				if g.attributeIndex[attrKey] == nil {
-					g.attributeIndex[attrKey] = make(map[string]*Node)
+			if value, ok := scalarString(attrValue); ok {
+				g.indexAttribute(attrKey, value, node, true)
+				continue
+			}
+
+			list, ok := attrValue.([]interface{})
+			if !ok || len(list) > maxIndexedListLength {
+				continue
+			}
+			for _, element := range list {
+				if value, ok := scalarString(element); ok {
+					g.indexAttribute(attrKey, value, node, false)
 				}
-				g.attributeIndex[attrKey][strValue] = node
 			}
 		}
 	}
 }
 
+// indexAttribute records node under an attribute value, replacing the node indexed for
+// it so far only when replace is set
+func (g *Graph) indexAttribute(attrKey, value string, node *Node, replace bool) {
+	if value == "" {
+		return
+	}
+	index := g.attributeIndex[attrKey]
+	if index == nil {
+		index = make(map[string]*Node)
+		g.attributeIndex[attrKey] = index
+	}
+	if _, exists := index[value]; replace || !exists {
+		index[value] = node
+	}
+}
+
+// scalarString returns a string attribute value as is and a number in plain decimal
+// form, e.g. "12345" for a droplet id decoded from JSON as float64 12345
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
 // inferRelationship determines the type of relationship between two resources
 func inferRelationship(from, to *Node) string {
 	// Network security to compute/load balancer
@@ -174,6 +287,24 @@ func inferRelationship(from, to *Node) string {
 		return "routes_to"
 	}
 
+	// CDN to its origin
+	if from.ResourceType == parser.ResourceTypeCDN {
+		if to.ResourceType == parser.ResourceTypeStorage {
+			return "caches"
+		}
+		if to.ResourceType == parser.ResourceTypeLoadBalancer || to.ResourceType == parser.ResourceTypeCompute {
+			return "serves"
+		}
+	}
+
+	// DNS record to its target
+	if from.ResourceType == parser.ResourceTypeDNS {
+		switch to.ResourceType {
+		case parser.ResourceTypeLoadBalancer, parser.ResourceTypeCompute, parser.ResourceTypeCDN:
+			return "resolves_to"
+		}
+	}
+
 	// Network to subnet/security
 	if from.ResourceType == parser.ResourceTypeNetwork {
 		return "contains"
@@ -197,6 +328,123 @@ func inferRelationship(from, to *Node) string {
 // reducing memory allocations in the hot path.
 var emptyMetadata = map[string]string{}
 
+// withVia returns a copy of metadata recording the attribute that created the edge.
+// The input map is never modified, since it may be the shared emptyMetadata.
+func withVia(metadata map[string]string, attr string) map[string]string {
+	result := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		result[k] = v
+	}
+	result["via"] = attr
+	return result
+}
+
+// findReferencingAttribute returns the name of the attribute on from whose value
+// references to's ID (e.g., "vpc_id"), or an empty string if none does
+func findReferencingAttribute(from, to *Node) string {
+	toID := getAttributeString(to.Attributes, "id")
+	if toID == "" {
+		return ""
+	}
+
+	// Sort keys so the result is stable when several attributes match
+	keys := make([]string, 0, len(from.Attributes))
+	for key := range from.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "id" {
+			continue
+		}
+		switch v := from.Attributes[key].(type) {
+		case string:
+			if v == toID {
+				return key
+			}
+		case []interface{}:
+			for _, item := range v {
+				if str, ok := item.(string); ok && str == toID {
+					return key
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// minReferenceIDLength is the shortest id matched by detectReferenceConnections; shorter
+// ids such as "1" or "main" are too likely to equal unrelated attribute values
+const minReferenceIDLength = 8
+
+// detectReferenceConnections adds a generic "references" edge from each node to every
+// other node whose id appears as one of its attribute values (e.g. an unrecognized
+// resource's subnet_id), catching references the specific detectors miss. Nodes that are
+// already connected in either direction are skipped, as are short ids and ids shared
+// by several nodes, which can't identify a single resource.
+func (g *Graph) detectReferenceConnections() {
+	idCounts := make(map[string]int)
+	for _, node := range g.Nodes {
+		if id := getAttributeString(node.Attributes, "id"); id != "" {
+			idCounts[id]++
+		}
+	}
+
+	// Connected node pairs, keyed in both directions
+	connected := make(map[[2]string]bool, 2*len(g.Edges))
+	for _, edge := range g.Edges {
+		connected[[2]string{edge.From.ID, edge.To.ID}] = true
+		connected[[2]string{edge.To.ID, edge.From.ID}] = true
+	}
+
+	// Visit nodes and attributes in sorted order so edges are added deterministically
+	nodeIDs := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	for _, nodeID := range nodeIDs {
+		node := g.Nodes[nodeID]
+		keys := make([]string, 0, len(node.Attributes))
+		for key := range node.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if key == "id" {
+				continue
+			}
+			var values []string
+			switch v := node.Attributes[key].(type) {
+			case string:
+				values = []string{v}
+			case []interface{}:
+				for _, item := range v {
+					if str, ok := item.(string); ok {
+						values = append(values, str)
+					}
+				}
+			}
+
+			for _, value := range values {
+				if len(value) < minReferenceIDLength || idCounts[value] != 1 {
+					continue
+				}
+				target := g.attributeIndex["id"][value]
+				if target == nil || target == node || connected[[2]string{node.ID, target.ID}] {
+					continue
+				}
+				g.addEdge(node, target, "references", withVia(emptyMetadata, key))
+				connected[[2]string{node.ID, target.ID}] = true
+				connected[[2]string{target.ID, node.ID}] = true
+			}
+		}
+	}
+}
+
 // extractConnectionMetadata extracts metadata about the connection using safe attribute helpers.
 // Returns a shared empty map if no metadata is found to avoid unnecessary allocations.
 func extractConnectionMetadata(from, to *Node) map[string]string {
@@ -246,6 +494,25 @@ func extractConnectionMetadata(from, to *Node) map[string]string {
 			ensureMetadata()
 			metadata["port"] = port
 		}
+		if protocol, ok := parser.GetStringAttribute(from.Attributes, "protocol"); ok {
+			ensureMetadata()
+			metadata["protocol"] = protocol
+		}
+	}
+
+	// GCP: Extract forwarding rule ports (a single range or a list of ports)
+	if from.Provider == "gcp" && gcpForwardingRuleTypes[from.Type] {
+		if portRange, ok := parser.GetStringAttribute(from.Attributes, "port_range"); ok && portRange != "" {
+			ensureMetadata()
+			metadata["port"] = normalizePortRange(portRange)
+		} else if ports, ok := parser.GetStringSliceAttribute(from.Attributes, "ports"); ok && len(ports) > 0 {
+			ensureMetadata()
+			metadata["port"] = strings.Join(ports, ",")
+		}
+		if protocol, ok := parser.GetStringAttribute(from.Attributes, "ip_protocol"); ok && protocol != "" {
+			ensureMetadata()
+			metadata["protocol"] = protocol
+		}
 	}
 
 	// DigitalOcean: Extract firewall rule ports - safely handle nested structures
@@ -291,21 +558,67 @@ func extractConnectionMetadata(from, to *Node) map[string]string {
 	return metadata
 }
 
+// normalizePortRange collapses a single-port range such as "80-80" to "80"
+func normalizePortRange(portRange string) string {
+	if start, end, ok := strings.Cut(portRange, "-"); ok && start == end {
+		return start
+	}
+	return portRange
+}
+
 // detectImplicitConnections finds connections not explicitly in dependencies.
 // Uses the attribute index for O(1) lookups instead of O(n) scans.
 func (g *Graph) detectImplicitConnections() {
 	// Azure: NSG to subnet associations
 	for _, node := range g.Nodes {
 		if node.Provider == "azure" && node.Type == "azurerm_subnet_network_security_group_association" {
-			// Find subnet and NSG
-			subnetID := getAttributeString(node.Attributes, "subnet_id")
-			nsgID := getAttributeString(node.Attributes, "network_security_group_id")
+			g.linkSubnetNSGAssociation(node.Attributes, node)
+		}
 
-			subnetNode := g.findNodeByAttributeValue("id", subnetID)
-			nsgNode := g.findNodeByAttributeValue("id", nsgID)
+		// Azure: App Service / Function App to its App Service Plan
+		if node.Provider == "azure" && appServiceTypes[node.Type] {
+			for _, attr := range appServicePlanAttributes {
+				planNode := g.findAzureNodeByID(getAttributeString(node.Attributes, attr), node)
+				if planNode != nil && appServicePlanTypes[planNode.Type] {
+					g.addEdge(node, planNode, "hosted_on", withVia(emptyMetadata, attr))
+					break
+				}
+			}
+		}
 
-			if subnetNode != nil && nsgNode != nil {
-				g.addEdge(nsgNode, subnetNode, "protects", emptyMetadata)
+		// Azure: Network interface to the subnets of its ip_configuration blocks
+		if node.Provider == "azure" && node.Type == "azurerm_network_interface" {
+			if ipConfigs, ok := node.Attributes["ip_configuration"].([]interface{}); ok {
+				for _, c := range ipConfigs {
+					ipConfig, ok := c.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					subnetNode := g.findAzureNodeByID(getAttributeString(ipConfig, "subnet_id"), node)
+					if subnetNode != nil && subnetNode.Type == "azurerm_subnet" {
+						g.addEdge(node, subnetNode, "attached_to", withVia(emptyMetadata, "ip_configuration.subnet_id"))
+					}
+				}
+			}
+		}
+
+		// Azure: Private endpoint to its subnet and to the resources it connects privately
+		if node.Provider == "azure" && node.Type == "azurerm_private_endpoint" {
+			subnetNode := g.findAzureNodeByID(getAttributeString(node.Attributes, "subnet_id"), node)
+			if subnetNode != nil && subnetNode.Type == "azurerm_subnet" {
+				g.addEdge(node, subnetNode, "deployed_in", withVia(emptyMetadata, "subnet_id"))
+			}
+			if connections, ok := node.Attributes["private_service_connection"].([]interface{}); ok {
+				for _, c := range connections {
+					connection, ok := c.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					targetNode := g.findAzureNodeByID(getAttributeString(connection, "private_connection_resource_id"), node)
+					if targetNode != nil {
+						g.addEdge(node, targetNode, "connects_to", withVia(emptyMetadata, "private_service_connection.private_connection_resource_id"))
+					}
+				}
 			}
 		}
 
@@ -316,24 +629,259 @@ func (g *Graph) detectImplicitConnections() {
 					if sgIDStr, ok := sgID.(string); ok {
 						sgNode := g.findNodeByAttributeValue("id", sgIDStr)
 						if sgNode != nil {
-							g.addEdge(sgNode, node, "protects", emptyMetadata)
+							g.addEdge(sgNode, node, "protects", withVia(emptyMetadata, "vpc_security_group_ids"))
 						}
 					}
 				}
 			}
 		}
 
+		// AWS: Security group rules referencing another security group
+		if node.Provider == "aws" && node.Type == "aws_security_group_rule" {
+			ownerNode := g.findNodeOfType("aws_security_group", "id", getAttributeString(node.Attributes, "security_group_id"))
+			sourceNode := g.findNodeOfType("aws_security_group", "id", getAttributeString(node.Attributes, "source_security_group_id"))
+			if ownerNode != nil && sourceNode != nil && ownerNode != sourceNode {
+				g.addSecurityGroupRuleEdge(ownerNode, sourceNode, getAttributeString(node.Attributes, "type") == "egress", node.Attributes, "source_security_group_id")
+			}
+		}
+
+		// AWS: Inline ingress/egress blocks on security groups referencing other security groups
+		if node.Provider == "aws" && node.Type == "aws_security_group" {
+			for _, direction := range []string{"ingress", "egress"} {
+				rules, ok := node.Attributes[direction].([]interface{})
+				if !ok {
+					continue
+				}
+				for _, r := range rules {
+					rule, ok := r.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					sgIDs, _ := parser.GetStringSliceAttribute(rule, "security_groups")
+					for _, sgID := range sgIDs {
+						peerNode := g.findNodeOfType("aws_security_group", "id", sgID)
+						if peerNode != nil && peerNode != node {
+							g.addSecurityGroupRuleEdge(node, peerNode, direction == "egress", rule, direction+".security_groups")
+						}
+					}
+				}
+			}
+		}
+
+		// AWS: RDS instance to its DB subnet group
+		if node.Provider == "aws" && node.Type == "aws_db_instance" {
+			if groupName := getAttributeString(node.Attributes, "db_subnet_group_name"); groupName != "" {
+				groupNode := g.findNodeOfType("aws_db_subnet_group", "name", groupName)
+				if groupNode == nil {
+					groupNode = g.findNodeOfType("aws_db_subnet_group", "id", groupName)
+				}
+				if groupNode != nil {
+					g.addEdge(node, groupNode, "deployed_in", withVia(emptyMetadata, "db_subnet_group_name"))
+				}
+			}
+		}
+
+		// AWS: DB subnet group to the subnets it spans
+		if node.Provider == "aws" && node.Type == "aws_db_subnet_group" {
+			if subnetIDs, ok := parser.GetStringSliceAttribute(node.Attributes, "subnet_ids"); ok {
+				for _, subnetID := range subnetIDs {
+					if subnetNode := g.findNodeOfType("aws_subnet", "id", subnetID); subnetNode != nil {
+						g.addEdge(node, subnetNode, "contains", withVia(emptyMetadata, "subnet_ids"))
+					}
+				}
+			}
+		}
+
+		// AWS: Route to its gateway target
+		if node.Provider == "aws" && node.Type == "aws_route" {
+			for _, target := range routeTargets {
+				targetID := getAttributeString(node.Attributes, target.attribute)
+				for _, targetType := range target.types {
+					if targetNode := g.findNodeOfType(targetType, "id", targetID); targetNode != nil {
+						g.addEdge(node, targetNode, "routes_to", withVia(emptyMetadata, target.attribute))
+						break
+					}
+				}
+			}
+		}
+
+		// AWS: ECS service to its cluster (the cluster attribute holds the ARN or name)
+		if node.Provider == "aws" && node.Type == "aws_ecs_service" {
+			cluster := getAttributeString(node.Attributes, "cluster")
+			for _, attr := range []string{"arn", "id", "name"} {
+				if clusterNode := g.findNodeOfType("aws_ecs_cluster", attr, cluster); clusterNode != nil {
+					g.addEdge(node, clusterNode, "deployed_in", withVia(emptyMetadata, "cluster"))
+					break
+				}
+			}
+		}
+
+		// AWS: EKS node group to its cluster
+		if node.Provider == "aws" && node.Type == "aws_eks_node_group" {
+			if clusterNode := g.findNodeOfType("aws_eks_cluster", "name", getAttributeString(node.Attributes, "cluster_name")); clusterNode != nil {
+				g.addEdge(node, clusterNode, "member_of", withVia(emptyMetadata, "cluster_name"))
+			}
+		}
+
+		// AWS: CloudFront distribution to its origins, matched by domain name
+		if node.Provider == "aws" && node.Type == "aws_cloudfront_distribution" {
+			if origins, ok := node.Attributes["origin"].([]interface{}); ok {
+				for _, o := range origins {
+					origin, ok := o.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					if originNode, relationship := g.findCloudFrontOrigin(getAttributeString(origin, "domain_name")); originNode != nil {
+						g.addEdge(node, originNode, relationship, withVia(emptyMetadata, "origin.domain_name"))
+					}
+				}
+			}
+		}
+
+		// AWS: Lambda function to the subnets and security groups of its vpc_config block
+		if node.Provider == "aws" && node.Type == "aws_lambda_function" {
+			if vpcConfigs, ok := node.Attributes["vpc_config"].([]interface{}); ok {
+				for _, v := range vpcConfigs {
+					vpcConfig, ok := v.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					subnetIDs, _ := parser.GetStringSliceAttribute(vpcConfig, "subnet_ids")
+					for _, subnetID := range subnetIDs {
+						if subnetNode := g.findNodeOfType("aws_subnet", "id", subnetID); subnetNode != nil {
+							g.addEdge(node, subnetNode, "deployed_in", withVia(emptyMetadata, "vpc_config.subnet_ids"))
+						}
+					}
+					sgIDs, _ := parser.GetStringSliceAttribute(vpcConfig, "security_group_ids")
+					for _, sgID := range sgIDs {
+						if sgNode := g.findNodeOfType("aws_security_group", "id", sgID); sgNode != nil {
+							g.addEdge(sgNode, node, "protects", withVia(emptyMetadata, "vpc_config.security_group_ids"))
+						}
+					}
+				}
+			}
+		}
+
+		// AWS: Lambda event source mapping to the function it triggers (by ARN or name) and
+		// to its event source (e.g. an SQS queue ARN or a DynamoDB table's stream ARN)
+		if node.Provider == "aws" && node.Type == "aws_lambda_event_source_mapping" {
+			for _, attr := range []string{"function_arn", "function_name"} {
+				target := getAttributeString(node.Attributes, attr)
+				functionNode := g.findNodeOfType("aws_lambda_function", "arn", target)
+				if functionNode == nil {
+					functionNode = g.findNodeOfType("aws_lambda_function", "function_name", target)
+				}
+				if functionNode != nil {
+					g.addEdge(node, functionNode, "triggers", withVia(emptyMetadata, attr))
+					break
+				}
+			}
+			if sourceARN := getAttributeString(node.Attributes, "event_source_arn"); sourceARN != "" {
+				sourceNode := g.findNodeByAttributeValue("arn", sourceARN)
+				if sourceNode == nil {
+					sourceNode = g.findNodeByAttributeValue("stream_arn", sourceARN)
+				}
+				if sourceNode != nil && sourceNode != node {
+					g.addEdge(node, sourceNode, "reads_from", withVia(emptyMetadata, "event_source_arn"))
+				}
+			}
+		}
+
+		// AWS: VPC peering connection to the requester and accepter VPCs (drawn bidirectional)
+		if node.Provider == "aws" && node.Type == "aws_vpc_peering_connection" {
+			for _, attr := range []string{"vpc_id", "peer_vpc_id"} {
+				if vpcNode := g.findNodeOfType("aws_vpc", "id", getAttributeString(node.Attributes, attr)); vpcNode != nil {
+					g.addEdge(node, vpcNode, "peers_with", withVia(emptyMetadata, attr))
+				}
+			}
+		}
+
+		// AWS: Elastic network interface to its subnet, and security groups to the interface
+		if node.Provider == "aws" && node.Type == "aws_network_interface" {
+			if subnetNode := g.findNodeOfType("aws_subnet", "id", getAttributeString(node.Attributes, "subnet_id")); subnetNode != nil {
+				g.addEdge(node, subnetNode, "attached_to", withVia(emptyMetadata, "subnet_id"))
+			}
+			sgIDs, _ := parser.GetStringSliceAttribute(node.Attributes, "security_groups")
+			for _, sgID := range sgIDs {
+				if sgNode := g.findNodeOfType("aws_security_group", "id", sgID); sgNode != nil {
+					g.addEdge(sgNode, node, "protects", withVia(emptyMetadata, "security_groups"))
+				}
+			}
+		}
+
+		// AWS: Instance to the instance profile it launches with, and profile to its role
+		// (both referenced by name)
+		if node.Provider == "aws" && node.Type == "aws_instance" {
+			if profileNode := g.findNodeOfType("aws_iam_instance_profile", "name", getAttributeString(node.Attributes, "iam_instance_profile")); profileNode != nil {
+				g.addEdge(node, profileNode, "assumes", withVia(emptyMetadata, "iam_instance_profile"))
+			}
+		}
+		if node.Provider == "aws" && node.Type == "aws_iam_instance_profile" {
+			if roleNode := g.findNodeOfType("aws_iam_role", "name", getAttributeString(node.Attributes, "role")); roleNode != nil {
+				g.addEdge(node, roleNode, "assumes", withVia(emptyMetadata, "role"))
+			}
+		}
+
+		// AWS: Transit gateway VPC attachment to its transit gateway and VPC
+		if node.Provider == "aws" && node.Type == "aws_ec2_transit_gateway_vpc_attachment" {
+			if tgwNode := g.findNodeOfType("aws_ec2_transit_gateway", "id", getAttributeString(node.Attributes, "transit_gateway_id")); tgwNode != nil {
+				g.addEdge(node, tgwNode, "attached_to", withVia(emptyMetadata, "transit_gateway_id"))
+			}
+			if vpcNode := g.findNodeOfType("aws_vpc", "id", getAttributeString(node.Attributes, "vpc_id")); vpcNode != nil {
+				g.addEdge(node, vpcNode, "attached_to", withVia(emptyMetadata, "vpc_id"))
+			}
+		}
+
+		// GCP: Managed instance group to its instance template (top-level or per version)
+		if node.Provider == "gcp" && node.Type == "google_compute_instance_group_manager" {
+			g.linkGCPInstanceTemplate(node, node.Attributes, "instance_template")
+			if versions, ok := node.Attributes["version"].([]interface{}); ok {
+				for _, v := range versions {
+					if version, ok := v.(map[string]interface{}); ok {
+						g.linkGCPInstanceTemplate(node, version, "version.instance_template")
+					}
+				}
+			}
+		}
+
+		// GCP: Backend service to the instance groups it balances across
+		if node.Provider == "gcp" && node.Type == "google_compute_backend_service" {
+			if backends, ok := node.Attributes["backend"].([]interface{}); ok {
+				for _, b := range backends {
+					backend, ok := b.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					group := getAttributeString(backend, "group")
+					groupNode := g.findGCPNodeByLink("google_compute_instance_group_manager", group, "instance_group")
+					if groupNode == nil {
+						groupNode = g.findGCPNodeByLink("google_compute_instance_group", group, "self_link", "id")
+					}
+					if groupNode != nil {
+						g.addEdge(node, groupNode, "routes_to", withVia(emptyMetadata, "backend.group"))
+					}
+				}
+			}
+		}
+
+		// Alibaba Cloud: VSwitch to its VPC
+		if node.Provider == "alicloud" && node.Type == "alicloud_vswitch" {
+			if vpcNode := g.findNodeOfType("alicloud_vpc", "id", getAttributeString(node.Attributes, "vpc_id")); vpcNode != nil {
+				g.addEdge(node, vpcNode, "member_of", withVia(emptyMetadata, "vpc_id"))
+			}
+		}
+
 		// DigitalOcean: Firewall to Droplet
 		if node.Provider == "digitalocean" && node.Type == "digitalocean_droplet" {
 			// Droplets can reference firewalls via tags or explicit firewall associations
-			if dropletID := getAttributeString(node.Attributes, "id"); dropletID != "" {
+			if dropletID, _ := scalarString(node.Attributes["id"]); dropletID != "" {
 				// Find firewalls that protect this droplet
 				for _, fwNode := range g.Nodes {
 					if fwNode.Provider == "digitalocean" && fwNode.Type == "digitalocean_firewall" {
 						if dropletIDs, ok := fwNode.Attributes["droplet_ids"].([]interface{}); ok {
 							for _, id := range dropletIDs {
-								if idStr, ok := id.(string); ok && idStr == dropletID {
-									g.addEdge(fwNode, node, "protects", emptyMetadata)
+								if idStr, ok := scalarString(id); ok && idStr == dropletID {
+									g.addEdge(fwNode, node, "protects", withVia(emptyMetadata, "droplet_ids"))
 								}
 							}
 						}
@@ -346,10 +894,10 @@ func (g *Graph) detectImplicitConnections() {
 		if node.Provider == "digitalocean" && node.Type == "digitalocean_loadbalancer" {
 			if dropletIDs, ok := node.Attributes["droplet_ids"].([]interface{}); ok {
 				for _, id := range dropletIDs {
-					if idStr, ok := id.(string); ok {
+					if idStr, ok := scalarString(id); ok {
 						dropletNode := g.findNodeByAttributeValue("id", idStr)
 						if dropletNode != nil {
-							g.addEdge(node, dropletNode, "routes_to", emptyMetadata)
+							g.addEdge(node, dropletNode, "routes_to", withVia(emptyMetadata, "droplet_ids"))
 						}
 					}
 				}
@@ -358,6 +906,210 @@ func (g *Graph) detectImplicitConnections() {
 	}
 }
 
+// detectAssociationConnections adds edges described by association resources that
+// are excluded from the diagram as nodes (see parser.ShouldIncludeInDiagram)
+func (g *Graph) detectAssociationConnections(associations []parser.Resource) {
+	for _, res := range associations {
+		if res.Provider == "azure" && res.Type == "azurerm_subnet_network_security_group_association" {
+			g.linkSubnetNSGAssociation(res.Attributes, nil)
+		}
+
+		// AWS: Route table to the subnets it is associated with
+		if res.Provider == "aws" && res.Type == "aws_route_table_association" {
+			routeTableNode := g.findNodeOfType("aws_route_table", "id", getAttributeString(res.Attributes, "route_table_id"))
+			subnetNode := g.findNodeOfType("aws_subnet", "id", getAttributeString(res.Attributes, "subnet_id"))
+			if routeTableNode != nil && subnetNode != nil {
+				g.addEdge(routeTableNode, subnetNode, "routes", withVia(emptyMetadata, "subnet_id"))
+			}
+		}
+
+		// AWS: EBS volume to the instance it is attached to
+		if res.Provider == "aws" && res.Type == "aws_volume_attachment" {
+			volumeNode := g.findNodeOfType("aws_ebs_volume", "id", getAttributeString(res.Attributes, "volume_id"))
+			instanceNode := g.findNodeOfType("aws_instance", "id", getAttributeString(res.Attributes, "instance_id"))
+			if volumeNode != nil && instanceNode != nil {
+				g.addEdge(volumeNode, instanceNode, "attached_to", withVia(emptyMetadata, "instance_id"))
+			}
+		}
+
+		// AWS: Elastic network interface to the instance it is attached to
+		if res.Provider == "aws" && res.Type == "aws_network_interface_attachment" {
+			eniNode := g.findNodeOfType("aws_network_interface", "id", getAttributeString(res.Attributes, "network_interface_id"))
+			instanceNode := g.findNodeOfType("aws_instance", "id", getAttributeString(res.Attributes, "instance_id"))
+			if eniNode != nil && instanceNode != nil {
+				g.addEdge(eniNode, instanceNode, "attached_to", withVia(emptyMetadata, "instance_id"))
+			}
+		}
+
+		// Azure: Managed data disk to the virtual machine it is attached to
+		if res.Provider == "azure" && res.Type == "azurerm_virtual_machine_data_disk_attachment" {
+			diskNode := g.findAzureNodeByID(getAttributeString(res.Attributes, "managed_disk_id"), nil)
+			vmNode := g.findAzureNodeByID(getAttributeString(res.Attributes, "virtual_machine_id"), nil)
+			if diskNode != nil && vmNode != nil && diskNode.Type == "azurerm_managed_disk" {
+				g.addEdge(diskNode, vmNode, "attached_to", withVia(emptyMetadata, "virtual_machine_id"))
+			}
+		}
+
+		// DigitalOcean: Block storage volume to the droplet it is attached to
+		if res.Provider == "digitalocean" && res.Type == "digitalocean_volume_attachment" {
+			volumeNode := g.findNodeOfType("digitalocean_volume", "id", getAttributeString(res.Attributes, "volume_id"))
+			dropletID, _ := scalarString(res.Attributes["droplet_id"])
+			dropletNode := g.findNodeOfType("digitalocean_droplet", "id", dropletID)
+			if volumeNode != nil && dropletNode != nil {
+				g.addEdge(volumeNode, dropletNode, "attached_to", withVia(emptyMetadata, "droplet_id"))
+			}
+		}
+	}
+}
+
+// routeTargets lists the aws_route attributes that point at a gateway, with the resource
+// types of the gateways each one can hold the ID of
+var routeTargets = []struct {
+	attribute string
+	types     []string
+}{
+	{"gateway_id", []string{"aws_internet_gateway", "aws_vpn_gateway"}},
+	{"nat_gateway_id", []string{"aws_nat_gateway"}},
+}
+
+// findCloudFrontOrigin returns the S3 bucket ("caches") or load balancer ("serves") behind
+// a CloudFront origin domain. Domain names are not in the id index, so the nodes are
+// scanned: buckets match their regional/global domain name or a <bucket>.s3.* domain,
+// load balancers match their dns_name.
+func (g *Graph) findCloudFrontOrigin(domain string) (*Node, string) {
+	if domain == "" {
+		return nil, ""
+	}
+
+	for _, node := range g.Nodes {
+		switch node.Type {
+		case "aws_s3_bucket":
+			for _, attr := range []string{"bucket_regional_domain_name", "bucket_domain_name"} {
+				if name := getAttributeString(node.Attributes, attr); name != "" && strings.EqualFold(name, domain) {
+					return node, "caches"
+				}
+			}
+			if bucket := getAttributeString(node.Attributes, "bucket"); bucket != "" &&
+				strings.HasPrefix(strings.ToLower(domain), strings.ToLower(bucket)+".s3.") {
+				return node, "caches"
+			}
+		case "aws_lb", "aws_alb":
+			if dnsName := getAttributeString(node.Attributes, "dns_name"); dnsName != "" && strings.EqualFold(dnsName, domain) {
+				return node, "serves"
+			}
+		}
+	}
+	return nil, ""
+}
+
+// linkGCPInstanceTemplate adds a managed instance group -> template edge for the
+// template referenced by attrs[key]; via records where the reference was found
+func (g *Graph) linkGCPInstanceTemplate(mig *Node, attrs map[string]interface{}, via string) {
+	link := getAttributeString(attrs, "instance_template")
+	if templateNode := g.findGCPNodeByLink("google_compute_instance_template", link, "self_link", "id"); templateNode != nil {
+		g.addEdge(mig, templateNode, "uses", withVia(emptyMetadata, via))
+	}
+}
+
+// findGCPNodeByLink looks up a node of the given type whose attrKeys hold the resource
+// referenced by link. GCP references may be full self-link URLs or relative resource
+// paths (projects/...), so both sides are compared without the API prefix.
+func (g *Graph) findGCPNodeByLink(resourceType, link string, attrKeys ...string) *Node {
+	path := gcpResourcePath(link)
+	if path == "" {
+		return nil
+	}
+
+	for _, node := range g.Nodes {
+		if node.Type != resourceType {
+			continue
+		}
+		for _, attrKey := range attrKeys {
+			if gcpResourcePath(getAttributeString(node.Attributes, attrKey)) == path {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// gcpResourcePath strips the scheme, host and API version from a GCP self-link,
+// e.g. https://www.googleapis.com/compute/v1/projects/p/global/instanceTemplates/t
+// becomes projects/p/global/instanceTemplates/t. Relative paths are returned unchanged.
+func gcpResourcePath(link string) string {
+	if i := strings.Index(link, "/projects/"); i >= 0 {
+		return link[i+1:]
+	}
+	return link
+}
+
+// gcpForwardingRuleTypes lists the GCP forwarding rule types whose ports label their edges
+var gcpForwardingRuleTypes = map[string]bool{
+	"google_compute_forwarding_rule":        true,
+	"google_compute_global_forwarding_rule": true,
+}
+
+// appServiceTypes lists the Azure app types hosted on an App Service Plan
+var appServiceTypes = map[string]bool{
+	"azurerm_app_service":   true,
+	"azurerm_linux_web_app": true,
+	"azurerm_function_app":  true,
+}
+
+// appServicePlanTypes lists the Azure App Service Plan resource types
+var appServicePlanTypes = map[string]bool{
+	"azurerm_app_service_plan": true,
+	"azurerm_service_plan":     true,
+}
+
+// appServicePlanAttributes lists the app attributes that point at an App Service Plan
+var appServicePlanAttributes = []string{"service_plan_id", "app_service_plan_id"}
+
+// linkSubnetNSGAssociation adds an NSG -> subnet edge for an Azure subnet/NSG association.
+// self is the association's own node, if it is part of the graph.
+func (g *Graph) linkSubnetNSGAssociation(attributes map[string]interface{}, self *Node) {
+	subnetNode := g.findAzureNodeByID(getAttributeString(attributes, "subnet_id"), self)
+	nsgNode := g.findAzureNodeByID(getAttributeString(attributes, "network_security_group_id"), self)
+
+	if subnetNode != nil && nsgNode != nil {
+		g.addEdge(nsgNode, subnetNode, "protects", withVia(emptyMetadata, "subnet_id"))
+	}
+}
+
+// addSecurityGroupRuleEdge links two security groups connected by a rule, recording via as the source attribute.
+// Ingress rules produce peer -> owner ("allows_from"), egress rules owner -> peer ("allows_to").
+func (g *Graph) addSecurityGroupRuleEdge(owner, peer *Node, egress bool, rule map[string]interface{}, via string) {
+	metadata := withVia(securityRuleMetadata(rule), via)
+	if egress {
+		g.addEdge(owner, peer, "allows_to", metadata)
+		return
+	}
+	g.addEdge(peer, owner, "allows_from", metadata)
+}
+
+// securityRuleMetadata extracts port and protocol information from an AWS security group rule
+func securityRuleMetadata(rule map[string]interface{}) map[string]string {
+	metadata := make(map[string]string)
+
+	fromPort, hasFrom := parser.GetStringAttribute(rule, "from_port")
+	toPort, hasTo := parser.GetStringAttribute(rule, "to_port")
+	if hasFrom {
+		if hasTo && toPort != fromPort {
+			metadata["port"] = fromPort + "-" + toPort
+		} else {
+			metadata["port"] = fromPort
+		}
+	}
+	if protocol, ok := parser.GetStringAttribute(rule, "protocol"); ok {
+		metadata["protocol"] = protocol
+	}
+
+	if len(metadata) == 0 {
+		return emptyMetadata
+	}
+	return metadata
+}
+
 // Helper functions
 func getAttributeString(attrs map[string]interface{}, key string) string {
 	if val, ok := attrs[key]; ok {
@@ -386,3 +1138,47 @@ func (g *Graph) findNodeByAttributeValue(attrKey, attrValue string) *Node {
 	}
 	return nil
 }
+
+// findAzureNodeByID looks up an Azure resource by its ID, ignoring exclude.
+// Azure resource IDs are case-insensitive and state files are inconsistent about casing
+// (e.g., "/resourceGroups/" vs "/resourcegroups/"), so a failed exact match falls back
+// to a case-insensitive comparison. Association resources often share the ID of the
+// resource they attach to, so callers pass themselves as exclude.
+func (g *Graph) findAzureNodeByID(id string, exclude *Node) *Node {
+	if id == "" {
+		return nil
+	}
+
+	if node := g.findNodeByAttributeValue("id", id); node != nil && node != exclude {
+		return node
+	}
+
+	for _, node := range g.Nodes {
+		if node != exclude && node.Provider == "azure" && strings.EqualFold(getAttributeString(node.Attributes, "id"), id) {
+			return node
+		}
+	}
+	return nil
+}
+
+// findNodeOfType looks up a node of a specific resource type by attribute value.
+// Uses the O(1) index when the indexed node has the expected type, otherwise scans
+// nodes of that type (attribute values such as names are not unique across types).
+func (g *Graph) findNodeOfType(resourceType, attrKey, attrValue string) *Node {
+	if attrValue == "" {
+		return nil
+	}
+
+	if index, ok := g.attributeIndex[attrKey]; ok {
+		if node, found := index[attrValue]; found && node.Type == resourceType {
+			return node
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if node.Type == resourceType && getAttributeString(node.Attributes, attrKey) == attrValue {
+			return node
+		}
+	}
+	return nil
+}
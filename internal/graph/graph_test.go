@@ -2,6 +2,9 @@ package graph
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/ankek/terraform-provider-cartography/internal/parser"
@@ -195,6 +198,65 @@ func TestFindNodeByAttributeValue(t *testing.T) {
 	}
 }
 
+func TestBuildAttributeIndex_NumbersAndLists(t *testing.T) {
+	longList := make([]interface{}, maxIndexedListLength+1)
+	for i := range longList {
+		longList[i] = fmt.Sprintf("10.0.%d.0/24", i)
+	}
+
+	resources := []parser.Resource{
+		{
+			ID: "digitalocean_droplet.web", Type: "digitalocean_droplet", Name: "web", Provider: "digitalocean",
+			Attributes: map[string]interface{}{"id": float64(350123456), "name": "web"},
+		},
+		{
+			ID: "digitalocean_loadbalancer.public", Type: "digitalocean_loadbalancer", Name: "public", Provider: "digitalocean",
+			Attributes: map[string]interface{}{"id": "lb-4f2a9c", "droplet_ids": []interface{}{float64(350123456)}},
+		},
+		{
+			ID: "aws_instance.app", Type: "aws_instance", Name: "app", Provider: "aws",
+			Attributes: map[string]interface{}{"id": "i-app", "security_groups": []interface{}{"sg-web", "sg-ssh"}, "cidr_blocks": longList},
+		},
+		{
+			ID: "aws_instance.bastion", Type: "aws_instance", Name: "bastion", Provider: "aws",
+			Attributes: map[string]interface{}{"id": "i-bastion", "security_groups": "sg-web"},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+	droplet := g.Nodes["digitalocean_droplet.web"]
+
+	// The numeric id is indexed in plain decimal form, so the lookup hits the index
+	if got := g.attributeIndex["id"]["350123456"]; got != droplet {
+		t.Errorf("attributeIndex[id][350123456] = %v, want the droplet", got)
+	}
+	if got := g.findNodeByAttributeValue("id", "350123456"); got != droplet {
+		t.Errorf("findNodeByAttributeValue(id, 350123456) = %v, want the droplet", got)
+	}
+
+	// The load balancer's numeric droplet_ids element references the droplet
+	found := false
+	for _, edge := range g.Edges {
+		if edge.From.ID == "digitalocean_loadbalancer.public" && edge.To == droplet && edge.Relationship == "routes_to" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a routes_to edge from the load balancer to the droplet")
+	}
+
+	// List elements are indexed, but never replace a value held directly
+	if got := g.findNodeByAttributeValue("security_groups", "sg-ssh"); got != g.Nodes["aws_instance.app"] {
+		t.Errorf("findNodeByAttributeValue(security_groups, sg-ssh) = %v, want aws_instance.app", got)
+	}
+	if got := g.attributeIndex["security_groups"]["sg-web"]; got != g.Nodes["aws_instance.bastion"] {
+		t.Errorf("attributeIndex[security_groups][sg-web] = %v, want aws_instance.bastion", got)
+	}
+	if _, indexed := g.attributeIndex["cidr_blocks"]; indexed {
+		t.Errorf("lists longer than %d elements should not be indexed", maxIndexedListLength)
+	}
+}
+
 func TestInferRelationship(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -238,6 +300,48 @@ func TestInferRelationship(t *testing.T) {
 			toType:   parser.ResourceTypeDatabase,
 			want:     "connects_to_db",
 		},
+		{
+			name:     "CDN to storage origin",
+			fromType: parser.ResourceTypeCDN,
+			toType:   parser.ResourceTypeStorage,
+			want:     "caches",
+		},
+		{
+			name:     "CDN to load balancer origin",
+			fromType: parser.ResourceTypeCDN,
+			toType:   parser.ResourceTypeLoadBalancer,
+			want:     "serves",
+		},
+		{
+			name:     "CDN to compute origin",
+			fromType: parser.ResourceTypeCDN,
+			toType:   parser.ResourceTypeCompute,
+			want:     "serves",
+		},
+		{
+			name:     "DNS to load balancer",
+			fromType: parser.ResourceTypeDNS,
+			toType:   parser.ResourceTypeLoadBalancer,
+			want:     "resolves_to",
+		},
+		{
+			name:     "DNS to compute",
+			fromType: parser.ResourceTypeDNS,
+			toType:   parser.ResourceTypeCompute,
+			want:     "resolves_to",
+		},
+		{
+			name:     "DNS to CDN",
+			fromType: parser.ResourceTypeDNS,
+			toType:   parser.ResourceTypeCDN,
+			want:     "resolves_to",
+		},
+		{
+			name:     "DNS to unrelated type",
+			fromType: parser.ResourceTypeDNS,
+			toType:   parser.ResourceTypeDatabase,
+			want:     "depends_on",
+		},
 		{
 			name:     "default relationship",
 			fromType: parser.ResourceTypeCompute,
@@ -308,6 +412,63 @@ func TestExtractConnectionMetadata(t *testing.T) {
 			checkKey:   "port",
 			checkValue: "80",
 		},
+		{
+			name: "azure lb rule protocol",
+			from: &Node{
+				Provider: "azure",
+				Type:     "azurerm_lb_rule",
+				Attributes: map[string]interface{}{
+					"frontend_port": float64(443),
+					"backend_port":  float64(8443),
+					"protocol":      "Tcp",
+				},
+			},
+			to:         &Node{},
+			checkKey:   "protocol",
+			checkValue: "Tcp",
+		},
+		{
+			name: "gcp forwarding rule port range",
+			from: &Node{
+				Provider: "gcp",
+				Type:     "google_compute_global_forwarding_rule",
+				Attributes: map[string]interface{}{
+					"port_range":  "443-443",
+					"ip_protocol": "TCP",
+				},
+			},
+			to:         &Node{},
+			checkKey:   "port",
+			checkValue: "443",
+		},
+		{
+			name: "gcp forwarding rule port list",
+			from: &Node{
+				Provider: "gcp",
+				Type:     "google_compute_forwarding_rule",
+				Attributes: map[string]interface{}{
+					"port_range": "",
+					"ports":      []interface{}{"80", "8080"},
+				},
+			},
+			to:         &Node{},
+			checkKey:   "port",
+			checkValue: "80,8080",
+		},
+		{
+			name: "gcp forwarding rule protocol",
+			from: &Node{
+				Provider: "gcp",
+				Type:     "google_compute_forwarding_rule",
+				Attributes: map[string]interface{}{
+					"port_range":  "8000-8100",
+					"ip_protocol": "UDP",
+				},
+			},
+			to:         &Node{},
+			checkKey:   "protocol",
+			checkValue: "UDP",
+		},
 	}
 
 	for _, tt := range tests {
@@ -348,3 +509,1685 @@ func TestEdgeDuplication(t *testing.T) {
 		t.Errorf("addEdge() created duplicate edge, got %d edges, want 1", len(g.Edges))
 	}
 }
+
+func TestDetectImplicitConnections_RDSSubnetGroup(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:       "aws_db_instance.main",
+			Type:     "aws_db_instance",
+			Name:     "main",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":                   "db-main",
+				"db_subnet_group_name": "main-db-subnets",
+			},
+		},
+		{
+			ID:       "aws_db_subnet_group.main",
+			Type:     "aws_db_subnet_group",
+			Name:     "main",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":         "main-db-subnets",
+				"name":       "main-db-subnets",
+				"subnet_ids": []interface{}{"subnet-a", "subnet-b"},
+			},
+		},
+		{
+			ID:         "aws_subnet.a",
+			Type:       "aws_subnet",
+			Name:       "a",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-a"},
+		},
+		{
+			ID:         "aws_subnet.b",
+			Type:       "aws_subnet",
+			Name:       "b",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-b"},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]string{
+		"aws_db_instance.main->aws_db_subnet_group.main": "deployed_in",
+		"aws_db_subnet_group.main->aws_subnet.a":         "contains",
+		"aws_db_subnet_group.main->aws_subnet.b":         "contains",
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		relationship, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, relationship)
+		}
+	}
+}
+
+func TestDetectImplicitConnections_SecurityGroupRules(t *testing.T) {
+	appSG := parser.Resource{
+		ID:         "aws_security_group.app",
+		Type:       "aws_security_group",
+		Name:       "app",
+		Provider:   "aws",
+		Attributes: map[string]interface{}{"id": "sg-app"},
+	}
+
+	t.Run("separate rule resource", func(t *testing.T) {
+		resources := []parser.Resource{
+			appSG,
+			{
+				ID:         "aws_security_group.db",
+				Type:       "aws_security_group",
+				Name:       "db",
+				Provider:   "aws",
+				Attributes: map[string]interface{}{"id": "sg-db"},
+			},
+			{
+				ID:       "aws_security_group_rule.db_from_app",
+				Type:     "aws_security_group_rule",
+				Name:     "db_from_app",
+				Provider: "aws",
+				Attributes: map[string]interface{}{
+					"type":                     "ingress",
+					"security_group_id":        "sg-db",
+					"source_security_group_id": "sg-app",
+					"from_port":                float64(5432),
+					"to_port":                  float64(5432),
+					"protocol":                 "tcp",
+				},
+			},
+		}
+
+		g := BuildGraph(context.Background(), resources)
+
+		edge := findEdge(g, "aws_security_group.app", "aws_security_group.db")
+		if edge == nil {
+			t.Fatal("expected allows_from edge from app SG to db SG")
+		}
+		if edge.Relationship != "allows_from" {
+			t.Errorf("Relationship = %s, want allows_from", edge.Relationship)
+		}
+		if edge.Metadata["port"] != "5432" || edge.Metadata["protocol"] != "tcp" {
+			t.Errorf("Metadata = %v, want port 5432 and protocol tcp", edge.Metadata)
+		}
+	})
+
+	t.Run("inline ingress block", func(t *testing.T) {
+		resources := []parser.Resource{
+			appSG,
+			{
+				ID:       "aws_security_group.db",
+				Type:     "aws_security_group",
+				Name:     "db",
+				Provider: "aws",
+				Attributes: map[string]interface{}{
+					"id": "sg-db",
+					"ingress": []interface{}{
+						map[string]interface{}{
+							"from_port":       float64(3306),
+							"to_port":         float64(3306),
+							"protocol":        "tcp",
+							"security_groups": []interface{}{"sg-app"},
+						},
+					},
+				},
+			},
+		}
+
+		g := BuildGraph(context.Background(), resources)
+
+		edge := findEdge(g, "aws_security_group.app", "aws_security_group.db")
+		if edge == nil {
+			t.Fatal("expected allows_from edge from app SG to db SG")
+		}
+		if edge.Relationship != "allows_from" {
+			t.Errorf("Relationship = %s, want allows_from", edge.Relationship)
+		}
+		if edge.Metadata["port"] != "3306" {
+			t.Errorf("Metadata[port] = %s, want 3306", edge.Metadata["port"])
+		}
+	})
+}
+
+// findEdge returns the first edge between two node IDs, or nil
+func findEdge(g *Graph, fromID, toID string) *Edge {
+	for _, edge := range g.Edges {
+		if edge.From.ID == fromID && edge.To.ID == toID {
+			return edge
+		}
+	}
+	return nil
+}
+
+func TestBuildGraphWithOptions_DetectImplicitDisabled(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_db_subnet_group.main",
+			Type:       "aws_db_subnet_group",
+			Name:       "main",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "main", "name": "main"},
+		},
+		{
+			ID:       "aws_db_instance.db",
+			Type:     "aws_db_instance",
+			Name:     "db",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":                   "db",
+				"db_subnet_group_name": "main",
+			},
+		},
+		{
+			ID:           "aws_instance.web",
+			Type:         "aws_instance",
+			Name:         "web",
+			Provider:     "aws",
+			Attributes:   map[string]interface{}{"id": "i-web"},
+			Dependencies: []string{"aws_db_instance.db"},
+		},
+	}
+
+	withImplicit := BuildGraphWithOptions(context.Background(), resources, GraphOptions{DetectImplicit: true})
+	if findEdge(withImplicit, "aws_db_instance.db", "aws_db_subnet_group.main") == nil {
+		t.Fatal("expected implicit subnet group edge when DetectImplicit is enabled")
+	}
+
+	explicitOnly := BuildGraphWithOptions(context.Background(), resources, GraphOptions{DetectImplicit: false})
+	if len(explicitOnly.Edges) != 1 {
+		t.Fatalf("expected only the explicit dependency edge, got %d edges", len(explicitOnly.Edges))
+	}
+	if findEdge(explicitOnly, "aws_instance.web", "aws_db_instance.db") == nil {
+		t.Error("expected explicit dependency edge to be kept")
+	}
+	if findEdge(explicitOnly, "aws_db_instance.db", "aws_db_subnet_group.main") != nil {
+		t.Error("implicit subnet group edge should be absent when DetectImplicit is disabled")
+	}
+}
+
+func TestEdgeDuplication_DistinctPorts(t *testing.T) {
+	g := &Graph{
+		Nodes: make(map[string]*Node),
+		Edges: make([]*Edge, 0),
+	}
+
+	firewall := &Node{ID: "firewall", Edges: make([]*Edge, 0)}
+	server := &Node{ID: "server", Edges: make([]*Edge, 0)}
+
+	g.Nodes["firewall"] = firewall
+	g.Nodes["server"] = server
+
+	g.addEdge(firewall, server, "protects", map[string]string{"port": "22", "protocol": "tcp"})
+	g.addEdge(firewall, server, "protects", map[string]string{"port": "443", "protocol": "tcp"})
+	// Same port again should still be deduplicated
+	g.addEdge(firewall, server, "protects", map[string]string{"port": "443", "protocol": "tcp"})
+
+	if len(g.Edges) != 2 {
+		t.Fatalf("addEdge() got %d edges, want 2", len(g.Edges))
+	}
+
+	ports := map[string]bool{}
+	for _, edge := range g.Edges {
+		ports[edge.Metadata["port"]] = true
+	}
+	if !ports["22"] || !ports["443"] {
+		t.Errorf("expected edges for ports 22 and 443, got %v", ports)
+	}
+}
+
+func TestDetectImplicitConnections_AzureIDCaseInsensitive(t *testing.T) {
+	subnetID := "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/app"
+	nsgID := "/subscriptions/0000/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/app-nsg"
+
+	resources := []parser.Resource{
+		{
+			ID:         "azurerm_subnet.app",
+			Type:       "azurerm_subnet",
+			Name:       "app",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": subnetID},
+		},
+		{
+			ID:         "azurerm_network_security_group.app",
+			Type:       "azurerm_network_security_group",
+			Name:       "app",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": nsgID},
+		},
+		{
+			ID:       "azurerm_subnet_network_security_group_association.app",
+			Type:     "azurerm_subnet_network_security_group_association",
+			Name:     "app",
+			Provider: "azure",
+			Attributes: map[string]interface{}{
+				"id":                        subnetID,
+				"subnet_id":                 strings.ToLower(subnetID),
+				"network_security_group_id": strings.Replace(nsgID, "/resourceGroups/", "/resourcegroups/", 1),
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	edge := findEdge(g, "azurerm_network_security_group.app", "azurerm_subnet.app")
+	if edge == nil {
+		t.Fatal("expected NSG -> subnet edge despite mismatched ID casing")
+	}
+	if edge.Relationship != "protects" {
+		t.Errorf("Relationship = %s, want protects", edge.Relationship)
+	}
+}
+
+func TestDetectImplicitConnections_RouteTables(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_route_table.public",
+			Type:       "aws_route_table",
+			Name:       "public",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "rtb-public"},
+		},
+		{
+			ID:         "aws_subnet.a",
+			Type:       "aws_subnet",
+			Name:       "a",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-a"},
+		},
+		{
+			ID:         "aws_subnet.b",
+			Type:       "aws_subnet",
+			Name:       "b",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-b"},
+		},
+		{
+			ID:       "aws_route_table_association.a",
+			Type:     "aws_route_table_association",
+			Name:     "a",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":             "rtbassoc-a",
+				"route_table_id": "rtb-public",
+				"subnet_id":      "subnet-a",
+			},
+		},
+		{
+			ID:       "aws_route_table_association.b",
+			Type:     "aws_route_table_association",
+			Name:     "b",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":             "rtbassoc-b",
+				"route_table_id": "rtb-public",
+				"subnet_id":      "subnet-b",
+			},
+		},
+		{
+			ID:         "aws_internet_gateway.main",
+			Type:       "aws_internet_gateway",
+			Name:       "main",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "igw-main"},
+		},
+		{
+			ID:         "aws_nat_gateway.main",
+			Type:       "aws_nat_gateway",
+			Name:       "main",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "nat-main"},
+		},
+		{
+			// No id attribute: must not be mistaken for the target of a route lacking one
+			ID:         "aws_s3_bucket.logs",
+			Type:       "aws_s3_bucket",
+			Name:       "logs",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"bucket": "logs"},
+		},
+		{
+			ID:       "aws_route.internet",
+			Type:     "aws_route",
+			Name:     "internet",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":             "r-rtb-public-internet",
+				"route_table_id": "rtb-public",
+				"gateway_id":     "igw-main",
+			},
+		},
+		{
+			ID:       "aws_route.nat",
+			Type:     "aws_route",
+			Name:     "nat",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":             "r-rtb-public-nat",
+				"route_table_id": "rtb-public",
+				"nat_gateway_id": "nat-main",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	for _, id := range []string{"aws_route_table_association.a", "aws_route_table_association.b"} {
+		if _, ok := g.Nodes[id]; ok {
+			t.Errorf("association %s should not be drawn as a node", id)
+		}
+	}
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_route_table.public->aws_subnet.a":          {"routes", "subnet_id"},
+		"aws_route_table.public->aws_subnet.b":          {"routes", "subnet_id"},
+		"aws_route.internet->aws_internet_gateway.main": {"routes_to", "gateway_id"},
+		"aws_route.nat->aws_nat_gateway.main":           {"routes_to", "nat_gateway_id"},
+		// Routes have no specific detector for their table, so the generic reference pass links them
+		"aws_route.internet->aws_route_table.public": {"references", "route_table_id"},
+		"aws_route.nat->aws_route_table.public":      {"references", "route_table_id"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+}
+
+func TestDetectImplicitConnections_AlicloudVSwitch(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "alicloud_vpc.main",
+			Type:       "alicloud_vpc",
+			Name:       "main",
+			Provider:   "alicloud",
+			Attributes: map[string]interface{}{"id": "vpc-abc123"},
+		},
+		{
+			ID:       "alicloud_vswitch.app",
+			Type:     "alicloud_vswitch",
+			Name:     "app",
+			Provider: "alicloud",
+			Attributes: map[string]interface{}{
+				"id":     "vsw-def456",
+				"vpc_id": "vpc-abc123",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	if len(g.Edges) != 1 {
+		t.Fatalf("BuildGraph() got %d edges, want 1", len(g.Edges))
+	}
+	edge := g.Edges[0]
+	if edge.From.ID != "alicloud_vswitch.app" || edge.To.ID != "alicloud_vpc.main" {
+		t.Errorf("edge = %s -> %s, want alicloud_vswitch.app -> alicloud_vpc.main", edge.From.ID, edge.To.ID)
+	}
+	if edge.Relationship != "member_of" {
+		t.Errorf("edge relationship = %s, want member_of", edge.Relationship)
+	}
+	if edge.Metadata["via"] != "vpc_id" {
+		t.Errorf("edge via = %q, want %q", edge.Metadata["via"], "vpc_id")
+	}
+}
+
+func TestDetectImplicitConnections_ContainerClusters(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:       "aws_ecs_cluster.main",
+			Type:     "aws_ecs_cluster",
+			Name:     "main",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":   "arn:aws:ecs:us-east-1:123456789012:cluster/main",
+				"arn":  "arn:aws:ecs:us-east-1:123456789012:cluster/main",
+				"name": "main",
+			},
+		},
+		{
+			ID:       "aws_ecs_service.api",
+			Type:     "aws_ecs_service",
+			Name:     "api",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":      "arn:aws:ecs:us-east-1:123456789012:service/main/api",
+				"cluster": "arn:aws:ecs:us-east-1:123456789012:cluster/main",
+			},
+		},
+		{
+			ID:       "aws_ecs_service.worker",
+			Type:     "aws_ecs_service",
+			Name:     "worker",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":      "arn:aws:ecs:us-east-1:123456789012:service/main/worker",
+				"cluster": "main",
+			},
+		},
+		{
+			ID:       "aws_eks_cluster.prod",
+			Type:     "aws_eks_cluster",
+			Name:     "prod",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":   "prod",
+				"name": "prod",
+			},
+		},
+		{
+			ID:       "aws_eks_node_group.workers",
+			Type:     "aws_eks_node_group",
+			Name:     "workers",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":           "prod:workers",
+				"cluster_name": "prod",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_ecs_service.api->aws_ecs_cluster.main":        {"deployed_in", "cluster"},
+		"aws_ecs_service.worker->aws_ecs_cluster.main":     {"deployed_in", "cluster"},
+		"aws_eks_node_group.workers->aws_eks_cluster.prod": {"member_of", "cluster_name"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+
+	if got := g.Nodes["aws_ecs_service.api"].ResourceType; got != parser.ResourceTypeContainer {
+		t.Errorf("aws_ecs_service ResourceType = %v, want ResourceTypeContainer", got)
+	}
+}
+
+func TestDetectImplicitConnections_AppServicePlan(t *testing.T) {
+	planID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Web/serverFarms/plan"
+
+	resources := []parser.Resource{
+		{
+			ID:         "azurerm_service_plan.main",
+			Type:       "azurerm_service_plan",
+			Name:       "main",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": planID},
+		},
+		{
+			ID:       "azurerm_linux_web_app.web",
+			Type:     "azurerm_linux_web_app",
+			Name:     "web",
+			Provider: "azure",
+			Attributes: map[string]interface{}{
+				"id":              "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Web/sites/web",
+				"service_plan_id": planID,
+			},
+		},
+		{
+			ID:       "azurerm_function_app.jobs",
+			Type:     "azurerm_function_app",
+			Name:     "jobs",
+			Provider: "azure",
+			Attributes: map[string]interface{}{
+				"id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Web/sites/jobs",
+				// Azure IDs are case-insensitive
+				"app_service_plan_id": strings.ToLower(planID),
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]string{
+		"azurerm_linux_web_app.web->azurerm_service_plan.main": "service_plan_id",
+		"azurerm_function_app.jobs->azurerm_service_plan.main": "app_service_plan_id",
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		via, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != "hosted_on" {
+			t.Errorf("edge %s relationship = %s, want hosted_on", key, edge.Relationship)
+		}
+		if edge.Metadata["via"] != via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], via)
+		}
+	}
+
+	if got := g.Nodes["azurerm_service_plan.main"].ResourceType; got != parser.ResourceTypeCompute {
+		t.Errorf("azurerm_service_plan ResourceType = %v, want ResourceTypeCompute", got)
+	}
+}
+
+func TestDetectImplicitConnections_GCPInstanceGroups(t *testing.T) {
+	const api = "https://www.googleapis.com/compute/v1/"
+
+	resources := []parser.Resource{
+		{
+			ID:       "google_compute_instance_template.web",
+			Type:     "google_compute_instance_template",
+			Name:     "web",
+			Provider: "gcp",
+			Attributes: map[string]interface{}{
+				"id":        "projects/demo/global/instanceTemplates/web-20240101",
+				"self_link": api + "projects/demo/global/instanceTemplates/web-20240101",
+			},
+		},
+		{
+			ID:       "google_compute_instance_template.canary",
+			Type:     "google_compute_instance_template",
+			Name:     "canary",
+			Provider: "gcp",
+			Attributes: map[string]interface{}{
+				"id":        "projects/demo/global/instanceTemplates/canary",
+				"self_link": api + "projects/demo/global/instanceTemplates/canary",
+			},
+		},
+		{
+			ID:       "google_compute_instance_group_manager.web",
+			Type:     "google_compute_instance_group_manager",
+			Name:     "web",
+			Provider: "gcp",
+			Attributes: map[string]interface{}{
+				"id":             "projects/demo/zones/us-central1-a/instanceGroupManagers/web",
+				"self_link":      api + "projects/demo/zones/us-central1-a/instanceGroupManagers/web",
+				"instance_group": api + "projects/demo/zones/us-central1-a/instanceGroups/web",
+				"version": []interface{}{
+					// Full self-link on one version, relative path on the other
+					map[string]interface{}{"name": "primary", "instance_template": api + "projects/demo/global/instanceTemplates/web-20240101"},
+					map[string]interface{}{"name": "canary", "instance_template": "projects/demo/global/instanceTemplates/canary"},
+				},
+			},
+		},
+		{
+			ID:       "google_compute_backend_service.web",
+			Type:     "google_compute_backend_service",
+			Name:     "web",
+			Provider: "gcp",
+			Attributes: map[string]interface{}{
+				"id": "projects/demo/global/backendServices/web",
+				"backend": []interface{}{
+					map[string]interface{}{"group": api + "projects/demo/zones/us-central1-a/instanceGroups/web"},
+				},
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"google_compute_instance_group_manager.web->google_compute_instance_template.web":    {"uses", "version.instance_template"},
+		"google_compute_instance_group_manager.web->google_compute_instance_template.canary": {"uses", "version.instance_template"},
+		"google_compute_backend_service.web->google_compute_instance_group_manager.web":      {"routes_to", "backend.group"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+
+	if got := g.Nodes["google_compute_instance_group_manager.web"].ResourceType; got != parser.ResourceTypeCompute {
+		t.Errorf("google_compute_instance_group_manager ResourceType = %v, want ResourceTypeCompute", got)
+	}
+}
+
+func TestDetectImplicitConnections_CloudFrontOrigins(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:       "aws_s3_bucket.assets",
+			Type:     "aws_s3_bucket",
+			Name:     "assets",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":                          "assets-bucket",
+				"bucket":                      "assets-bucket",
+				"bucket_regional_domain_name": "assets-bucket.s3.eu-west-1.amazonaws.com",
+			},
+		},
+		{
+			ID:       "aws_s3_bucket.logs",
+			Type:     "aws_s3_bucket",
+			Name:     "logs",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":     "logs-bucket",
+				"bucket": "logs-bucket",
+			},
+		},
+		{
+			ID:       "aws_lb.api",
+			Type:     "aws_lb",
+			Name:     "api",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":       "arn:aws:elasticloadbalancing:eu-west-1:123456789012:loadbalancer/app/api/abc",
+				"dns_name": "api-123.eu-west-1.elb.amazonaws.com",
+			},
+		},
+		{
+			ID:       "aws_cloudfront_distribution.site",
+			Type:     "aws_cloudfront_distribution",
+			Name:     "site",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id": "E1ABCDEF",
+				"origin": []interface{}{
+					map[string]interface{}{"origin_id": "s3", "domain_name": "assets-bucket.s3.eu-west-1.amazonaws.com"},
+					map[string]interface{}{"origin_id": "alb", "domain_name": "API-123.eu-west-1.elb.amazonaws.com"},
+					map[string]interface{}{"origin_id": "external", "domain_name": "example.com"},
+				},
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_cloudfront_distribution.site->aws_s3_bucket.assets": {"caches", "origin.domain_name"},
+		"aws_cloudfront_distribution.site->aws_lb.api":           {"serves", "origin.domain_name"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+
+	if got := g.Nodes["aws_cloudfront_distribution.site"].ResourceType; got != parser.ResourceTypeCDN {
+		t.Errorf("aws_cloudfront_distribution ResourceType = %v, want ResourceTypeCDN", got)
+	}
+
+	// A bucket without domain attributes still matches on its name
+	if node, relationship := g.findCloudFrontOrigin("logs-bucket.s3.amazonaws.com"); node == nil || node.ID != "aws_s3_bucket.logs" || relationship != "caches" {
+		t.Errorf("findCloudFrontOrigin() = %v, %q, want aws_s3_bucket.logs, caches", node, relationship)
+	}
+}
+
+func TestBuildGraphWithOptions_IncludeAssociations(t *testing.T) {
+	resources := []parser.Resource{
+		{ID: "aws_route_table.public", Type: "aws_route_table", Name: "public", Provider: "aws", Attributes: map[string]interface{}{"id": "rtb-public"}},
+		{ID: "aws_subnet.a", Type: "aws_subnet", Name: "a", Provider: "aws", Attributes: map[string]interface{}{"id": "subnet-a"}},
+		{
+			ID:           "aws_route_table_association.a",
+			Type:         "aws_route_table_association",
+			Name:         "a",
+			Provider:     "aws",
+			Dependencies: []string{"aws_route_table.public", "aws_subnet.a"},
+			Attributes: map[string]interface{}{
+				"id":             "rtbassoc-a",
+				"route_table_id": "rtb-public",
+				"subnet_id":      "subnet-a",
+			},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		includeAssociations bool
+		wantNode            bool
+		wantEdges           []string
+	}{
+		{
+			name:      "associations folded into edges by default",
+			wantEdges: []string{"aws_route_table.public->aws_subnet.a"},
+		},
+		{
+			name:                "associations drawn as nodes",
+			includeAssociations: true,
+			wantNode:            true,
+			wantEdges: []string{
+				"aws_route_table_association.a->aws_route_table.public",
+				"aws_route_table_association.a->aws_subnet.a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultGraphOptions()
+			opts.IncludeAssociations = tt.includeAssociations
+			g := BuildGraphWithOptions(context.Background(), resources, opts)
+
+			if _, ok := g.Nodes["aws_route_table_association.a"]; ok != tt.wantNode {
+				t.Errorf("association node present = %v, want %v", ok, tt.wantNode)
+			}
+
+			var got []string
+			for _, edge := range g.Edges {
+				got = append(got, edge.From.ID+"->"+edge.To.ID)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.wantEdges, ",") {
+				t.Errorf("edges = %v, want %v", got, tt.wantEdges)
+			}
+		})
+	}
+}
+
+func TestReachable(t *testing.T) {
+	resources := []parser.Resource{
+		{ID: "aws_route53_record.www", Type: "aws_route53_record", Name: "www", Provider: "aws", Dependencies: []string{"aws_lb.main"}},
+		{ID: "aws_lb.main", Type: "aws_lb", Name: "main", Provider: "aws", Dependencies: []string{"aws_instance.web"}},
+		{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws", Dependencies: []string{"aws_db_instance.main"}},
+		{ID: "aws_db_instance.main", Type: "aws_db_instance", Name: "main", Provider: "aws"},
+		{ID: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs", Provider: "aws"},
+	}
+
+	g := BuildGraphWithOptions(context.Background(), resources, GraphOptions{DetectImplicit: false})
+
+	tests := []struct {
+		name      string
+		startID   string
+		opts      TraversalOptions
+		wantNodes []string
+		wantEdges int
+	}{
+		{
+			name:      "depth zero returns only the start node",
+			startID:   "aws_lb.main",
+			opts:      TraversalOptions{MaxDepth: 0},
+			wantNodes: []string{"aws_lb.main"},
+			wantEdges: 0,
+		},
+		{
+			name:      "depth one follows outgoing edges",
+			startID:   "aws_lb.main",
+			opts:      TraversalOptions{MaxDepth: 1},
+			wantNodes: []string{"aws_instance.web", "aws_lb.main"},
+			wantEdges: 1,
+		},
+		{
+			name:      "unlimited depth",
+			startID:   "aws_lb.main",
+			opts:      TraversalOptions{MaxDepth: -1},
+			wantNodes: []string{"aws_db_instance.main", "aws_instance.web", "aws_lb.main"},
+			wantEdges: 2,
+		},
+		{
+			name:      "bidirectional depth one",
+			startID:   "aws_lb.main",
+			opts:      TraversalOptions{MaxDepth: 1, Bidirectional: true},
+			wantNodes: []string{"aws_instance.web", "aws_lb.main", "aws_route53_record.www"},
+			wantEdges: 2,
+		},
+		{
+			name:      "bidirectional unlimited depth",
+			startID:   "aws_lb.main",
+			opts:      TraversalOptions{MaxDepth: -1, Bidirectional: true},
+			wantNodes: []string{"aws_db_instance.main", "aws_instance.web", "aws_lb.main", "aws_route53_record.www"},
+			wantEdges: 3,
+		},
+		{
+			name:      "unknown start node",
+			startID:   "aws_lb.missing",
+			opts:      TraversalOptions{MaxDepth: -1, Bidirectional: true},
+			wantNodes: []string{},
+			wantEdges: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := g.ReachableWithOptions(tt.startID, tt.opts)
+
+			gotNodes := make([]string, 0, len(sub.Nodes))
+			for id := range sub.Nodes {
+				gotNodes = append(gotNodes, id)
+			}
+			sort.Strings(gotNodes)
+
+			if strings.Join(gotNodes, ",") != strings.Join(tt.wantNodes, ",") {
+				t.Errorf("ReachableWithOptions() nodes = %v, want %v", gotNodes, tt.wantNodes)
+			}
+			if len(sub.Edges) != tt.wantEdges {
+				t.Errorf("ReachableWithOptions() got %d edges, want %d", len(sub.Edges), tt.wantEdges)
+			}
+			for _, edge := range sub.Edges {
+				if sub.Nodes[edge.From.ID] != edge.From || sub.Nodes[edge.To.ID] != edge.To {
+					t.Errorf("edge %s -> %s should reference nodes of the new graph", edge.From.ID, edge.To.ID)
+				}
+			}
+		})
+	}
+
+	// Reachable follows outgoing edges only
+	if sub := g.Reachable("aws_instance.web", 1); len(sub.Nodes) != 2 || sub.Nodes["aws_db_instance.main"] == nil {
+		t.Errorf("Reachable() got %d nodes, want aws_instance.web and aws_db_instance.main", len(sub.Nodes))
+	}
+
+	// Filter keeps the selected nodes and the edges between them
+	filtered := g.Filter(func(node *Node) bool { return node.ID != "aws_instance.web" })
+	if len(filtered.Nodes) != 4 || len(filtered.Edges) != 1 || filtered.Nodes["aws_instance.web"] != nil {
+		t.Errorf("Filter() got %d nodes and %d edges, want 4 nodes and the DNS -> LB edge", len(filtered.Nodes), len(filtered.Edges))
+	}
+
+	// The original graph must be left untouched
+	if len(g.Nodes) != 5 || len(g.Edges) != 3 {
+		t.Errorf("original graph changed: %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+	if len(g.Nodes["aws_lb.main"].Edges) != 1 {
+		t.Errorf("original node edges changed: got %d, want 1", len(g.Nodes["aws_lb.main"].Edges))
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	resources := []parser.Resource{
+		{ID: "aws_lb.main", Type: "aws_lb", Name: "main", Provider: "aws", Dependencies: []string{"aws_instance.worker", "aws_instance.web"}},
+		{ID: "aws_instance.worker", Type: "aws_instance", Name: "worker", Provider: "aws", Dependencies: []string{"aws_sqs_queue.jobs"}},
+		{ID: "aws_sqs_queue.jobs", Type: "aws_sqs_queue", Name: "jobs", Provider: "aws", Dependencies: []string{"aws_db_instance.main"}},
+		{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws", Dependencies: []string{"aws_db_instance.main"}},
+		{ID: "aws_db_instance.main", Type: "aws_db_instance", Name: "main", Provider: "aws"},
+		{ID: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs", Provider: "aws"},
+	}
+
+	g := BuildGraphWithOptions(context.Background(), resources, GraphOptions{DetectImplicit: false})
+
+	tests := []struct {
+		name   string
+		fromID string
+		toID   string
+		want   []string
+	}{
+		{
+			name:   "shortest of two routes",
+			fromID: "aws_lb.main",
+			toID:   "aws_db_instance.main",
+			want:   []string{"aws_lb.main", "aws_instance.web", "aws_db_instance.main"},
+		},
+		{
+			name:   "against edge direction",
+			fromID: "aws_db_instance.main",
+			toID:   "aws_lb.main",
+			want:   []string{"aws_db_instance.main", "aws_instance.web", "aws_lb.main"},
+		},
+		{
+			name:   "through a shared neighbor",
+			fromID: "aws_instance.worker",
+			toID:   "aws_instance.web",
+			want:   []string{"aws_instance.worker", "aws_lb.main", "aws_instance.web"},
+		},
+		{
+			name:   "same node",
+			fromID: "aws_lb.main",
+			toID:   "aws_lb.main",
+			want:   []string{"aws_lb.main"},
+		},
+		{
+			name:   "no path",
+			fromID: "aws_lb.main",
+			toID:   "aws_s3_bucket.logs",
+			want:   nil,
+		},
+		{
+			name:   "unknown node",
+			fromID: "aws_lb.main",
+			toID:   "aws_lb.missing",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := g.ShortestPath(tt.fromID, tt.toID)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+				t.Errorf("ShortestPath(%q, %q) = %v, want %v", tt.fromID, tt.toID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEdgeMetadata_Via(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:       "aws_db_subnet_group.main",
+			Type:     "aws_db_subnet_group",
+			Name:     "main",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":         "main",
+				"subnet_ids": []interface{}{"subnet-a"},
+			},
+		},
+		{
+			ID:           "aws_subnet.a",
+			Type:         "aws_subnet",
+			Name:         "a",
+			Provider:     "aws",
+			Attributes:   map[string]interface{}{"id": "subnet-a", "vpc_id": "vpc-1"},
+			Dependencies: []string{"aws_vpc.main"},
+		},
+		{
+			ID:         "aws_vpc.main",
+			Type:       "aws_vpc",
+			Name:       "main",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "vpc-1"},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	implicit := findEdge(g, "aws_db_subnet_group.main", "aws_subnet.a")
+	if implicit == nil {
+		t.Fatal("expected implicit subnet group -> subnet edge")
+	}
+	if implicit.Metadata["via"] != "subnet_ids" {
+		t.Errorf("implicit edge Metadata[via] = %q, want %q", implicit.Metadata["via"], "subnet_ids")
+	}
+
+	explicit := findEdge(g, "aws_subnet.a", "aws_vpc.main")
+	if explicit == nil {
+		t.Fatal("expected explicit subnet -> VPC edge")
+	}
+	if explicit.Metadata["via"] != "vpc_id" {
+		t.Errorf("explicit edge Metadata[via] = %q, want %q", explicit.Metadata["via"], "vpc_id")
+	}
+
+	// The shared empty metadata map must never be modified
+	if len(emptyMetadata) != 0 {
+		t.Errorf("emptyMetadata was modified: %v", emptyMetadata)
+	}
+}
+
+func TestDetectReferenceConnections(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_subnet.private",
+			Type:       "aws_subnet",
+			Name:       "private",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-0abc123"},
+		},
+		{
+			ID:       "aws_instance.web",
+			Type:     "aws_instance",
+			Name:     "web",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":        "i-0def4567",
+				"subnet_id": "subnet-0abc123",
+				"tags":      map[string]interface{}{"Name": "web"},
+			},
+		},
+		// Short and shared ids are too ambiguous to link
+		{
+			ID:         "aws_ecs_cluster.main",
+			Type:       "aws_ecs_cluster",
+			Name:       "main",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "main"},
+		},
+		{
+			ID:         "aws_sns_topic.a",
+			Type:       "aws_sns_topic",
+			Name:       "a",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "shared-topic"},
+		},
+		{
+			ID:         "aws_sqs_queue.b",
+			Type:       "aws_sqs_queue",
+			Name:       "b",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "shared-topic"},
+		},
+		{
+			ID:       "aws_lambda_function.worker",
+			Type:     "aws_lambda_function",
+			Name:     "worker",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":           "worker-function",
+				"cluster":      "main",
+				"topic":        "shared-topic",
+				"self_pointer": "worker-function",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	edge := findEdge(g, "aws_instance.web", "aws_subnet.private")
+	if edge == nil {
+		t.Fatal("expected a references edge from the instance to its subnet")
+	}
+	if edge.Relationship != "references" || edge.Metadata["via"] != "subnet_id" {
+		t.Errorf("edge = %s via %q, want references via %q", edge.Relationship, edge.Metadata["via"], "subnet_id")
+	}
+
+	if len(g.Edges) != 1 {
+		for _, e := range g.Edges {
+			t.Logf("edge %s -> %s (%s)", e.From.ID, e.To.ID, e.Relationship)
+		}
+		t.Errorf("BuildGraph() got %d edges, want only the subnet reference", len(g.Edges))
+	}
+
+	// Disabling implicit detection also disables the reference pass
+	g = BuildGraphWithOptions(context.Background(), resources, GraphOptions{})
+	if len(g.Edges) != 0 {
+		t.Errorf("BuildGraphWithOptions() without DetectImplicit got %d edges, want 0", len(g.Edges))
+	}
+}
+
+func TestDetectImplicitConnections_Lambda(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_subnet.private",
+			Type:       "aws_subnet",
+			Name:       "private",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-0abc"},
+		},
+		{
+			ID:         "aws_security_group.lambda",
+			Type:       "aws_security_group",
+			Name:       "lambda",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "sg-0lambda"},
+		},
+		{
+			ID:       "aws_lambda_function.processor",
+			Type:     "aws_lambda_function",
+			Name:     "processor",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":            "order-processor",
+				"function_name": "order-processor",
+				"arn":           "arn:aws:lambda:us-east-1:123456789012:function:order-processor",
+				"vpc_config": []interface{}{
+					map[string]interface{}{
+						"subnet_ids":         []interface{}{"subnet-0abc"},
+						"security_group_ids": []interface{}{"sg-0lambda"},
+					},
+				},
+			},
+		},
+		{
+			ID:       "aws_sqs_queue.orders",
+			Type:     "aws_sqs_queue",
+			Name:     "orders",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":  "https://sqs.us-east-1.amazonaws.com/123456789012/orders",
+				"arn": "arn:aws:sqs:us-east-1:123456789012:orders",
+			},
+		},
+		{
+			ID:       "aws_dynamodb_table.events",
+			Type:     "aws_dynamodb_table",
+			Name:     "events",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":         "events",
+				"arn":        "arn:aws:dynamodb:us-east-1:123456789012:table/events",
+				"stream_arn": "arn:aws:dynamodb:us-east-1:123456789012:table/events/stream/2024-01-01T00:00:00.000",
+			},
+		},
+		{
+			ID:       "aws_lambda_event_source_mapping.orders",
+			Type:     "aws_lambda_event_source_mapping",
+			Name:     "orders",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":               "11111111-2222-3333-4444-555555555555",
+				"function_name":    "order-processor",
+				"event_source_arn": "arn:aws:sqs:us-east-1:123456789012:orders",
+			},
+		},
+		{
+			ID:       "aws_lambda_event_source_mapping.events",
+			Type:     "aws_lambda_event_source_mapping",
+			Name:     "events",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":               "66666666-7777-8888-9999-000000000000",
+				"function_arn":     "arn:aws:lambda:us-east-1:123456789012:function:order-processor",
+				"event_source_arn": "arn:aws:dynamodb:us-east-1:123456789012:table/events/stream/2024-01-01T00:00:00.000",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_lambda_function.processor->aws_subnet.private":                     {"deployed_in", "vpc_config.subnet_ids"},
+		"aws_security_group.lambda->aws_lambda_function.processor":              {"protects", "vpc_config.security_group_ids"},
+		"aws_lambda_event_source_mapping.orders->aws_lambda_function.processor": {"triggers", "function_name"},
+		"aws_lambda_event_source_mapping.orders->aws_sqs_queue.orders":          {"reads_from", "event_source_arn"},
+		"aws_lambda_event_source_mapping.events->aws_lambda_function.processor": {"triggers", "function_arn"},
+		"aws_lambda_event_source_mapping.events->aws_dynamodb_table.events":     {"reads_from", "event_source_arn"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+
+	if got := g.Nodes["aws_lambda_function.processor"].ResourceType; got != parser.ResourceTypeCompute {
+		t.Errorf("aws_lambda_function ResourceType = %v, want ResourceTypeCompute", got)
+	}
+}
+
+func TestDetectImplicitConnections_VPCPeering(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_vpc.east",
+			Type:       "aws_vpc",
+			Name:       "east",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "vpc-0east"},
+		},
+		{
+			ID:         "aws_vpc.west",
+			Type:       "aws_vpc",
+			Name:       "west",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "vpc-0west"},
+		},
+		{
+			ID:       "aws_vpc_peering_connection.east_west",
+			Type:     "aws_vpc_peering_connection",
+			Name:     "east_west",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":          "pcx-0eastwest",
+				"vpc_id":      "vpc-0east",
+				"peer_vpc_id": "vpc-0west",
+			},
+		},
+		{
+			ID:         "aws_ec2_transit_gateway.hub",
+			Type:       "aws_ec2_transit_gateway",
+			Name:       "hub",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "tgw-0hub"},
+		},
+		{
+			ID:       "aws_ec2_transit_gateway_vpc_attachment.east",
+			Type:     "aws_ec2_transit_gateway_vpc_attachment",
+			Name:     "east",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":                 "tgw-attach-0east",
+				"transit_gateway_id": "tgw-0hub",
+				"vpc_id":             "vpc-0east",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_vpc_peering_connection.east_west->aws_vpc.east":                       {"peers_with", "vpc_id"},
+		"aws_vpc_peering_connection.east_west->aws_vpc.west":                       {"peers_with", "peer_vpc_id"},
+		"aws_ec2_transit_gateway_vpc_attachment.east->aws_ec2_transit_gateway.hub": {"attached_to", "transit_gateway_id"},
+		"aws_ec2_transit_gateway_vpc_attachment.east->aws_vpc.east":                {"attached_to", "vpc_id"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+
+	for _, id := range []string{"aws_vpc_peering_connection.east_west", "aws_ec2_transit_gateway.hub"} {
+		if got := g.Nodes[id].ResourceType; got != parser.ResourceTypeNetwork {
+			t.Errorf("%s ResourceType = %v, want ResourceTypeNetwork", id, got)
+		}
+	}
+}
+
+func TestDetectImplicitConnections_AzurePrivateNetworking(t *testing.T) {
+	const subnetID = "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/app"
+	const storageID = "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/data"
+
+	resources := []parser.Resource{
+		{
+			ID:         "azurerm_subnet.app",
+			Type:       "azurerm_subnet",
+			Name:       "app",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": subnetID},
+		},
+		{
+			ID:         "azurerm_storage_account.data",
+			Type:       "azurerm_storage_account",
+			Name:       "data",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": storageID},
+		},
+		{
+			ID:       "azurerm_network_interface.vm",
+			Type:     "azurerm_network_interface",
+			Name:     "vm",
+			Provider: "azure",
+			Attributes: map[string]interface{}{
+				"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm",
+				"ip_configuration": []interface{}{
+					map[string]interface{}{
+						"name": "internal",
+						// Casing differs from the subnet's own ID
+						"subnet_id":                     strings.ToLower(subnetID),
+						"private_ip_address_allocation": "Dynamic",
+					},
+				},
+			},
+		},
+		{
+			ID:       "azurerm_private_endpoint.data",
+			Type:     "azurerm_private_endpoint",
+			Name:     "data",
+			Provider: "azure",
+			Attributes: map[string]interface{}{
+				"id":        "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Network/privateEndpoints/data",
+				"subnet_id": subnetID,
+				"private_service_connection": []interface{}{
+					map[string]interface{}{
+						"name":                           "data-blob",
+						"private_connection_resource_id": storageID,
+						"subresource_names":              []interface{}{"blob"},
+					},
+				},
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"azurerm_network_interface.vm->azurerm_subnet.app":            {"attached_to", "ip_configuration.subnet_id"},
+		"azurerm_private_endpoint.data->azurerm_subnet.app":           {"deployed_in", "subnet_id"},
+		"azurerm_private_endpoint.data->azurerm_storage_account.data": {"connects_to", "private_service_connection.private_connection_resource_id"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+}
+
+func TestDetectAssociationConnections_StorageAttachments(t *testing.T) {
+	const vmID = "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/app"
+	const diskID = "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/disks/app-data"
+
+	resources := []parser.Resource{
+		{
+			ID:         "aws_instance.web",
+			Type:       "aws_instance",
+			Name:       "web",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "i-web"},
+		},
+		{
+			ID:         "aws_ebs_volume.data",
+			Type:       "aws_ebs_volume",
+			Name:       "data",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "vol-data"},
+		},
+		{
+			ID:           "aws_volume_attachment.data",
+			Type:         "aws_volume_attachment",
+			Name:         "data",
+			Provider:     "aws",
+			Dependencies: []string{"aws_ebs_volume.data", "aws_instance.web"},
+			Attributes: map[string]interface{}{
+				"id":          "vai-data",
+				"device_name": "/dev/sdh",
+				"instance_id": "i-web",
+				"volume_id":   "vol-data",
+			},
+		},
+		{
+			ID:         "azurerm_linux_virtual_machine.app",
+			Type:       "azurerm_linux_virtual_machine",
+			Name:       "app",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": vmID},
+		},
+		{
+			ID:         "azurerm_managed_disk.data",
+			Type:       "azurerm_managed_disk",
+			Name:       "data",
+			Provider:   "azure",
+			Attributes: map[string]interface{}{"id": diskID},
+		},
+		{
+			ID:           "azurerm_virtual_machine_data_disk_attachment.data",
+			Type:         "azurerm_virtual_machine_data_disk_attachment",
+			Name:         "data",
+			Provider:     "azure",
+			Dependencies: []string{"azurerm_linux_virtual_machine.app", "azurerm_managed_disk.data"},
+			Attributes: map[string]interface{}{
+				"id": vmID + "/dataDisks/app-data",
+				// Casing differs from the disk's own ID
+				"managed_disk_id":    strings.ToLower(diskID),
+				"virtual_machine_id": vmID,
+				"lun":                "10",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	for _, id := range []string{"aws_volume_attachment.data", "azurerm_virtual_machine_data_disk_attachment.data"} {
+		if _, ok := g.Nodes[id]; ok {
+			t.Errorf("attachment %s should not be drawn as a node", id)
+		}
+	}
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_ebs_volume.data->aws_instance.web":                        {"attached_to", "instance_id"},
+		"azurerm_managed_disk.data->azurerm_linux_virtual_machine.app": {"attached_to", "virtual_machine_id"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+}
+
+func TestDetectImplicitConnections_NetworkInterfaces(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_subnet.private",
+			Type:       "aws_subnet",
+			Name:       "private",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "subnet-private"},
+		},
+		{
+			ID:         "aws_security_group.app",
+			Type:       "aws_security_group",
+			Name:       "app",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "sg-app"},
+		},
+		{
+			ID:         "aws_instance.app",
+			Type:       "aws_instance",
+			Name:       "app",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "i-app"},
+		},
+		{
+			ID:       "aws_network_interface.secondary",
+			Type:     "aws_network_interface",
+			Name:     "secondary",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":              "eni-secondary",
+				"subnet_id":       "subnet-private",
+				"security_groups": []interface{}{"sg-app"},
+			},
+		},
+		{
+			ID:           "aws_network_interface_attachment.secondary",
+			Type:         "aws_network_interface_attachment",
+			Name:         "secondary",
+			Provider:     "aws",
+			Dependencies: []string{"aws_instance.app", "aws_network_interface.secondary"},
+			Attributes: map[string]interface{}{
+				"id":                   "eni-attach-secondary",
+				"device_index":         "1",
+				"instance_id":          "i-app",
+				"network_interface_id": "eni-secondary",
+			},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	if _, ok := g.Nodes["aws_network_interface_attachment.secondary"]; ok {
+		t.Error("attachment should not be drawn as a node")
+	}
+
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_network_interface.secondary->aws_subnet.private":     {"attached_to", "subnet_id"},
+		"aws_network_interface.secondary->aws_instance.app":       {"attached_to", "instance_id"},
+		"aws_security_group.app->aws_network_interface.secondary": {"protects", "security_groups"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+}
+
+func TestDetectImplicitConnections_InstanceProfiles(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_iam_role.app",
+			Type:       "aws_iam_role",
+			Name:       "app",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "app-role", "name": "app-role", "arn": "arn:aws:iam::123456789012:role/app-role"},
+		},
+		{
+			ID:         "aws_iam_role.unused",
+			Type:       "aws_iam_role",
+			Name:       "unused",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "unused-role", "name": "unused-role"},
+		},
+		{
+			ID:         "aws_iam_instance_profile.app",
+			Type:       "aws_iam_instance_profile",
+			Name:       "app",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "app-profile", "name": "app-profile", "role": "app-role"},
+		},
+		{
+			ID:         "aws_instance.app",
+			Type:       "aws_instance",
+			Name:       "app",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "i-app", "iam_instance_profile": "app-profile"},
+		},
+		{
+			ID:         "aws_instance.batch",
+			Type:       "aws_instance",
+			Name:       "batch",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "i-batch", "iam_instance_profile": "missing-profile"},
+		},
+	}
+
+	g := BuildGraph(context.Background(), resources)
+
+	if got := g.Nodes["aws_iam_role.app"].ResourceType; got != parser.ResourceTypeSecurity {
+		t.Errorf("aws_iam_role ResourceType = %v, want ResourceTypeSecurity", got)
+	}
+	if got := g.Nodes["aws_iam_instance_profile.app"].ResourceType; got != parser.ResourceTypeSecurity {
+		t.Errorf("aws_iam_instance_profile ResourceType = %v, want ResourceTypeSecurity", got)
+	}
+
+	// The instance resolves to its role through the profile; the other role and the
+	// instance with an unknown profile stay unconnected
+	want := map[string]struct {
+		relationship string
+		via          string
+	}{
+		"aws_instance.app->aws_iam_instance_profile.app": {"assumes", "iam_instance_profile"},
+		"aws_iam_instance_profile.app->aws_iam_role.app": {"assumes", "role"},
+	}
+
+	if len(g.Edges) != len(want) {
+		t.Fatalf("BuildGraph() got %d edges, want %d", len(g.Edges), len(want))
+	}
+	for _, edge := range g.Edges {
+		key := edge.From.ID + "->" + edge.To.ID
+		expected, ok := want[key]
+		if !ok {
+			t.Errorf("unexpected edge %s", key)
+			continue
+		}
+		if edge.Relationship != expected.relationship {
+			t.Errorf("edge %s relationship = %s, want %s", key, edge.Relationship, expected.relationship)
+		}
+		if edge.Metadata["via"] != expected.via {
+			t.Errorf("edge %s via = %q, want %q", key, edge.Metadata["via"], expected.via)
+		}
+	}
+}
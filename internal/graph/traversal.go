@@ -0,0 +1,140 @@
+package graph
+
+// TraversalOptions controls how ReachableWithOptions walks the graph
+type TraversalOptions struct {
+	// MaxDepth limits how many edges away from the start node a node may be.
+	// 0 returns only the start node; a negative value means unlimited.
+	MaxDepth int
+	// Bidirectional follows edges in both directions instead of only outgoing edges
+	Bidirectional bool
+}
+
+// Reachable returns a new graph containing the start node and every node reachable
+// from it by following outgoing edges at most maxDepth times (negative = unlimited).
+// See ReachableWithOptions for details.
+func (g *Graph) Reachable(startID string, maxDepth int) *Graph {
+	return g.ReachableWithOptions(startID, TraversalOptions{MaxDepth: maxDepth})
+}
+
+// ReachableWithOptions returns a new graph containing the start node and the nodes
+// reachable from it using a breadth-first search bounded by opts.MaxDepth.
+// The result keeps every edge between the selected nodes. The original graph is not
+// modified; an unknown startID yields an empty graph.
+func (g *Graph) ReachableWithOptions(startID string, opts TraversalOptions) *Graph {
+	if g.Nodes[startID] == nil {
+		return g.subgraph(func(*Node) bool { return false })
+	}
+
+	// Build adjacency from the edge list; Node.Edges only holds outgoing edges
+	neighbors := make(map[string][]string)
+	for _, edge := range g.Edges {
+		neighbors[edge.From.ID] = append(neighbors[edge.From.ID], edge.To.ID)
+		if opts.Bidirectional {
+			neighbors[edge.To.ID] = append(neighbors[edge.To.ID], edge.From.ID)
+		}
+	}
+
+	depths := map[string]int{startID: 0}
+	queue := []string{startID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if opts.MaxDepth >= 0 && depths[id] >= opts.MaxDepth {
+			continue
+		}
+		for _, next := range neighbors[id] {
+			if _, seen := depths[next]; !seen {
+				depths[next] = depths[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return g.subgraph(func(node *Node) bool {
+		_, reached := depths[node.ID]
+		return reached
+	})
+}
+
+// ShortestPath returns the IDs of the nodes on a shortest path from fromID to toID, both
+// included, using a breadth-first search that follows edges in either direction. It
+// returns nil when either node is unknown or no path connects them.
+func (g *Graph) ShortestPath(fromID, toID string) []string {
+	if g.Nodes[fromID] == nil || g.Nodes[toID] == nil {
+		return nil
+	}
+
+	neighbors := make(map[string][]string)
+	for _, edge := range g.Edges {
+		neighbors[edge.From.ID] = append(neighbors[edge.From.ID], edge.To.ID)
+		neighbors[edge.To.ID] = append(neighbors[edge.To.ID], edge.From.ID)
+	}
+
+	// previous records the node each visited node was first reached from
+	previous := map[string]string{fromID: ""}
+	queue := []string{fromID}
+	for len(queue) > 0 && queue[0] != toID {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range neighbors[id] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	if _, reached := previous[toID]; !reached {
+		return nil
+	}
+
+	var path []string
+	for id := toID; id != ""; id = previous[id] {
+		path = append([]string{id}, path...)
+	}
+	return path
+}
+
+// Filter returns a new graph containing the nodes for which keep returns true and every
+// edge between them. The original graph is not modified.
+func (g *Graph) Filter(keep func(*Node) bool) *Graph {
+	return g.subgraph(keep)
+}
+
+// subgraph copies the nodes selected by keep, and the edges between them, into a new graph
+func (g *Graph) subgraph(keep func(*Node) bool) *Graph {
+	result := &Graph{
+		Nodes:          make(map[string]*Node),
+		Edges:          make([]*Edge, 0),
+		attributeIndex: make(map[string]map[string]*Node),
+	}
+
+	// Shallow-copy selected nodes so edge lists can be rebuilt without touching g
+	for id, node := range g.Nodes {
+		if !keep(node) {
+			continue
+		}
+		nodeCopy := *node
+		nodeCopy.Edges = make([]*Edge, 0, len(nodeCopy.Edges))
+		result.Nodes[id] = &nodeCopy
+	}
+
+	for _, edge := range g.Edges {
+		from, to := result.Nodes[edge.From.ID], result.Nodes[edge.To.ID]
+		if from == nil || to == nil {
+			continue
+		}
+		edgeCopy := &Edge{
+			From:         from,
+			To:           to,
+			Relationship: edge.Relationship,
+			Metadata:     edge.Metadata,
+		}
+		result.Edges = append(result.Edges, edgeCopy)
+		from.Edges = append(from.Edges, edgeCopy)
+	}
+
+	result.buildAttributeIndex()
+
+	return result
+}
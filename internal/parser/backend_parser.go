@@ -1,10 +1,10 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
@@ -34,17 +34,19 @@ const (
 	BackendTypePg       BackendType = "pg"
 )
 
-// ParseBackendConfig reads Terraform configuration files and extracts backend configuration
+// ParseBackendConfig reads Terraform configuration files and extracts backend configuration.
+// Values recorded by `terraform init` in .terraform/terraform.tfstate (e.g. from
+// -backend-config) are merged over the configured ones, so partial backend blocks resolve.
 func ParseBackendConfig(configPath string) (*BackendConfig, error) {
 	parser := hclparse.NewParser()
 
-	// Find all .tf files in the directory
+	// Find all .tf and .tf.json files in the directory
 	var tfFiles []string
 	err := filepath.Walk(configPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(path, ".tf") {
+		if !info.IsDir() && IsConfigFile(path) {
 			tfFiles = append(tfFiles, path)
 		}
 		return nil
@@ -61,7 +63,7 @@ func ParseBackendConfig(configPath string) (*BackendConfig, error) {
 			continue
 		}
 		if backend != nil {
-			return backend, nil
+			return mergeInitBackend(backend)
 		}
 	}
 
@@ -73,9 +75,57 @@ func ParseBackendConfig(configPath string) (*BackendConfig, error) {
 	}, nil
 }
 
-// parseBackendFromFile parses a single .tf file looking for backend configuration
+// initBackendState is the part of .terraform/terraform.tfstate where `terraform init`
+// records the effective backend configuration
+type initBackendState struct {
+	Backend *struct {
+		Type   string                 `json:"type"`
+		Config map[string]interface{} `json:"config"`
+	} `json:"backend"`
+}
+
+// mergeInitBackend overlays the backend configuration recorded by `terraform init` on
+// backend, preferring the recorded values. The record is ignored when it is missing or
+// was made for a different backend type, since terraform would require a new init then.
+func mergeInitBackend(backend *BackendConfig) (*BackendConfig, error) {
+	path := filepath.Join(backend.WorkingDir, ".terraform", "terraform.tfstate")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return backend, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var state initBackendState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, withKind(ErrParse, fmt.Errorf("failed to parse %s: %w", path, err))
+	}
+	if state.Backend == nil || state.Backend.Type != backend.Type {
+		return backend, nil
+	}
+
+	config := make(map[string]interface{}, len(backend.Config)+len(state.Backend.Config))
+	for key, value := range backend.Config {
+		config[key] = value
+	}
+	for key, value := range state.Backend.Config {
+		// Terraform records every attribute of the backend schema, with null for unset ones
+		if value != nil {
+			config[key] = value
+		}
+	}
+
+	return &BackendConfig{
+		Type:       backend.Type,
+		Config:     config,
+		WorkingDir: backend.WorkingDir,
+	}, nil
+}
+
+// parseBackendFromFile parses a single .tf or .tf.json file looking for backend configuration
 func parseBackendFromFile(parser *hclparse.Parser, path string, workingDir string) (*BackendConfig, error) {
-	file, diags := parser.ParseHCLFile(path)
+	file, diags := parseConfigFile(parser, path)
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("HCL parse errors: %s", diags.Error())
 	}
@@ -223,7 +273,7 @@ func GetStatePath(backend *BackendConfig) (string, error) {
 		// These require special handling - state is not on local filesystem
 		return "", fmt.Errorf("backend type '%s' requires remote state fetching", backend.Type)
 	default:
-		return "", fmt.Errorf("unsupported backend type: %s", backend.Type)
+		return "", withKind(ErrUnsupportedBackend, fmt.Errorf("unsupported backend type: %s", backend.Type))
 	}
 }
 
@@ -236,7 +286,7 @@ func getLocalStatePath(backend *BackendConfig) (string, error) {
 		if _, err := os.Stat(fullPath); err == nil {
 			return fullPath, nil
 		}
-		return "", fmt.Errorf("state file not found at configured path: %s", fullPath)
+		return "", withKind(ErrNoStateFound, fmt.Errorf("state file not found at configured path: %s", fullPath))
 	}
 
 	// Default local backend path
@@ -251,7 +301,7 @@ func getLocalStatePath(backend *BackendConfig) (string, error) {
 		return terraformPath, nil
 	}
 
-	return "", fmt.Errorf("no state file found in working directory: %s", backend.WorkingDir)
+	return "", withKind(ErrNoStateFound, fmt.Errorf("no state file found in working directory: %s", backend.WorkingDir))
 }
 
 // AutoDetectStatePath attempts to find the state file without backend configuration
@@ -271,5 +321,5 @@ func AutoDetectStatePath(configPath string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("no state file found in common locations under: %s", configPath)
+	return "", withKind(ErrNoStateFound, fmt.Errorf("no state file found in common locations under: %s", configPath))
 }
@@ -143,6 +143,83 @@ terraform {
 			wantBackendType: "s3",
 			wantErr:         false,
 		},
+		{
+			name: "backend in .tf.json file",
+			files: map[string]string{
+				"backend.tf.json": `{
+  "terraform": {
+    "backend": {
+      "s3": {
+        "bucket": "json-state",
+        "key": "prod/terraform.tfstate",
+        "region": "eu-west-1"
+      }
+    }
+  }
+}`,
+			},
+			wantBackendType: "s3",
+			wantConfig: map[string]interface{}{
+				"bucket": "json-state",
+				"key":    "prod/terraform.tfstate",
+				"region": "eu-west-1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "partial backend completed by terraform init",
+			files: map[string]string{
+				"backend.tf": `
+terraform {
+  backend "s3" {
+    key    = "prod/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
+`,
+				".terraform/terraform.tfstate": `{
+  "version": 3,
+  "backend": {
+    "type": "s3",
+    "config": {
+      "bucket": "init-state",
+      "key": "prod/terraform.tfstate",
+      "region": "eu-central-1",
+      "profile": null
+    },
+    "hash": 1234567890
+  }
+}`,
+			},
+			wantBackendType: "s3",
+			wantConfig: map[string]interface{}{
+				"bucket": "init-state",
+				"key":    "prod/terraform.tfstate",
+				"region": "eu-central-1",
+			},
+			wantErr: false,
+		},
+		{
+			name: "stale terraform init backend of another type",
+			files: map[string]string{
+				"backend.tf": `
+terraform {
+  backend "gcs" {
+    bucket = "my-terraform-state"
+  }
+}
+`,
+				".terraform/terraform.tfstate": `{
+  "version": 3,
+  "backend": {"type": "s3", "config": {"bucket": "old-state"}}
+}`,
+			},
+			wantBackendType: "gcs",
+			wantConfig: map[string]interface{}{
+				"bucket": "my-terraform-state",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -152,6 +229,9 @@ terraform {
 			// Create test files
 			for filename, content := range tt.files {
 				filePath := filepath.Join(tmpDir, filename)
+				if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+					t.Fatalf("Failed to create directory for %s: %v", filename, err)
+				}
 				if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
 					t.Fatalf("Failed to create test file %s: %v", filename, err)
 				}
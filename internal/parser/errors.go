@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Errors identifying common failure modes. Errors returned by this package wrap the
+// matching one while keeping their detailed message, so callers can tell them apart
+// with errors.Is.
+var (
+	// ErrNoStateFound is returned when there is no state file or remote state object
+	ErrNoStateFound = errors.New("no state found")
+
+	// ErrBackendAuth is returned when backend credentials are missing or rejected
+	ErrBackendAuth = errors.New("backend authentication failed")
+
+	// ErrUnsupportedBackend is returned for backend types that cannot be read
+	ErrUnsupportedBackend = errors.New("unsupported backend")
+
+	// ErrParse is returned when a state or configuration file cannot be parsed
+	ErrParse = errors.New("parse error")
+)
+
+// kindError marks an error as one of the failure modes above without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// withKind returns err marked as the failure mode kind, or err itself when kind is nil
+func withKind(kind, err error) error {
+	if kind == nil || err == nil {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// statusKind returns the failure mode of an HTTP error status from a backend, if any
+func statusKind(statusCode int) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return ErrNoStateFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrBackendAuth
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+	invalidState := filepath.Join(tmpDir, "invalid.tfstate")
+	if err := os.WriteFile(invalidState, []byte(`{"version": 4,`), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		call     func() error
+		wantKind error
+	}{
+		{
+			name: "local state file missing",
+			call: func() error {
+				_, err := GetStatePath(&BackendConfig{Type: "local", Config: map[string]interface{}{}, WorkingDir: tmpDir})
+				return err
+			},
+			wantKind: ErrNoStateFound,
+		},
+		{
+			name: "configured local state path missing",
+			call: func() error {
+				_, err := GetStatePath(&BackendConfig{Type: "local", Config: map[string]interface{}{"path": "prod.tfstate"}, WorkingDir: tmpDir})
+				return err
+			},
+			wantKind: ErrNoStateFound,
+		},
+		{
+			name: "auto-detect finds no state",
+			call: func() error {
+				_, err := AutoDetectStatePath(tmpDir)
+				return err
+			},
+			wantKind: ErrNoStateFound,
+		},
+		{
+			name: "state file missing",
+			call: func() error {
+				_, err := ParseStateFile(ctx, filepath.Join(tmpDir, "missing.tfstate"))
+				return err
+			},
+			wantKind: ErrNoStateFound,
+		},
+		{
+			name: "state URL not found",
+			call: func() error {
+				_, err := ParseStateURL(ctx, server.URL+"/missing", nil)
+				return err
+			},
+			wantKind: ErrNoStateFound,
+		},
+		{
+			name: "state URL forbidden",
+			call: func() error {
+				_, err := ParseStateURL(ctx, server.URL+"/private", nil)
+				return err
+			},
+			wantKind: ErrBackendAuth,
+		},
+		{
+			name: "unknown backend type",
+			call: func() error {
+				_, err := GetStatePath(&BackendConfig{Type: "swift", Config: map[string]interface{}{}})
+				return err
+			},
+			wantKind: ErrUnsupportedBackend,
+		},
+		{
+			name: "remote fetching unsupported for backend",
+			call: func() error {
+				_, err := FetchRemoteState(ctx, &RemoteStateConfig{Backend: &BackendConfig{Type: "consul", Config: map[string]interface{}{}}})
+				return err
+			},
+			wantKind: ErrUnsupportedBackend,
+		},
+		{
+			name: "invalid state file",
+			call: func() error {
+				_, err := ParseStateFile(ctx, invalidState)
+				return err
+			},
+			wantKind: ErrParse,
+		},
+	}
+
+	kinds := []error{ErrNoStateFound, ErrBackendAuth, ErrUnsupportedBackend, ErrParse}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, kind := range kinds {
+				if got, want := errors.Is(err, kind), kind == tt.wantKind; got != want {
+					t.Errorf("errors.Is(%q, %v) = %v, want %v", err, kind, got, want)
+				}
+			}
+		})
+	}
+}
@@ -2,61 +2,189 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/zclconf/go-cty/cty"
 )
 
+// ConfigParseOptions controls which files ParseConfigDirectoryWithOptions reads and how
+// it handles invalid ones
+type ConfigParseOptions struct {
+	// Strict fails the whole parse if any file can't be parsed.
+	// Otherwise invalid files are skipped and reported as warnings.
+	Strict bool
+
+	// NonRecursive reads only the files directly in the directory, not those of its
+	// subdirectories
+	NonRecursive bool
+}
+
 // ParseConfigDirectory reads and parses all .tf files in a directory.
+// Files that fail to parse are skipped and logged as warnings.
 // It respects the provided context for cancellation.
 func ParseConfigDirectory(ctx context.Context, dirPath string) ([]Resource, error) {
+	resources, warnings, err := ParseConfigDirectoryWithOptions(ctx, dirPath, ConfigParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(warnings) > 0 {
+		tflog.Warn(ctx, fmt.Sprintf("Skipped %d unparseable configuration file(s)", len(warnings)), map[string]interface{}{
+			"errors": errors.Join(warnings...).Error(),
+		})
+	}
+
+	return resources, nil
+}
+
+// ParseConfigDirectoryWithOptions reads and parses all .tf files in a directory.
+// Unless opts.Strict is set, files that fail to parse are skipped and returned as
+// warnings alongside the resources from the remaining files. An error is returned
+// if every file fails to parse.
+func ParseConfigDirectoryWithOptions(ctx context.Context, dirPath string, opts ConfigParseOptions) ([]Resource, []error, error) {
 	// Check if context is already cancelled
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, nil, ctx.Err()
 	default:
 	}
 
-	parser := hclparse.NewParser()
-
-	// Find all .tf files
+	// Find all .tf and .tf.json files
 	var tfFiles []string
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(path, ".tf") {
+		if info.IsDir() && path != dirPath && opts.NonRecursive {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && IsConfigFile(path) {
 			tfFiles = append(tfFiles, path)
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan directory: %w", err)
+		return nil, nil, fmt.Errorf("failed to scan directory: %w", err)
 	}
 
-	var resources []Resource
-	for _, tfFile := range tfFiles {
-		fileResources, err := parseHCLFile(parser, tfFile)
+	resources, fileErrs, err := parseConfigFiles(ctx, tfFiles, runtime.GOMAXPROCS(0))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(fileErrs) > 0 && (opts.Strict || len(fileErrs) == len(tfFiles)) {
+		return nil, nil, errors.Join(fileErrs...)
+	}
+
+	// Report source files relative to the configuration directory
+	for i := range resources {
+		if rel, err := filepath.Rel(dirPath, resources[i].SourceFile); err == nil {
+			resources[i].SourceFile = filepath.ToSlash(rel)
+		}
+	}
+
+	return resources, fileErrs, nil
+}
+
+// parseConfigFiles parses files using up to workers goroutines.
+// Each worker owns its hclparse.Parser, which is not safe for concurrent use.
+// Resources are sorted by ID so the result doesn't depend on scheduling.
+// Per-file parse errors are returned in file order; the error result is only
+// set when the context is cancelled.
+func parseConfigFiles(ctx context.Context, files []string, workers int) ([]Resource, []error, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	results := make([][]Resource, len(files))
+	errs := make([]error, len(files))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parser := hclparse.NewParser()
+			for i := range jobs {
+				fileResources, err := parseHCLFile(parser, files[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to parse %s: %w", files[i], err)
+					continue
+				}
+				results[i] = fileResources
+			}
+		}()
+	}
+
+	// Feed files to workers, stopping early if the context is cancelled
+	var ctxErr error
+feed:
+	for i := range files {
+		select {
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if ctxErr != nil {
+		return nil, nil, ctxErr
+	}
+
+	var fileErrs []error
+	for _, err := range errs {
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse %s: %w", tfFile, err)
+			fileErrs = append(fileErrs, err)
 		}
+	}
+
+	var resources []Resource
+	for _, fileResources := range results {
 		resources = append(resources, fileResources...)
 	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].ID < resources[j].ID
+	})
 
-	return resources, nil
+	return resources, fileErrs, nil
+}
+
+// IsConfigFile reports whether path is a Terraform configuration file (.tf or .tf.json)
+func IsConfigFile(path string) bool {
+	return strings.HasSuffix(path, ".tf") || strings.HasSuffix(path, ".tf.json")
+}
+
+// parseConfigFile parses a .tf file as native HCL syntax or a .tf.json file as HCL JSON
+func parseConfigFile(parser *hclparse.Parser, path string) (*hcl.File, hcl.Diagnostics) {
+	if strings.HasSuffix(path, ".tf.json") {
+		return parser.ParseJSONFile(path)
+	}
+	return parser.ParseHCLFile(path)
 }
 
-// parseHCLFile parses a single HCL file and extracts resources
+// parseHCLFile parses a single configuration file and extracts resources
 func parseHCLFile(parser *hclparse.Parser, path string) ([]Resource, error) {
-	file, diags := parser.ParseHCLFile(path)
+	file, diags := parseConfigFile(parser, path)
 	if diags.HasErrors() {
-		return nil, fmt.Errorf("HCL parse errors: %s", diags.Error())
+		return nil, withKind(ErrParse, fmt.Errorf("HCL parse errors: %s", diags.Error()))
 	}
 
 	var resources []Resource
@@ -101,6 +229,8 @@ func parseHCLFile(parser *hclparse.Parser, path string) ([]Resource, error) {
 			Attributes:   attrs,
 			ID:           fmt.Sprintf("%s.%s", resourceType, resourceName),
 			Dependencies: deps,
+			SourceFile:   path,
+			SourceLine:   block.DefRange.Start.Line,
 		}
 
 		resources = append(resources, resource)
@@ -109,8 +239,54 @@ func parseHCLFile(parser *hclparse.Parser, path string) ([]Resource, error) {
 	return resources, nil
 }
 
-// parseResourceAttributes extracts attributes from a resource block
+// metaBlockTypes are Terraform meta-argument blocks that don't describe resource attributes
+var metaBlockTypes = map[string]bool{
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+	"dynamic":     true, // Content depends on for_each, which can't be evaluated without context
+}
+
+// parseResourceAttributes extracts attributes from a resource block.
+// Nested blocks (e.g., ingress on aws_security_group) are parsed recursively and
+// stored as a list of maps per block type, matching the shape found in state files.
 func parseResourceAttributes(body hcl.Body) (map[string]interface{}, error) {
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return parseJustAttributes(body)
+	}
+
+	attrs := make(map[string]interface{})
+
+	for name, attr := range syntaxBody.Attributes {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			// Skip attributes that can't be evaluated without context
+			continue
+		}
+
+		attrs[name] = ctyToInterface(val)
+	}
+
+	for _, block := range syntaxBody.Blocks {
+		if metaBlockTypes[block.Type] {
+			continue
+		}
+
+		blockAttrs, err := parseResourceAttributes(block.Body)
+		if err != nil {
+			return attrs, err
+		}
+
+		blocks, _ := attrs[block.Type].([]interface{})
+		attrs[block.Type] = append(blocks, blockAttrs)
+	}
+
+	return attrs, nil
+}
+
+// parseJustAttributes extracts top-level attributes from a body that isn't native HCL syntax
+func parseJustAttributes(body hcl.Body) (map[string]interface{}, error) {
 	attrs := make(map[string]interface{})
 
 	// Get all attributes
@@ -211,13 +387,16 @@ func extractDependenciesFromBlock(body hcl.Body) []string {
 	// Try to get the syntax body for traversal extraction
 	if syntaxBody, ok := body.(*hclsyntax.Body); ok {
 		extractTraversals(syntaxBody, deps)
+	} else {
+		extractJSONTraversals(body, deps)
 	}
 
-	// Convert map to slice
+	// Convert map to slice, sorted for deterministic output
 	var result []string
 	for dep := range deps {
 		result = append(result, dep)
 	}
+	sort.Strings(result)
 
 	return result
 }
@@ -235,25 +414,65 @@ func extractTraversals(body *hclsyntax.Body, deps map[string]bool) {
 	}
 }
 
-// findTraversalsInExpr finds resource references in an HCL expression
-func findTraversalsInExpr(expr hclsyntax.Expression, deps map[string]bool) {
-	// Check if this expression is a scope traversal (e.g., digitalocean_vpc.example.id)
-	if traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr); ok {
-		if len(traversal.Traversal) >= 2 {
-			rootName := traversal.Traversal.RootName()
+// extractJSONTraversals finds resource references in a non-native body such as HCL JSON,
+// where references appear in "${...}" templates and depends_on holds plain reference strings
+func extractJSONTraversals(body hcl.Body, deps map[string]bool) {
+	attrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return
+	}
 
-			// Skip variables, locals, data sources, etc. - only track resource references
-			if rootName == "var" || rootName == "local" || rootName == "data" ||
-			   rootName == "module" || rootName == "path" || rootName == "terraform" {
-				return
-			}
+	for name, attr := range attrs {
+		for _, traversal := range attr.Expr.Variables() {
+			addTraversalDependency(traversal, deps)
+		}
 
-			// Get the first two parts: resource_type.resource_name
-			if attr, ok := traversal.Traversal[1].(hcl.TraverseAttr); ok {
-				dep := fmt.Sprintf("%s.%s", rootName, attr.Name)
-				deps[dep] = true
+		if name == "depends_on" {
+			refs, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				continue
+			}
+			if refList, ok := ctyToInterface(refs).([]interface{}); ok {
+				for _, ref := range refList {
+					refStr, ok := ref.(string)
+					if !ok {
+						continue
+					}
+					if traversal, diags := hclsyntax.ParseTraversalAbs([]byte(refStr), "", hcl.InitialPos); !diags.HasErrors() {
+						addTraversalDependency(traversal, deps)
+					}
+				}
 			}
 		}
+	}
+}
+
+// addTraversalDependency records the resource referenced by a traversal (e.g., aws_vpc.main.id -> aws_vpc.main)
+func addTraversalDependency(traversal hcl.Traversal, deps map[string]bool) {
+	if len(traversal) < 2 {
+		return
+	}
+
+	rootName := traversal.RootName()
+
+	// Skip variables, locals, data sources, etc. - only track resource references
+	if rootName == "var" || rootName == "local" || rootName == "data" ||
+	   rootName == "module" || rootName == "path" || rootName == "terraform" {
+		return
+	}
+
+	// Get the first two parts: resource_type.resource_name
+	if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+		dep := fmt.Sprintf("%s.%s", rootName, attr.Name)
+		deps[dep] = true
+	}
+}
+
+// findTraversalsInExpr finds resource references in an HCL expression
+func findTraversalsInExpr(expr hclsyntax.Expression, deps map[string]bool) {
+	// Check if this expression is a scope traversal (e.g., digitalocean_vpc.example.id)
+	if traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr); ok {
+		addTraversalDependency(traversal.Traversal, deps)
 		return
 	}
 
@@ -2,8 +2,11 @@ package parser
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -202,6 +205,67 @@ resource "aws_instance" "web" {
 	}
 }
 
+func TestParseConfigDirectory_SourceFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`,
+		"network/subnets.tf": `# Subnets
+
+resource "aws_subnet" "public" {
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_subnet" "private" {
+  vpc_id = aws_vpc.main.id
+}
+`,
+		"compute.tf.json": `{
+  "resource": {
+    "aws_instance": {
+      "web": {"ami": "ami-12345"}
+    }
+  }
+}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	resources, err := ParseConfigDirectory(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("ParseConfigDirectory() error = %v", err)
+	}
+
+	type source struct {
+		file string
+		line int
+	}
+	want := map[string]source{
+		"aws_vpc.main":       {"main.tf", 2},
+		"aws_subnet.public":  {"network/subnets.tf", 3},
+		"aws_subnet.private": {"network/subnets.tf", 7},
+		"aws_instance.web":   {"compute.tf.json", 4},
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("ParseConfigDirectory() got %d resources, want %d", len(resources), len(want))
+	}
+	for _, res := range resources {
+		if got := (source{res.SourceFile, res.SourceLine}); got != want[res.ID] {
+			t.Errorf("%s source = %s:%d, want %s:%d", res.ID, got.file, got.line, want[res.ID].file, want[res.ID].line)
+		}
+	}
+}
+
 func TestParseConfigDirectory_MultiCloudProviders(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -264,3 +328,256 @@ resource "digitalocean_droplet" "web" {
 		}
 	}
 }
+
+func TestParseConfigDirectory_NestedBlocks(t *testing.T) {
+	tmpDir := t.TempDir()
+	tfFile := filepath.Join(tmpDir, "main.tf")
+	content := `
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  ingress {
+    from_port = 22
+    to_port   = 22
+    protocol  = "tcp"
+  }
+
+  egress {
+    from_port = 0
+    to_port   = 0
+    protocol  = "-1"
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+`
+	if err := os.WriteFile(tfFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	resources, err := ParseConfigDirectory(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("ParseConfigDirectory() error = %v", err)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("ParseConfigDirectory() got %d resources, want 1", len(resources))
+	}
+
+	attrs := resources[0].Attributes
+	if name, _ := GetStringAttribute(attrs, "name"); name != "web" {
+		t.Errorf("name = %q, want %q", name, "web")
+	}
+
+	ingress, ok := attrs["ingress"].([]interface{})
+	if !ok || len(ingress) != 2 {
+		t.Fatalf("ingress = %#v, want 2 blocks", attrs["ingress"])
+	}
+
+	first, ok := ingress[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("ingress[0] = %#v, want map", ingress[0])
+	}
+	if port, _ := GetIntAttribute(first, "from_port"); port != 443 {
+		t.Errorf("ingress[0].from_port = %d, want 443", port)
+	}
+	if protocol, _ := GetStringAttribute(first, "protocol"); protocol != "tcp" {
+		t.Errorf("ingress[0].protocol = %q, want %q", protocol, "tcp")
+	}
+	if cidrs, _ := GetStringSliceAttribute(first, "cidr_blocks"); len(cidrs) != 1 || cidrs[0] != "0.0.0.0/0" {
+		t.Errorf("ingress[0].cidr_blocks = %v, want [0.0.0.0/0]", cidrs)
+	}
+
+	if egress, ok := attrs["egress"].([]interface{}); !ok || len(egress) != 1 {
+		t.Errorf("egress = %#v, want 1 block", attrs["egress"])
+	}
+	if _, ok := attrs["lifecycle"]; ok {
+		t.Error("lifecycle meta-argument block should not be parsed as an attribute")
+	}
+}
+
+// writeConfigFiles writes count .tf files, each with a VPC and a dependent subnet
+func writeConfigFiles(tb testing.TB, dir string, count int) []string {
+	tb.Helper()
+
+	var files []string
+	for i := 0; i < count; i++ {
+		content := fmt.Sprintf(`
+resource "aws_vpc" "vpc%[1]d" {
+  cidr_block = "10.%[1]d.0.0/16"
+}
+
+resource "aws_subnet" "subnet%[1]d" {
+  vpc_id     = aws_vpc.vpc%[1]d.id
+  cidr_block = "10.%[1]d.1.0/24"
+}
+`, i)
+		path := filepath.Join(dir, fmt.Sprintf("file%03d.tf", i))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			tb.Fatalf("Failed to create test file: %v", err)
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+func TestParseConfigFiles_ConcurrentMatchesSequential(t *testing.T) {
+	files := writeConfigFiles(t, t.TempDir(), 40)
+	ctx := context.Background()
+
+	sequential, fileErrs, err := parseConfigFiles(ctx, files, 1)
+	if err != nil || len(fileErrs) > 0 {
+		t.Fatalf("parseConfigFiles() sequential error = %v, file errors = %v", err, fileErrs)
+	}
+	concurrent, fileErrs, err := parseConfigFiles(ctx, files, 8)
+	if err != nil || len(fileErrs) > 0 {
+		t.Fatalf("parseConfigFiles() concurrent error = %v, file errors = %v", err, fileErrs)
+	}
+
+	if len(sequential) != 80 {
+		t.Fatalf("parseConfigFiles() got %d resources, want 80", len(sequential))
+	}
+	if !reflect.DeepEqual(sequential, concurrent) {
+		t.Error("concurrent parsing produced different results than sequential parsing")
+	}
+	for i := 1; i < len(concurrent); i++ {
+		if concurrent[i-1].ID > concurrent[i].ID {
+			t.Fatalf("resources not sorted by ID: %s before %s", concurrent[i-1].ID, concurrent[i].ID)
+		}
+	}
+}
+
+func TestParseConfigDirectoryWithOptions_InvalidFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfigFiles(t, tmpDir, 1)
+	for _, name := range []string{"bad1.tf", "bad2.tf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(`resource "aws_vpc" {`), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("lenient skips invalid files", func(t *testing.T) {
+		resources, warnings, err := ParseConfigDirectoryWithOptions(ctx, tmpDir, ConfigParseOptions{})
+		if err != nil {
+			t.Fatalf("ParseConfigDirectoryWithOptions() error = %v", err)
+		}
+		if len(resources) != 2 {
+			t.Errorf("got %d resources, want 2 from the valid file", len(resources))
+		}
+		if len(warnings) != 2 {
+			t.Fatalf("got %d warnings, want 2", len(warnings))
+		}
+		if !strings.Contains(warnings[0].Error(), "bad1.tf") || !strings.Contains(warnings[1].Error(), "bad2.tf") {
+			t.Errorf("warnings should name the invalid files in order, got: %v", warnings)
+		}
+	})
+
+	t.Run("ParseConfigDirectory returns valid resources", func(t *testing.T) {
+		resources, err := ParseConfigDirectory(ctx, tmpDir)
+		if err != nil {
+			t.Fatalf("ParseConfigDirectory() error = %v", err)
+		}
+		if len(resources) != 2 {
+			t.Errorf("got %d resources, want 2 from the valid file", len(resources))
+		}
+	})
+
+	t.Run("strict fails fast", func(t *testing.T) {
+		_, _, err := ParseConfigDirectoryWithOptions(ctx, tmpDir, ConfigParseOptions{Strict: true})
+		if err == nil {
+			t.Fatal("ParseConfigDirectoryWithOptions() with Strict should fail for invalid files")
+		}
+		for _, name := range []string{"bad1.tf", "bad2.tf"} {
+			if !strings.Contains(err.Error(), name) {
+				t.Errorf("error should mention %s, got: %v", name, err)
+			}
+		}
+	})
+}
+
+func BenchmarkParseConfigDirectory(b *testing.B) {
+	dir := b.TempDir()
+	writeConfigFiles(b, dir, 200)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseConfigDirectory(ctx, dir); err != nil {
+			b.Fatalf("ParseConfigDirectory() error = %v", err)
+		}
+	}
+}
+
+func TestParseConfigDirectory_JSONFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"network.tf": `
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`,
+		"generated.tf.json": `{
+  "resource": {
+    "aws_subnet": {
+      "public": {
+        "vpc_id": "${aws_vpc.main.id}",
+        "cidr_block": "10.0.1.0/24"
+      }
+    },
+    "aws_instance": {
+      "web": {
+        "ami": "ami-12345",
+        "instance_type": "t3.micro",
+        "depends_on": ["aws_subnet.public"]
+      }
+    }
+  }
+}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	resources, err := ParseConfigDirectory(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("ParseConfigDirectory() error = %v", err)
+	}
+	if len(resources) != 3 {
+		t.Fatalf("ParseConfigDirectory() got %d resources, want 3", len(resources))
+	}
+
+	byID := make(map[string]Resource)
+	for _, res := range resources {
+		byID[res.ID] = res
+	}
+
+	subnet, ok := byID["aws_subnet.public"]
+	if !ok {
+		t.Fatal("aws_subnet.public from .tf.json not found")
+	}
+	if subnet.Provider != "aws" {
+		t.Errorf("aws_subnet.public provider = %s, want aws", subnet.Provider)
+	}
+	if cidr, _ := GetStringAttribute(subnet.Attributes, "cidr_block"); cidr != "10.0.1.0/24" {
+		t.Errorf("aws_subnet.public cidr_block = %q, want %q", cidr, "10.0.1.0/24")
+	}
+	if !reflect.DeepEqual(subnet.Dependencies, []string{"aws_vpc.main"}) {
+		t.Errorf("aws_subnet.public dependencies = %v, want [aws_vpc.main]", subnet.Dependencies)
+	}
+
+	instance := byID["aws_instance.web"]
+	if !reflect.DeepEqual(instance.Dependencies, []string{"aws_subnet.public"}) {
+		t.Errorf("aws_instance.web dependencies = %v, want [aws_subnet.public]", instance.Dependencies)
+	}
+}
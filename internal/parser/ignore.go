@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the file, in the state or configuration directory, listing glob
+// patterns of resources to leave out of the diagram
+const IgnoreFileName = ".cartographyignore"
+
+// LoadIgnorePatterns reads the ignore file in dir, one path.Match pattern per line.
+// Blank lines and lines starting with # are skipped. A missing file yields no patterns.
+func LoadIgnorePatterns(dir string) ([]string, error) {
+	ignorePath := filepath.Join(dir, IgnoreFileName)
+	file, err := os.Open(ignorePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q on line %d of %s: %w", line, lineNum, IgnoreFileName, err)
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+
+	return patterns, nil
+}
+
+// FilterIgnoredResources returns the resources whose ID (type.name) matches none of
+// the patterns, e.g. "aws_cloudwatch_*" drops every CloudWatch resource and "*.logging"
+// every resource named logging. Count/for_each instances ("type.name[0]") also match
+// on their ID without the index.
+func FilterIgnoredResources(resources []Resource, patterns []string) []Resource {
+	if len(patterns) == 0 {
+		return resources
+	}
+
+	filtered := make([]Resource, 0, len(resources))
+	for _, res := range resources {
+		if !isIgnored(res, patterns) {
+			filtered = append(filtered, res)
+		}
+	}
+	return filtered
+}
+
+// isIgnored reports whether a resource matches any ignore pattern
+func isIgnored(res Resource, patterns []string) bool {
+	ids := []string{res.ID, res.Type + "." + res.Name}
+	for _, pattern := range patterns {
+		for _, id := range ids {
+			// Patterns are validated when loaded, so errors cannot occur here
+			if matched, _ := path.Match(pattern, id); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
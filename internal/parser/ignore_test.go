@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadIgnorePatterns(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		patterns, err := LoadIgnorePatterns(t.TempDir())
+		if err != nil || patterns != nil {
+			t.Errorf("LoadIgnorePatterns() = %v, %v; want no patterns and no error", patterns, err)
+		}
+	})
+
+	t.Run("patterns and comments", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "# noisy resources\naws_cloudwatch_*\n\n  *.logging  \n"
+		if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		patterns, err := LoadIgnorePatterns(dir)
+		if err != nil {
+			t.Fatalf("LoadIgnorePatterns() error = %v", err)
+		}
+		if want := []string{"aws_cloudwatch_*", "*.logging"}; !reflect.DeepEqual(patterns, want) {
+			t.Errorf("LoadIgnorePatterns() = %v, want %v", patterns, want)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("aws_[instance\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadIgnorePatterns(dir); err == nil {
+			t.Error("LoadIgnorePatterns() should fail for a malformed pattern")
+		}
+	})
+}
+
+func TestFilterIgnoredResources(t *testing.T) {
+	resources := []Resource{
+		{ID: "aws_instance.web[0]", Type: "aws_instance", Name: "web"},
+		{ID: "aws_cloudwatch_log_group.app", Type: "aws_cloudwatch_log_group", Name: "app"},
+		{ID: "aws_s3_bucket.logging", Type: "aws_s3_bucket", Name: "logging"},
+		{ID: "aws_s3_bucket.assets", Type: "aws_s3_bucket", Name: "assets"},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "no patterns", patterns: nil, want: []string{"aws_instance.web[0]", "aws_cloudwatch_log_group.app", "aws_s3_bucket.logging", "aws_s3_bucket.assets"}},
+		{name: "type prefix", patterns: []string{"aws_cloudwatch_*"}, want: []string{"aws_instance.web[0]", "aws_s3_bucket.logging", "aws_s3_bucket.assets"}},
+		{name: "name", patterns: []string{"*.logging"}, want: []string{"aws_instance.web[0]", "aws_cloudwatch_log_group.app", "aws_s3_bucket.assets"}},
+		{name: "indexed instance", patterns: []string{"aws_instance.web"}, want: []string{"aws_cloudwatch_log_group.app", "aws_s3_bucket.logging", "aws_s3_bucket.assets"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, res := range FilterIgnoredResources(resources, tt.patterns) {
+				got = append(got, res.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterIgnoredResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,14 +9,18 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/hashicorp/go-retryablehttp"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 )
 
 // RemoteStateConfig holds configuration for fetching remote state
@@ -28,9 +32,117 @@ type RemoteStateConfig struct {
 	AWSSecretKey   string
 	AWSSessionToken string // Optional session token for temporary credentials
 	AWSProfile      string // AWS profile name
+	AWSAnonymous    bool   // Fetch S3 state without credentials (public buckets); backend "anonymous" takes priority
 	AzureAccount    string // For Azure Storage
 	AzureKey        string
-	GCPCredentials  string // For GCS (JSON key)
+	GCPCredentials  string // For GCS (credentials JSON or file path); backend "credentials" takes priority
+	HTTPUsername    string // For HTTP backends and state URLs; backend "username" takes priority
+	HTTPPassword    string
+
+	// HTTPClient optionally overrides the underlying client used for HTTP-based
+	// backends (Terraform Cloud, GCS, HTTP), e.g. for custom transports or tests
+	HTTPClient *http.Client
+
+	// HTTPTimeout bounds each HTTP request (0 means no timeout beyond the context).
+	// The backend "timeout" setting takes priority.
+	HTTPTimeout time.Duration
+	// HTTPRetryMax sets the retry count for HTTP requests (0 uses the default of 3).
+	// The backend "retry_max" setting takes priority.
+	HTTPRetryMax int
+	// HTTPRetryWaitMin and HTTPRetryWaitMax bound the exponential backoff between retries
+	// (0 uses the defaults of 1s and 30s). The backend "retry_wait_min" and
+	// "retry_wait_max" settings take priority. A Retry-After header on 429 and 503
+	// responses overrides the backoff.
+	HTTPRetryWaitMin time.Duration
+	HTTPRetryWaitMax time.Duration
+}
+
+// ErrNoStateVersion is returned when a Terraform Cloud/Enterprise workspace exists but
+// has no state yet, e.g. before its first apply
+var ErrNoStateVersion = errors.New("workspace has no state versions yet")
+
+// defaultHTTPRetryMax is the number of retries used when none is configured
+const defaultHTTPRetryMax = 3
+
+// newRetryableClient creates the retrying HTTP client used for HTTP-based backends.
+// Requests are still bounded by the caller's context, which acts as the hard deadline.
+func newRetryableClient(config *RemoteStateConfig) (*retryablehttp.Client, error) {
+	retryMax, err := httpRetryMax(config)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := backendDuration(config, "timeout", config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	waitMin, err := backendDuration(config, "retry_wait_min", config.HTTPRetryWaitMin)
+	if err != nil {
+		return nil, err
+	}
+	waitMax, err := backendDuration(config, "retry_wait_max", config.HTTPRetryWaitMax)
+	if err != nil {
+		return nil, err
+	}
+
+	// The default backoff honors Retry-After on 429 and 503 responses
+	client := retryablehttp.NewClient()
+	client.RetryMax = retryMax
+	if waitMin > 0 {
+		client.RetryWaitMin = waitMin
+	}
+	if waitMax > 0 {
+		client.RetryWaitMax = waitMax
+	}
+	client.Logger = nil // Disable logging
+	if config.HTTPClient != nil {
+		// Copy so the timeout doesn't leak into the caller's client
+		httpClient := *config.HTTPClient
+		client.HTTPClient = &httpClient
+	}
+	if timeout > 0 {
+		client.HTTPClient.Timeout = timeout
+	}
+	return client, nil
+}
+
+// httpRetryMax resolves the retry count from backend config, then RemoteStateConfig, then the default
+func httpRetryMax(config *RemoteStateConfig) (int, error) {
+	if config.Backend != nil {
+		if _, set := config.Backend.Config["retry_max"]; set {
+			retryMax, ok := GetIntAttribute(config.Backend.Config, "retry_max")
+			if !ok || retryMax < 0 {
+				return 0, fmt.Errorf("invalid retry_max in backend configuration: %v", config.Backend.Config["retry_max"])
+			}
+			return retryMax, nil
+		}
+	}
+
+	if config.HTTPRetryMax > 0 {
+		return config.HTTPRetryMax, nil
+	}
+	return defaultHTTPRetryMax, nil
+}
+
+// backendDuration resolves a duration setting such as "timeout" from backend config,
+// then fallback from RemoteStateConfig. The backend value may be a number of seconds or
+// a duration string such as "30s".
+func backendDuration(config *RemoteStateConfig, key string, fallback time.Duration) (time.Duration, error) {
+	if config.Backend != nil {
+		if raw, set := config.Backend.Config[key]; set {
+			if str, ok := raw.(string); ok {
+				if duration, err := time.ParseDuration(str); err == nil {
+					return duration, nil
+				}
+			}
+			seconds, ok := GetFloat64Attribute(config.Backend.Config, key)
+			if !ok || seconds < 0 {
+				return 0, fmt.Errorf("invalid %s in backend configuration: %v", key, raw)
+			}
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+	}
+
+	return fallback, nil
 }
 
 // getCredentialFromBackendOrEnv gets a credential from backend config, then env var, then fallback
@@ -65,7 +177,7 @@ func FetchRemoteState(ctx context.Context, config *RemoteStateConfig) ([]byte, e
 	case BackendTypeHTTP:
 		return fetchHTTPState(ctx, config)
 	default:
-		return nil, fmt.Errorf("remote state fetching not supported for backend type: %s", config.Backend.Type)
+		return nil, withKind(ErrUnsupportedBackend, fmt.Errorf("remote state fetching not supported for backend type: %s", config.Backend.Type))
 	}
 }
 
@@ -96,7 +208,7 @@ func fetchTerraformCloudState(ctx context.Context, config *RemoteStateConfig) ([
 		token = os.Getenv("TF_TOKEN_" + strings.ReplaceAll(organization, "-", "_"))
 	}
 	if token == "" {
-		return nil, fmt.Errorf("Terraform Cloud token not found. Set TFE_TOKEN environment variable or provider configuration")
+		return nil, withKind(ErrBackendAuth, fmt.Errorf("Terraform Cloud token not found. Set TFE_TOKEN environment variable or provider configuration"))
 	}
 
 	// Determine hostname (default to app.terraform.io)
@@ -110,9 +222,10 @@ func fetchTerraformCloudState(ctx context.Context, config *RemoteStateConfig) ([
 		hostname, organization, workspaceName)
 
 	// Fetch workspace details to get current state version
-	client := retryablehttp.NewClient()
-	client.RetryMax = 3
-	client.Logger = nil // Disable logging
+	client, err := newRetryableClient(config)
+	if err != nil {
+		return nil, err
+	}
 
 	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", workspaceURL, nil)
 	if err != nil {
@@ -129,7 +242,7 @@ func fetchTerraformCloudState(ctx context.Context, config *RemoteStateConfig) ([
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to fetch workspace (status %d): %s", resp.StatusCode, string(body))
+		return nil, withKind(statusKind(resp.StatusCode), fmt.Errorf("failed to fetch workspace (status %d): %s", resp.StatusCode, string(body)))
 	}
 
 	var workspaceResp struct {
@@ -150,7 +263,7 @@ func fetchTerraformCloudState(ctx context.Context, config *RemoteStateConfig) ([
 
 	stateVersionID := workspaceResp.Data.Relationships.CurrentStateVersion.Data.ID
 	if stateVersionID == "" {
-		return nil, fmt.Errorf("no current state version found for workspace")
+		return nil, withKind(ErrNoStateFound, fmt.Errorf("%w: %s/%s", ErrNoStateVersion, organization, workspaceName))
 	}
 
 	// Fetch the actual state file
@@ -171,7 +284,7 @@ func fetchTerraformCloudState(ctx context.Context, config *RemoteStateConfig) ([
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to fetch state (status %d): %s", resp.StatusCode, string(body))
+		return nil, withKind(statusKind(resp.StatusCode), fmt.Errorf("failed to fetch state (status %d): %s", resp.StatusCode, string(body)))
 	}
 
 	return io.ReadAll(resp.Body)
@@ -222,12 +335,24 @@ func fetchS3State(ctx context.Context, remoteConfig *RemoteStateConfig) ([]byte,
 		profile = remoteConfig.AWSProfile
 	}
 
+	// Public buckets can be read without any credentials
+	anonymous := remoteConfig.AWSAnonymous
+	if value, ok := GetBoolAttribute(backend.Config, "anonymous"); ok {
+		anonymous = value
+	}
+
 	// Build AWS config with proper credential chain
 	var cfg aws.Config
 	var err error
 
-	// Priority 1: Use explicit credentials if provided
-	if accessKey != "" && secretKey != "" {
+	if anonymous {
+		// Unsigned requests: skip the credential chain entirely so no AWS setup is needed
+		cfg, err = config.LoadDefaultConfig(ctx,
+			config.WithRegion(region),
+			config.WithCredentialsProvider(aws.AnonymousCredentials{}),
+		)
+	} else if accessKey != "" && secretKey != "" {
+		// Priority 1: Use explicit credentials if provided
 		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(region),
 			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
@@ -253,8 +378,15 @@ func fetchS3State(ctx context.Context, remoteConfig *RemoteStateConfig) ([]byte,
 		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
 	}
 
-	// Create S3 client
-	client := s3.NewFromConfig(cfg)
+	// Create S3 client, honoring a custom endpoint (S3-compatible storage, local mocks)
+	endpoint, _ := backend.Config["endpoint"].(string)
+	usePathStyle, _ := GetBoolAttribute(backend.Config, "use_path_style")
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = usePathStyle
+	})
 
 	// Get the object from S3
 	result, err := client.GetObject(ctx, &s3.GetObjectInput{
@@ -262,13 +394,19 @@ func fetchS3State(ctx context.Context, remoteConfig *RemoteStateConfig) ([]byte,
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch state from S3 (bucket=%s, key=%s, region=%s): %w\n"+
+		var kind error
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) {
+			kind = statusKind(respErr.HTTPStatusCode())
+		}
+		return nil, withKind(kind, fmt.Errorf("failed to fetch state from S3 (bucket=%s, key=%s, region=%s): %w\n"+
 			"Hint: Ensure AWS credentials are configured via:\n"+
 			"  1. Provider config (aws_access_key, aws_secret_key)\n"+
 			"  2. Environment variables (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY)\n"+
 			"  3. AWS shared credentials file (~/.aws/credentials)\n"+
-			"  4. IAM role (if running on EC2, ECS, Lambda, etc.)",
-			bucket, key, region, err)
+			"  4. IAM role (if running on EC2, ECS, Lambda, etc.)\n"+
+			"  5. anonymous = true for publicly readable buckets",
+			bucket, key, region, err))
 	}
 	defer result.Body.Close()
 
@@ -285,8 +423,12 @@ func fetchS3State(ctx context.Context, remoteConfig *RemoteStateConfig) ([]byte,
 func fetchAzureState(ctx context.Context, remoteConfig *RemoteStateConfig) ([]byte, error) {
 	backend := remoteConfig.Backend
 
-	storageAccount, ok := backend.Config["storage_account_name"].(string)
-	if !ok || storageAccount == "" {
+	// Storage account from backend config, falling back to provider config
+	storageAccount, _ := backend.Config["storage_account_name"].(string)
+	if storageAccount == "" {
+		storageAccount = remoteConfig.AzureAccount
+	}
+	if storageAccount == "" {
 		return nil, fmt.Errorf("storage_account_name not specified in azurerm backend configuration")
 	}
 
@@ -310,11 +452,11 @@ func fetchAzureState(ctx context.Context, remoteConfig *RemoteStateConfig) ([]by
 	}
 
 	if accountKey == "" {
-		return nil, fmt.Errorf("Azure Storage account key not found. Set one of:\n"+
+		return nil, withKind(ErrBackendAuth, fmt.Errorf("Azure Storage account key not found. Set one of:\n"+
 			"  1. Backend config: access_key in azurerm backend block\n"+
 			"  2. Environment variable: ARM_ACCESS_KEY\n"+
 			"  3. Environment variable: AZURE_STORAGE_KEY\n"+
-			"  4. Provider config: azure_key (optional)")
+			"  4. Provider config: azure_key (optional)"))
 	}
 
 	// Create credential from account key
@@ -339,16 +481,16 @@ func fetchAzureState(ctx context.Context, remoteConfig *RemoteStateConfig) ([]by
 		var respErr *azcore.ResponseError
 		if ok := errors.As(err, &respErr); ok {
 			if respErr.StatusCode == 404 {
-				return nil, fmt.Errorf("state file not found in Azure Storage (account=%s, container=%s, key=%s)",
-					storageAccount, containerName, key)
+				return nil, withKind(ErrNoStateFound, fmt.Errorf("state file not found in Azure Storage (account=%s, container=%s, key=%s)",
+					storageAccount, containerName, key))
 			}
 			if respErr.StatusCode == 403 {
-				return nil, fmt.Errorf("access denied to Azure Storage. Verify:\n"+
+				return nil, withKind(ErrBackendAuth, fmt.Errorf("access denied to Azure Storage. Verify:\n"+
 					"  - Storage account name is correct\n"+
 					"  - Account key is valid\n"+
 					"  - Container exists and is accessible\n"+
 					"  (account=%s, container=%s, key=%s)",
-					storageAccount, containerName, key)
+					storageAccount, containerName, key))
 			}
 		}
 		return nil, fmt.Errorf("failed to download from Azure Storage: %w", err)
@@ -379,15 +521,29 @@ func fetchGCSState(ctx context.Context, config *RemoteStateConfig) ([]byte, erro
 	// Try fetching with anonymous/public access
 	gcsURL := fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, prefix)
 
-	client := retryablehttp.NewClient()
-	client.RetryMax = 3
-	client.Logger = nil
+	client, err := newRetryableClient(config)
+	if err != nil {
+		return nil, err
+	}
 
 	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", gcsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GCS request: %w", err)
 	}
 
+	// Authenticate when credentials are configured; public buckets are read anonymously
+	tokenSource, err := gcsTokenSource(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if tokenSource != nil {
+		token, err := tokenSource.Token()
+		if err != nil {
+			return nil, withKind(ErrBackendAuth, fmt.Errorf("failed to obtain GCS access token: %w", err))
+		}
+		token.SetAuthHeader(req.Request)
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch from GCS: %w", err)
@@ -395,18 +551,20 @@ func fetchGCSState(ctx context.Context, config *RemoteStateConfig) ([]byte, erro
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 403 || resp.StatusCode == 401 {
-		return nil, fmt.Errorf("GCS bucket requires authentication. This provider currently supports:\n"+
-			"  1. Public GCS buckets (no credentials needed)\n"+
-			"  2. Terraform Cloud backend (use terraform_token)\n"+
-			"\nFor private GCS buckets, please:\n"+
-			"  - Make the state file publicly readable, OR\n"+
-			"  - Use Terraform Cloud backend instead, OR\n"+
-			"  - Export state locally: terraform state pull > terraform.tfstate")
+		if tokenSource != nil {
+			return nil, withKind(ErrBackendAuth, fmt.Errorf("GCS denied access to bucket=%s, prefix=%s (HTTP %d); check that the credentials can read the state object",
+				bucket, prefix, resp.StatusCode))
+		}
+		return nil, withKind(ErrBackendAuth, fmt.Errorf("GCS bucket requires authentication. Provide credentials via:\n"+
+			"  1. The backend \"credentials\" setting (JSON or file path)\n"+
+			"  2. The provider gcp_credentials attribute\n"+
+			"  3. The GOOGLE_APPLICATION_CREDENTIALS environment variable\n"+
+			"\nService account keys and workload identity federation configurations are supported."))
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("GCS returned HTTP %d for bucket=%s, prefix=%s",
-			resp.StatusCode, bucket, prefix)
+		return nil, withKind(statusKind(resp.StatusCode), fmt.Errorf("GCS returned HTTP %d for bucket=%s, prefix=%s",
+			resp.StatusCode, bucket, prefix))
 	}
 
 	data, err := io.ReadAll(resp.Body)
@@ -417,6 +575,47 @@ func fetchGCSState(ctx context.Context, config *RemoteStateConfig) ([]byte, erro
 	return data, nil
 }
 
+// gcsReadOnlyScope is the OAuth scope requested for reading GCS state
+const gcsReadOnlyScope = "https://www.googleapis.com/auth/devstorage.read_only"
+
+// gcsTokenSource returns a token source for the configured GCS credentials, or nil when
+// none are configured. Credentials are resolved from the backend "credentials" setting,
+// then RemoteStateConfig.GCPCredentials, then GOOGLE_APPLICATION_CREDENTIALS; each may be
+// the JSON itself or a path to it. Any type accepted by google.CredentialsFromJSON works,
+// including workload identity federation (external_account) configurations.
+func gcsTokenSource(ctx context.Context, config *RemoteStateConfig) (oauth2.TokenSource, error) {
+	credentials := config.GCPCredentials
+	if value, ok := config.Backend.Config["credentials"].(string); ok && value != "" {
+		credentials = value
+	}
+	if credentials == "" {
+		credentials = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if credentials == "" {
+		return nil, nil
+	}
+
+	jsonData := []byte(credentials)
+	if !strings.HasPrefix(strings.TrimSpace(credentials), "{") {
+		data, err := os.ReadFile(credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GCS credentials file: %w", err)
+		}
+		jsonData = data
+	}
+
+	// Token requests (including federated token exchange) use the configured client
+	if config.HTTPClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, config.HTTPClient)
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, jsonData, gcsReadOnlyScope)
+	if err != nil {
+		return nil, withKind(ErrBackendAuth, fmt.Errorf("invalid GCS credentials: %w", err))
+	}
+	return creds.TokenSource, nil
+}
+
 // fetchHTTPState retrieves state from HTTP/HTTPS endpoint
 func fetchHTTPState(ctx context.Context, config *RemoteStateConfig) ([]byte, error) {
 	address, ok := config.Backend.Config["address"].(string)
@@ -424,9 +623,10 @@ func fetchHTTPState(ctx context.Context, config *RemoteStateConfig) ([]byte, err
 		return nil, fmt.Errorf("address not specified in HTTP backend configuration")
 	}
 
-	client := retryablehttp.NewClient()
-	client.RetryMax = 3
-	client.Logger = nil
+	client, err := newRetryableClient(config)
+	if err != nil {
+		return nil, err
+	}
 
 	req, err := retryablehttp.NewRequestWithContext(ctx, "GET", address, nil)
 	if err != nil {
@@ -434,10 +634,10 @@ func fetchHTTPState(ctx context.Context, config *RemoteStateConfig) ([]byte, err
 	}
 
 	// Add optional authentication
-	if username, ok := config.Backend.Config["username"].(string); ok && username != "" {
-		if password, ok := config.Backend.Config["password"].(string); ok && password != "" {
-			req.SetBasicAuth(username, password)
-		}
+	username := getCredentialFromBackendOrEnv(config.Backend, "username", nil, config.HTTPUsername)
+	password := getCredentialFromBackendOrEnv(config.Backend, "password", nil, config.HTTPPassword)
+	if username != "" && password != "" {
+		req.SetBasicAuth(username, password)
 	}
 
 	resp, err := client.Do(req)
@@ -448,7 +648,7 @@ func fetchHTTPState(ctx context.Context, config *RemoteStateConfig) ([]byte, err
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to fetch state (status %d): %s", resp.StatusCode, string(body))
+		return nil, withKind(statusKind(resp.StatusCode), fmt.Errorf("failed to fetch state (status %d): %s", resp.StatusCode, string(body)))
 	}
 
 	return io.ReadAll(resp.Body)
@@ -474,45 +674,84 @@ func LoadStateFromBackend(ctx context.Context, config *RemoteStateConfig) ([]Res
 	// Parse the state data
 	var state TerraformState
 	if err := json.Unmarshal(stateData, &state); err != nil {
-		return nil, fmt.Errorf("failed to parse remote state: %w", err)
+		return nil, withKind(ErrParse, fmt.Errorf("failed to parse remote state: %w", err))
 	}
 
-	// Extract resources (same logic as ParseStateFile)
-	var stateResources []StateResource
-	if state.Values != nil && state.Values.RootModule != nil {
-		stateResources = state.Values.RootModule.Resources
-	} else {
-		stateResources = state.Resources
+	return resourcesFromState(&state), nil
+}
+
+// LoadWorkspaceStates loads the state of each named workspace of a Terraform Cloud/Enterprise
+// (remote) backend, in place of the backend's own workspace, and merges the resources into
+// one set. Resource IDs are prefixed with the workspace name (see MergeWorkspaceResources).
+func LoadWorkspaceStates(ctx context.Context, config *RemoteStateConfig, workspaces []string) ([]Resource, error) {
+	if BackendType(config.Backend.Type) != BackendTypeRemote {
+		return nil, withKind(ErrUnsupportedBackend, fmt.Errorf("multiple workspaces are only supported for the remote backend, not %s", config.Backend.Type))
 	}
 
-	var resources []Resource
-	for _, stateRes := range stateResources {
-		if stateRes.Mode != "managed" {
-			continue
+	resourceSets := make(map[string][]Resource, len(workspaces))
+	for _, workspace := range workspaces {
+		workspaceConfig := *config
+		workspaceConfig.Backend = withWorkspaceName(config.Backend, workspace)
+
+		resources, err := LoadStateFromBackend(ctx, &workspaceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workspace %s: %w", workspace, err)
 		}
+		resourceSets[workspace] = resources
+	}
 
-		provider := extractProvider(stateRes.Type)
+	return MergeWorkspaceResources(workspaces, resourceSets), nil
+}
 
-		for idx, instance := range stateRes.Instances {
-			var resourceID string
-			if len(stateRes.Instances) == 1 {
-				resourceID = fmt.Sprintf("%s.%s", stateRes.Type, stateRes.Name)
-			} else {
-				resourceID = fmt.Sprintf("%s.%s[%d]", stateRes.Type, stateRes.Name, idx)
+// MergeWorkspaceResources combines the resources of several workspaces, in the order of
+// workspaces, into one set. Resource IDs and dependencies are prefixed with "<workspace>:"
+// (e.g. "prod:aws_vpc.main") so resources with the same address in different workspaces
+// stay distinct; dependencies therefore never link resources across workspaces.
+func MergeWorkspaceResources(workspaces []string, resourceSets map[string][]Resource) []Resource {
+	var merged []Resource
+	for _, workspace := range workspaces {
+		prefix := workspace + ":"
+		for _, res := range resourceSets[workspace] {
+			res.ID = prefix + res.ID
+			dependencies := make([]string, len(res.Dependencies))
+			for i, dep := range res.Dependencies {
+				dependencies[i] = prefix + dep
 			}
+			res.Dependencies = dependencies
+			merged = append(merged, res)
+		}
+	}
+	return merged
+}
 
-			resource := Resource{
-				Type:         stateRes.Type,
-				Name:         stateRes.Name,
-				Provider:     provider,
-				Attributes:   instance.Attributes,
-				ID:           resourceID,
-				Dependencies: instance.Dependencies,
-			}
+// withWorkspaceName returns a copy of a remote backend configured for the named workspace
+func withWorkspaceName(backend *BackendConfig, workspace string) *BackendConfig {
+	copied := *backend
+	copied.Config = make(map[string]interface{}, len(backend.Config))
+	for key, value := range backend.Config {
+		copied.Config[key] = value
+	}
+	copied.Config["workspaces"] = map[string]interface{}{"name": workspace}
+	return &copied
+}
 
-			resources = append(resources, resource)
-		}
+// IsStateURL reports whether a state path is an HTTP(S) URL rather than a local file
+func IsStateURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// ParseStateURL fetches a state file from an HTTP(S) URL and parses it.
+// The request uses the retry, timeout and basic auth settings of config, which may be nil.
+func ParseStateURL(ctx context.Context, address string, config *RemoteStateConfig) ([]Resource, error) {
+	urlConfig := RemoteStateConfig{}
+	if config != nil {
+		urlConfig = *config
+	}
+	urlConfig.Backend = &BackendConfig{
+		Type:   string(BackendTypeHTTP),
+		Config: map[string]interface{}{"address": address},
 	}
 
-	return resources, nil
+	return LoadStateFromBackend(ctx, &urlConfig)
 }
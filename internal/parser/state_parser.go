@@ -3,22 +3,42 @@ package parser
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
 // TerraformState represents the structure of a terraform.tfstate file
 type TerraformState struct {
-	Version          int                `json:"version"`
-	TerraformVersion string             `json:"terraform_version"`
-	Resources        []StateResource    `json:"resources"`        // Legacy format (v3 and below)
-	Values           *StateValues       `json:"values,omitempty"` // Modern format (v4+)
+	Version          int                    `json:"version"`
+	TerraformVersion string                 `json:"terraform_version"`
+	Resources        []StateResource        `json:"resources"`         // Legacy format (v3 and below)
+	Outputs          map[string]StateOutput `json:"outputs,omitempty"` // Root module outputs
+	Values           *StateValues           `json:"values,omitempty"`  // Modern format (v4+)
 }
 
 // StateValues represents the values section in modern state files
 type StateValues struct {
-	RootModule *StateModule `json:"root_module,omitempty"`
+	Outputs    map[string]StateOutput `json:"outputs,omitempty"`
+	RootModule *StateModule           `json:"root_module,omitempty"`
+}
+
+// StateOutput represents a root module output in the state file
+type StateOutput struct {
+	Value     interface{} `json:"value"`
+	Sensitive bool        `json:"sensitive,omitempty"`
+}
+
+// Output is a root module output value of a Terraform state
+type Output struct {
+	Name      string
+	Value     interface{} // Decoded JSON value: string, number, bool, list or map
+	Sensitive bool
 }
 
 // StateModule represents a module in the state file
@@ -42,8 +62,67 @@ type StateResourceInstance struct {
 }
 
 // ParseStateFile reads and parses a Terraform state file.
+// HTTP(S) URLs are fetched without credentials; use ParseStateURL to authenticate.
 // It respects the provided context for cancellation.
 func ParseStateFile(ctx context.Context, path string) ([]Resource, error) {
+	if IsStateURL(path) {
+		return ParseStateURL(ctx, path, nil)
+	}
+	return ParseStateFS(ctx, os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// DefaultKeepAttributes is a StateParseOptions.KeepAttributes allowlist that keeps the
+// identifying, placement and tagging attributes of each resource
+var DefaultKeepAttributes = []string{"id", "name", "region", "location", "cidr*", "*_id", "vpc_id", "tags", "labels"}
+
+// StateParseOptions controls how ParseStateFileWithOptions processes resources
+type StateParseOptions struct {
+	// KeepAttributes trims each resource's Attributes to the keys matching one of these
+	// path.Match patterns, e.g. "*_id", dropping large blobs such as user_data or policy
+	// JSON. Dependencies are kept regardless. Empty keeps every attribute.
+	KeepAttributes []string
+}
+
+// ParseStateFileWithOptions reads and parses a Terraform state file like ParseStateFile,
+// applying opts to the parsed resources
+func ParseStateFileWithOptions(ctx context.Context, statePath string, opts StateParseOptions) ([]Resource, error) {
+	for _, pattern := range opts.KeepAttributes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid attribute pattern %q: %w", pattern, err)
+		}
+	}
+
+	resources, err := ParseStateFile(ctx, statePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.KeepAttributes) > 0 {
+		for i := range resources {
+			resources[i].Attributes = keepAttributes(resources[i].Attributes, opts.KeepAttributes)
+		}
+	}
+	return resources, nil
+}
+
+// keepAttributes returns the attributes whose key matches any of the patterns
+func keepAttributes(attrs map[string]interface{}, patterns []string) map[string]interface{} {
+	kept := make(map[string]interface{})
+	for key, value := range attrs {
+		for _, pattern := range patterns {
+			// Patterns are validated before parsing, so errors cannot occur here
+			if matched, _ := path.Match(pattern, key); matched {
+				kept[key] = value
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// ParseStateFS reads and parses a Terraform state file from an arbitrary filesystem
+// (embedded files, archives, in-memory filesystems, etc.).
+// It respects the provided context for cancellation.
+func ParseStateFS(ctx context.Context, fsys fs.FS, name string) ([]Resource, error) {
 	// Check if context is already cancelled
 	select {
 	case <-ctx.Done():
@@ -51,16 +130,69 @@ func ParseStateFile(ctx context.Context, path string) ([]Resource, error) {
 	default:
 	}
 
-	data, err := os.ReadFile(path)
+	state, err := readStateFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return resourcesFromState(state), nil
+}
+
+// ParseStateOutputs reads the root module outputs of a local Terraform state file,
+// sorted by name. It respects the provided context for cancellation.
+func ParseStateOutputs(ctx context.Context, path string) ([]Output, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	state, err := readStateFS(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return outputsFromState(state), nil
+}
+
+// readStateFS reads and decodes a Terraform state file from fsys
+func readStateFS(fsys fs.FS, name string) (*TerraformState, error) {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state file: %w", err)
+		err = fmt.Errorf("failed to read state file: %w", err)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, withKind(ErrNoStateFound, err)
+		}
+		return nil, err
 	}
 
 	var state TerraformState
 	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("failed to parse state file: %w", err)
+		return nil, withKind(ErrParse, fmt.Errorf("failed to parse state file: %w", err))
+	}
+
+	return &state, nil
+}
+
+// outputsFromState extracts the root module outputs from a decoded state file, from
+// values.outputs in the modern format and the root outputs otherwise
+func outputsFromState(state *TerraformState) []Output {
+	stateOutputs := state.Outputs
+	if state.Values != nil && state.Values.Outputs != nil {
+		stateOutputs = state.Values.Outputs
 	}
 
+	outputs := make([]Output, 0, len(stateOutputs))
+	for name, output := range stateOutputs {
+		outputs = append(outputs, Output{Name: name, Value: output.Value, Sensitive: output.Sensitive})
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+
+	return outputs
+}
+
+// resourcesFromState extracts managed resources from a decoded state file
+func resourcesFromState(state *TerraformState) []Resource {
 	// Determine which format we're dealing with
 	var stateResources []StateResource
 	if state.Values != nil && state.Values.RootModule != nil {
@@ -79,6 +211,12 @@ func ParseStateFile(ctx context.Context, path string) ([]Resource, error) {
 		}
 
 		provider := extractProvider(stateRes.Type)
+		if provider == "unknown" {
+			// Third-party providers are not recognized by type prefix; use the provider source instead
+			if name := providerFromSource(stateRes.Provider); name != "" {
+				provider = name
+			}
+		}
 
 		for idx, instance := range stateRes.Instances {
 			// Generate ID - use simple format for single instances, indexed for multiple
@@ -104,7 +242,7 @@ func ParseStateFile(ctx context.Context, path string) ([]Resource, error) {
 		}
 	}
 
-	return resources, nil
+	return resources
 }
 
 // extractProvider determines the cloud provider from the resource type
@@ -117,6 +255,40 @@ func extractProvider(resourceType string) string {
 		return "gcp"
 	} else if strings.HasPrefix(resourceType, "digitalocean_") {
 		return "digitalocean"
+	} else if strings.HasPrefix(resourceType, "alicloud_") {
+		return "alicloud"
 	}
 	return "unknown"
 }
+
+// providerSourceAliases maps provider type names to the names used by extractProvider
+var providerSourceAliases = map[string]string{
+	"azurerm":     "azure",
+	"google":      "gcp",
+	"google-beta": "gcp",
+}
+
+// providerFromSource derives the provider name from a state resource's provider field.
+// It accepts both the modern form (provider["registry.terraform.io/cloudflare/cloudflare"],
+// optionally followed by .alias) and the legacy form (provider.cloudflare or provider.cloudflare.alias).
+// Returns an empty string if the field cannot be parsed.
+func providerFromSource(source string) string {
+	var name string
+	if start := strings.Index(source, `provider["`); start >= 0 {
+		address := source[start+len(`provider["`):]
+		end := strings.Index(address, `"]`)
+		if end < 0 {
+			return ""
+		}
+		address = address[:end]
+		name = address[strings.LastIndex(address, "/")+1:]
+	} else if rest, ok := strings.CutPrefix(source, "provider."); ok {
+		name, _, _ = strings.Cut(rest, ".")
+	}
+
+	name = strings.ToLower(name)
+	if alias, ok := providerSourceAliases[name]; ok {
+		return alias
+	}
+	return name
+}
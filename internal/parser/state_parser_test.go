@@ -2,9 +2,12 @@ package parser
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
+	"testing/fstest"
 )
 
 func TestParseStateFile(t *testing.T) {
@@ -86,6 +89,25 @@ func TestParseStateFile(t *testing.T) {
 			wantProvider:  "azure",
 			wantErr:       false,
 		},
+		{
+			name: "legacy state format v3 with unrecognized type",
+			stateContent: `{
+				"version": 3,
+				"terraform_version": "0.12.0",
+				"resources": [
+					{
+						"mode": "managed",
+						"type": "heroku_app",
+						"name": "api",
+						"provider": "provider.heroku.eu",
+						"instances": [{"attributes": {"id": "api-eu"}}]
+					}
+				]
+			}`,
+			wantResources: 1,
+			wantProvider:  "heroku",
+			wantErr:       false,
+		},
 		{
 			name: "multiple instances",
 			stateContent: `{
@@ -227,6 +249,106 @@ func TestParseStateFile_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestParseStateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"states/prod.tfstate": &fstest.MapFile{Data: []byte(`{
+			"version": 4,
+			"terraform_version": "1.5.0",
+			"values": {
+				"root_module": {
+					"resources": [
+						{
+							"mode": "managed",
+							"type": "aws_vpc",
+							"name": "main",
+							"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+							"instances": [{"attributes": {"id": "vpc-123"}}]
+						},
+						{
+							"mode": "data",
+							"type": "aws_ami",
+							"name": "ubuntu",
+							"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+							"instances": [{"attributes": {"id": "ami-123"}}]
+						}
+					]
+				}
+			}
+		}`)},
+		"states/broken.tfstate": &fstest.MapFile{Data: []byte(`{not json`)},
+	}
+
+	ctx := context.Background()
+
+	resources, err := ParseStateFS(ctx, fsys, "states/prod.tfstate")
+	if err != nil {
+		t.Fatalf("ParseStateFS() error = %v", err)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("ParseStateFS() got %d resources, want 1", len(resources))
+	}
+	if resources[0].ID != "aws_vpc.main" || resources[0].Provider != "aws" {
+		t.Errorf("ParseStateFS() got resource %s (%s), want aws_vpc.main (aws)", resources[0].ID, resources[0].Provider)
+	}
+
+	if _, err := ParseStateFS(ctx, fsys, "states/missing.tfstate"); err == nil {
+		t.Error("ParseStateFS() with missing file should return error")
+	}
+	if _, err := ParseStateFS(ctx, fsys, "states/broken.tfstate"); err == nil {
+		t.Error("ParseStateFS() with invalid JSON should return error")
+	}
+}
+
+func TestParseStateOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	states := map[string]string{
+		"modern.tfstate": `{
+			"version": 4,
+			"values": {
+				"outputs": {
+					"lb_dns": {"value": "web-123.elb.amazonaws.com", "type": "string"},
+					"db_password": {"value": "hunter2", "type": "string", "sensitive": true},
+					"subnet_ids": {"value": ["subnet-1", "subnet-2"]}
+				},
+				"root_module": {"resources": []}
+			}
+		}`,
+		"legacy.tfstate": `{
+			"version": 4,
+			"outputs": {
+				"lb_dns": {"value": "web-123.elb.amazonaws.com", "type": "string"}
+			},
+			"resources": []
+		}`,
+	}
+	for name, content := range states {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test state file: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	outputs, err := ParseStateOutputs(ctx, filepath.Join(tmpDir, "modern.tfstate"))
+	if err != nil {
+		t.Fatalf("ParseStateOutputs() error = %v", err)
+	}
+	if got := fmt.Sprint(outputs); got != "[{db_password hunter2 true} {lb_dns web-123.elb.amazonaws.com false} {subnet_ids [subnet-1 subnet-2] false}]" {
+		t.Errorf("ParseStateOutputs() = %s", got)
+	}
+
+	outputs, err = ParseStateOutputs(ctx, filepath.Join(tmpDir, "legacy.tfstate"))
+	if err != nil {
+		t.Fatalf("ParseStateOutputs() error = %v", err)
+	}
+	if len(outputs) != 1 || outputs[0].Name != "lb_dns" || outputs[0].Value != "web-123.elb.amazonaws.com" {
+		t.Errorf("ParseStateOutputs() of root outputs = %v", outputs)
+	}
+
+	if _, err := ParseStateOutputs(ctx, filepath.Join(tmpDir, "missing.tfstate")); err == nil {
+		t.Error("ParseStateOutputs() with missing file should return error")
+	}
+}
+
 func TestExtractProvider(t *testing.T) {
 	tests := []struct {
 		resourceType string
@@ -240,6 +362,8 @@ func TestExtractProvider(t *testing.T) {
 		{"google_storage_bucket", "gcp"},
 		{"digitalocean_droplet", "digitalocean"},
 		{"digitalocean_loadbalancer", "digitalocean"},
+		{"alicloud_instance", "alicloud"},
+		{"alicloud_vswitch", "alicloud"},
 		{"random_string", "unknown"},
 		{"null_resource", "unknown"},
 		{"", "unknown"},
@@ -255,6 +379,224 @@ func TestExtractProvider(t *testing.T) {
 	}
 }
 
+func TestGetResourceType_Alicloud(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		want         ResourceType
+	}{
+		{"alicloud_vpc", ResourceTypeNetwork},
+		{"alicloud_vswitch", ResourceTypeNetwork},
+		{"alicloud_instance", ResourceTypeCompute},
+		{"alicloud_slb", ResourceTypeLoadBalancer},
+		{"alicloud_oss_bucket", ResourceTypeStorage},
+		{"alicloud_db_instance", ResourceTypeDatabase},
+		{"alicloud_ram_role", ResourceTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			if got := GetResourceType(tt.resourceType); got != tt.want {
+				t.Errorf("GetResourceType(%s) = %v, want %v", tt.resourceType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportedResourceTypes(t *testing.T) {
+	supported := SupportedResourceTypes()
+
+	for _, provider := range []string{"aws", "azure", "digitalocean", "alicloud"} {
+		types := supported[provider]
+		if len(types) == 0 {
+			t.Errorf("SupportedResourceTypes()[%q] is empty", provider)
+			continue
+		}
+		if !sort.StringsAreSorted(types) {
+			t.Errorf("SupportedResourceTypes()[%q] is not sorted", provider)
+		}
+		for _, resourceType := range types {
+			if GetResourceType(resourceType) == ResourceTypeUnknown {
+				t.Errorf("GetResourceType(%s) = unknown, want a known category", resourceType)
+			}
+		}
+	}
+
+	found := false
+	for _, resourceType := range supported["aws"] {
+		if resourceType == "aws_instance" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("SupportedResourceTypes()[\"aws\"] does not contain aws_instance")
+	}
+}
+
+func TestProviderFromSource(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`provider["registry.terraform.io/cloudflare/cloudflare"]`, "cloudflare"},
+		{`provider["registry.terraform.io/datadog/datadog"].eu`, "datadog"},
+		{`module.dns.provider["registry.terraform.io/cloudflare/cloudflare"]`, "cloudflare"},
+		{`provider["registry.terraform.io/hashicorp/azurerm"]`, "azure"},
+		{`provider["registry.terraform.io/hashicorp/google-beta"]`, "gcp"},
+		{"provider.fastly", "fastly"},
+		{"provider.fastly.staging", "fastly"},
+		{`provider["registry.terraform.io/cloudflare/cloudflare`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := providerFromSource(tt.source); got != tt.want {
+				t.Errorf("providerFromSource(%s) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStateFile_ThirdPartyProvider(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.5.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "cloudflare_record",
+				"name": "www",
+				"provider": "provider[\"registry.terraform.io/cloudflare/cloudflare\"]",
+				"instances": [{"attributes": {"id": "rec-1"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "fastly_service_vcl",
+				"name": "cdn",
+				"provider": "provider.fastly",
+				"instances": [{"attributes": {"id": "svc-1"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"].west",
+				"instances": [{"attributes": {"id": "i-1"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	resources, err := ParseStateFile(context.Background(), stateFile)
+	if err != nil {
+		t.Fatalf("ParseStateFile() error = %v", err)
+	}
+
+	want := map[string]string{
+		"cloudflare_record.www":  "cloudflare",
+		"fastly_service_vcl.cdn": "fastly",
+		"aws_instance.web":       "aws",
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("ParseStateFile() got %d resources, want %d", len(resources), len(want))
+	}
+	for _, res := range resources {
+		if res.Provider != want[res.ID] {
+			t.Errorf("resource %s provider = %s, want %s", res.ID, res.Provider, want[res.ID])
+		}
+	}
+}
+
+func TestParseStateFileWithOptions_KeepAttributes(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.5.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{
+					"attributes": {
+						"id": "i-1",
+						"subnet_id": "subnet-1",
+						"region": "us-east-1",
+						"tags": {"Team": "web"},
+						"user_data": "#!/bin/bash\necho hello",
+						"instance_type": "t3.micro"
+					},
+					"dependencies": ["aws_subnet.main"]
+				}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_subnet",
+				"name": "main",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{
+					"attributes": {
+						"id": "subnet-1",
+						"vpc_id": "vpc-1",
+						"cidr_block": "10.0.1.0/24",
+						"map_public_ip_on_launch": false
+					}
+				}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	resources, err := ParseStateFileWithOptions(context.Background(), stateFile, StateParseOptions{KeepAttributes: DefaultKeepAttributes})
+	if err != nil {
+		t.Fatalf("ParseStateFileWithOptions() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"aws_instance.web": {"id", "region", "subnet_id", "tags"},
+		"aws_subnet.main":  {"cidr_block", "id", "vpc_id"},
+	}
+	if len(resources) != len(want) {
+		t.Fatalf("ParseStateFileWithOptions() got %d resources, want %d", len(resources), len(want))
+	}
+	for _, res := range resources {
+		var keys []string
+		for key := range res.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if fmt.Sprint(keys) != fmt.Sprint(want[res.ID]) {
+			t.Errorf("resource %s attributes = %v, want %v", res.ID, keys, want[res.ID])
+		}
+	}
+	if deps := resources[0].Dependencies; len(deps) != 1 || deps[0] != "aws_subnet.main" {
+		t.Errorf("resource %s dependencies = %v, want [aws_subnet.main]", resources[0].ID, deps)
+	}
+
+	// No allowlist keeps every attribute
+	resources, err = ParseStateFileWithOptions(context.Background(), stateFile, StateParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseStateFileWithOptions() error = %v", err)
+	}
+	if _, ok := resources[0].Attributes["user_data"]; !ok {
+		t.Error("ParseStateFileWithOptions() without KeepAttributes dropped user_data")
+	}
+
+	if _, err := ParseStateFileWithOptions(context.Background(), stateFile, StateParseOptions{KeepAttributes: []string{"["}}); err == nil {
+		t.Error("ParseStateFileWithOptions() with an invalid pattern should return error")
+	}
+}
+
 func TestParseStateFile_NonExistentFile(t *testing.T) {
 	ctx := context.Background()
 	_, err := ParseStateFile(ctx, "/nonexistent/path/terraform.tfstate")
@@ -1,17 +1,24 @@
 package parser
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 // Resource represents a parsed Terraform resource
 type Resource struct {
 	Type       string                 // e.g., "azurerm_virtual_machine", "aws_instance", "digitalocean_droplet"
 	Name       string                 // resource name
-	Provider   string                 // "azure", "aws", "gcp", "digitalocean"
+	Provider   string                 // "azure", "aws", "gcp", "digitalocean", "alicloud"
 	Attributes map[string]interface{} // resource attributes
 
 	// Computed fields for graph building
 	ID           string   // unique identifier
 	Dependencies []string // IDs of resources this depends on
+
+	// Where the resource is declared, for resources parsed from configuration files
+	SourceFile string // path relative to the configuration directory, e.g. "network/vpc.tf"
+	SourceLine int    // line of the resource block (0 = unknown)
 }
 
 // ResourceType categorizes resources for graph layout
@@ -32,79 +39,150 @@ const (
 	ResourceTypeCDN                  // CDN, CloudFront
 )
 
-// GetResourceType determines the type category of a resource
-func GetResourceType(resourceType string) ResourceType {
-	// Azure resources
-	azureTypeMap := map[string]ResourceType{
-		"azurerm_virtual_network":          ResourceTypeNetwork,
-		"azurerm_subnet":                   ResourceTypeNetwork,
-		"azurerm_network_security_group":   ResourceTypeSecurity,
-		"azurerm_network_security_rule":    ResourceTypeSecurity,
-		"azurerm_virtual_machine":          ResourceTypeCompute,
-		"azurerm_linux_virtual_machine":    ResourceTypeCompute,
-		"azurerm_windows_virtual_machine":  ResourceTypeCompute,
-		"azurerm_lb":                       ResourceTypeLoadBalancer,
-		"azurerm_lb_backend_address_pool":  ResourceTypeLoadBalancer,
-		"azurerm_lb_rule":                  ResourceTypeLoadBalancer,
-		"azurerm_storage_account":          ResourceTypeStorage,
-		"azurerm_managed_disk":             ResourceTypeStorage,
-		"azurerm_sql_server":               ResourceTypeDatabase,
-		"azurerm_sql_database":             ResourceTypeDatabase,
-		"azurerm_dns_zone":                 ResourceTypeDNS,
-		"azurerm_key_vault":                ResourceTypeSecret,
-		"azurerm_key_vault_certificate":    ResourceTypeCertificate,
-		"azurerm_key_vault_key":            ResourceTypeSecret,
-		"azurerm_key_vault_secret":         ResourceTypeSecret,
-	}
+// Azure resources
+var azureTypeMap = map[string]ResourceType{
+	"azurerm_virtual_network":         ResourceTypeNetwork,
+	"azurerm_subnet":                  ResourceTypeNetwork,
+	"azurerm_network_interface":       ResourceTypeNetwork,
+	"azurerm_private_endpoint":        ResourceTypeNetwork,
+	"azurerm_network_security_group":  ResourceTypeSecurity,
+	"azurerm_network_security_rule":   ResourceTypeSecurity,
+	"azurerm_virtual_machine":         ResourceTypeCompute,
+	"azurerm_linux_virtual_machine":   ResourceTypeCompute,
+	"azurerm_windows_virtual_machine": ResourceTypeCompute,
+	"azurerm_app_service_plan":        ResourceTypeCompute,
+	"azurerm_service_plan":            ResourceTypeCompute,
+	"azurerm_app_service":             ResourceTypeCompute,
+	"azurerm_linux_web_app":           ResourceTypeCompute,
+	"azurerm_function_app":            ResourceTypeCompute,
+	"azurerm_lb":                      ResourceTypeLoadBalancer,
+	"azurerm_lb_backend_address_pool": ResourceTypeLoadBalancer,
+	"azurerm_lb_rule":                 ResourceTypeLoadBalancer,
+	"azurerm_storage_account":         ResourceTypeStorage,
+	"azurerm_managed_disk":            ResourceTypeStorage,
+	"azurerm_sql_server":              ResourceTypeDatabase,
+	"azurerm_sql_database":            ResourceTypeDatabase,
+	"azurerm_dns_zone":                ResourceTypeDNS,
+	"azurerm_key_vault":               ResourceTypeSecret,
+	"azurerm_key_vault_certificate":   ResourceTypeCertificate,
+	"azurerm_key_vault_key":           ResourceTypeSecret,
+	"azurerm_key_vault_secret":        ResourceTypeSecret,
+}
 
-	// AWS resources
-	awsTypeMap := map[string]ResourceType{
-		"aws_vpc":                           ResourceTypeNetwork,
-		"aws_subnet":                        ResourceTypeNetwork,
-		"aws_security_group":                ResourceTypeSecurity,
-		"aws_security_group_rule":           ResourceTypeSecurity,
-		"aws_network_acl":                   ResourceTypeSecurity,
-		"aws_instance":                      ResourceTypeCompute,
-		"aws_launch_template":               ResourceTypeCompute,
-		"aws_lb":                            ResourceTypeLoadBalancer,
-		"aws_alb":                           ResourceTypeLoadBalancer,
-		"aws_lb_target_group":               ResourceTypeLoadBalancer,
-		"aws_lb_listener":                   ResourceTypeLoadBalancer,
-		"aws_s3_bucket":                     ResourceTypeStorage,
-		"aws_ebs_volume":                    ResourceTypeStorage,
-		"aws_db_instance":                   ResourceTypeDatabase,
-		"aws_dynamodb_table":                ResourceTypeDatabase,
-		"aws_route53_zone":                  ResourceTypeDNS,
-		"aws_route53_record":                ResourceTypeDNS,
-		"aws_acm_certificate":               ResourceTypeCertificate,
-		"aws_acm_certificate_validation":    ResourceTypeCertificate,
-		"aws_iam_server_certificate":        ResourceTypeCertificate,
-		"aws_secretsmanager_secret":         ResourceTypeSecret,
-		"aws_secretsmanager_secret_version": ResourceTypeSecret,
-		"aws_kms_key":                       ResourceTypeSecret,
-		"aws_kms_alias":                     ResourceTypeSecret,
-	}
+// AWS resources
+var awsTypeMap = map[string]ResourceType{
+	"aws_vpc":                           ResourceTypeNetwork,
+	"aws_subnet":                        ResourceTypeNetwork,
+	"aws_security_group":                ResourceTypeSecurity,
+	"aws_security_group_rule":           ResourceTypeSecurity,
+	"aws_network_acl":                   ResourceTypeSecurity,
+	"aws_instance":                      ResourceTypeCompute,
+	"aws_launch_template":               ResourceTypeCompute,
+	"aws_lambda_function":               ResourceTypeCompute,
+	"aws_ecs_cluster":                   ResourceTypeContainer,
+	"aws_ecs_service":                   ResourceTypeContainer,
+	"aws_ecs_task_definition":           ResourceTypeContainer,
+	"aws_eks_cluster":                   ResourceTypeContainer,
+	"aws_eks_node_group":                ResourceTypeCompute,
+	"aws_lb":                            ResourceTypeLoadBalancer,
+	"aws_alb":                           ResourceTypeLoadBalancer,
+	"aws_lb_target_group":               ResourceTypeLoadBalancer,
+	"aws_lb_listener":                   ResourceTypeLoadBalancer,
+	"aws_s3_bucket":                     ResourceTypeStorage,
+	"aws_ebs_volume":                    ResourceTypeStorage,
+	"aws_db_instance":                   ResourceTypeDatabase,
+	"aws_db_subnet_group":               ResourceTypeNetwork,
+	"aws_dynamodb_table":                ResourceTypeDatabase,
+	"aws_route53_zone":                  ResourceTypeDNS,
+	"aws_route53_record":                ResourceTypeDNS,
+	"aws_cloudfront_distribution":       ResourceTypeCDN,
+	"aws_acm_certificate":               ResourceTypeCertificate,
+	"aws_acm_certificate_validation":    ResourceTypeCertificate,
+	"aws_iam_server_certificate":        ResourceTypeCertificate,
+	"aws_secretsmanager_secret":         ResourceTypeSecret,
+	"aws_secretsmanager_secret_version": ResourceTypeSecret,
+	"aws_kms_key":                       ResourceTypeSecret,
+	"aws_kms_alias":                     ResourceTypeSecret,
+
+	// Cross-VPC connectivity
+	"aws_vpc_peering_connection":             ResourceTypeNetwork,
+	"aws_ec2_transit_gateway":                ResourceTypeNetwork,
+	"aws_ec2_transit_gateway_vpc_attachment": ResourceTypeNetwork,
 
-	// DigitalOcean resources
-	digitaloceanTypeMap := map[string]ResourceType{
-		"digitalocean_vpc":                  ResourceTypeNetwork,
-		"digitalocean_firewall":             ResourceTypeSecurity,
-		"digitalocean_droplet":              ResourceTypeCompute,
-		"digitalocean_kubernetes_cluster":   ResourceTypeCompute,
-		"digitalocean_app":                  ResourceTypeCompute,
-		"digitalocean_loadbalancer":         ResourceTypeLoadBalancer,
-		"digitalocean_spaces_bucket":        ResourceTypeStorage,
-		"digitalocean_volume":               ResourceTypeStorage,
-		"digitalocean_database_cluster":     ResourceTypeDatabase,
-		"digitalocean_database_db":          ResourceTypeDatabase,
-		"digitalocean_database_replica":     ResourceTypeDatabase,
-		"digitalocean_domain":               ResourceTypeDNS,
-		"digitalocean_record":               ResourceTypeDNS,
-		"digitalocean_certificate":          ResourceTypeCertificate,
-		"digitalocean_cdn":                  ResourceTypeCDN,
-		"digitalocean_container_registry":   ResourceTypeContainer,
+	// Elastic network interfaces
+	"aws_network_interface": ResourceTypeNetwork,
+
+	// IAM roles assumed by compute
+	"aws_iam_role":             ResourceTypeSecurity,
+	"aws_iam_instance_profile": ResourceTypeSecurity,
+}
+
+// DigitalOcean resources
+var digitaloceanTypeMap = map[string]ResourceType{
+	"digitalocean_vpc":                ResourceTypeNetwork,
+	"digitalocean_firewall":           ResourceTypeSecurity,
+	"digitalocean_droplet":            ResourceTypeCompute,
+	"digitalocean_kubernetes_cluster": ResourceTypeCompute,
+	"digitalocean_app":                ResourceTypeCompute,
+	"digitalocean_loadbalancer":       ResourceTypeLoadBalancer,
+	"digitalocean_spaces_bucket":      ResourceTypeStorage,
+	"digitalocean_volume":             ResourceTypeStorage,
+	"digitalocean_database_cluster":   ResourceTypeDatabase,
+	"digitalocean_database_db":        ResourceTypeDatabase,
+	"digitalocean_database_replica":   ResourceTypeDatabase,
+	"digitalocean_domain":             ResourceTypeDNS,
+	"digitalocean_record":             ResourceTypeDNS,
+	"digitalocean_certificate":        ResourceTypeCertificate,
+	"digitalocean_cdn":                ResourceTypeCDN,
+	"digitalocean_container_registry": ResourceTypeContainer,
+}
+
+// Google Cloud resources
+var gcpTypeMap = map[string]ResourceType{
+	"google_compute_instance":               ResourceTypeCompute,
+	"google_compute_instance_template":      ResourceTypeCompute,
+	"google_compute_instance_group":         ResourceTypeCompute,
+	"google_compute_instance_group_manager": ResourceTypeCompute,
+	"google_compute_backend_service":        ResourceTypeLoadBalancer,
+}
+
+// Alibaba Cloud resources
+var alicloudTypeMap = map[string]ResourceType{
+	"alicloud_vpc":            ResourceTypeNetwork,
+	"alicloud_vswitch":        ResourceTypeNetwork,
+	"alicloud_security_group": ResourceTypeSecurity,
+	"alicloud_instance":       ResourceTypeCompute,
+	"alicloud_slb":            ResourceTypeLoadBalancer,
+	"alicloud_oss_bucket":     ResourceTypeStorage,
+	"alicloud_db_instance":    ResourceTypeDatabase,
+}
+
+// resourceTypeMaps holds the category maps by provider name
+var resourceTypeMaps = map[string]map[string]ResourceType{
+	"azure":        azureTypeMap,
+	"aws":          awsTypeMap,
+	"digitalocean": digitaloceanTypeMap,
+	"gcp":          gcpTypeMap,
+	"alicloud":     alicloudTypeMap,
+}
+
+// SupportedResourceTypes returns the resource types with a known category, keyed by
+// provider name, with each list sorted
+func SupportedResourceTypes() map[string][]string {
+	supported := make(map[string][]string, len(resourceTypeMaps))
+	for provider, typeMap := range resourceTypeMaps {
+		types := make([]string, 0, len(typeMap))
+		for resourceType := range typeMap {
+			types = append(types, resourceType)
+		}
+		sort.Strings(types)
+		supported[provider] = types
 	}
+	return supported
+}
 
+// GetResourceType determines the type category of a resource
+func GetResourceType(resourceType string) ResourceType {
 	if rt, ok := azureTypeMap[resourceType]; ok {
 		return rt
 	}
@@ -114,6 +192,12 @@ func GetResourceType(resourceType string) ResourceType {
 	if rt, ok := digitaloceanTypeMap[resourceType]; ok {
 		return rt
 	}
+	if rt, ok := gcpTypeMap[resourceType]; ok {
+		return rt
+	}
+	if rt, ok := alicloudTypeMap[resourceType]; ok {
+		return rt
+	}
 
 	return ResourceTypeUnknown
 }
@@ -123,31 +207,31 @@ func GetResourceType(resourceType string) ResourceType {
 func IsCloudInfraResource(resourceType string) bool {
 	// List of non-cloud utility resource types to exclude
 	excludedTypes := map[string]bool{
-		"tls_private_key":                true,
-		"tls_cert_request":               true,
-		"tls_locally_signed_cert":        true,
-		"tls_self_signed_cert":           true,
-		"local_file":                     true,
-		"local_sensitive_file":           true,
-		"null_resource":                  true,
-		"random_id":                      true,
-		"random_integer":                 true,
-		"random_password":                true,
-		"random_pet":                     true,
-		"random_shuffle":                 true,
-		"random_string":                  true,
-		"random_uuid":                    true,
-		"time_sleep":                     true,
-		"time_static":                    true,
-		"time_rotating":                  true,
-		"time_offset":                    true,
-		"terraform_data":                 true,
-		"external":                       true,
-		"http":                           true,
-		"template_file":                  true,
-		"template_dir":                   true,
-		"template_cloudinit_config":      true,
-		"archive_file":                   true,
+		"tls_private_key":           true,
+		"tls_cert_request":          true,
+		"tls_locally_signed_cert":   true,
+		"tls_self_signed_cert":      true,
+		"local_file":                true,
+		"local_sensitive_file":      true,
+		"null_resource":             true,
+		"random_id":                 true,
+		"random_integer":            true,
+		"random_password":           true,
+		"random_pet":                true,
+		"random_shuffle":            true,
+		"random_string":             true,
+		"random_uuid":               true,
+		"time_sleep":                true,
+		"time_static":               true,
+		"time_rotating":             true,
+		"time_offset":               true,
+		"terraform_data":            true,
+		"external":                  true,
+		"http":                      true,
+		"template_file":             true,
+		"template_dir":              true,
+		"template_cloudinit_config": true,
+		"archive_file":              true,
 	}
 
 	return !excludedTypes[resourceType]
@@ -163,16 +247,33 @@ func ShouldIncludeInDiagram(resource Resource) bool {
 	// Exclude data sources (they don't create infrastructure)
 	// Note: This is handled during parsing, but double-check
 
-	// Exclude resources with "association" or "attachment" in the name
-	// These are typically helper resources that create relationships
-	// but don't represent actual infrastructure components
-	resourceTypeLower := strings.ToLower(resource.Type)
-	if strings.Contains(resourceTypeLower, "_association") &&
-	   !strings.Contains(resourceTypeLower, "load_balancer") {
-		// Exception: load balancer associations should be kept
-		// They represent actual infrastructure relationships
+	// Exclude association resources; they are drawn as edges instead of nodes
+	if IsAssociationResource(resource.Type) {
 		return false
 	}
 
 	return true
 }
+
+// attachmentTypes are helpers attaching a volume, disk or network interface to a machine,
+// drawn as an edge between the two like association resources
+var attachmentTypes = map[string]bool{
+	"aws_volume_attachment":                        true,
+	"aws_network_interface_attachment":             true,
+	"azurerm_virtual_machine_data_disk_attachment": true,
+	"digitalocean_volume_attachment":               true,
+}
+
+// IsAssociationResource reports whether a resource type is an association helper
+// (e.g. aws_route_table_association) or attachment (e.g. aws_volume_attachment)
+// that links resources without representing infrastructure itself. Load balancer
+// associations are not considered helpers, since they represent actual infrastructure
+// relationships.
+func IsAssociationResource(resourceType string) bool {
+	resourceTypeLower := strings.ToLower(resourceType)
+	if attachmentTypes[resourceTypeLower] {
+		return true
+	}
+	return strings.Contains(resourceTypeLower, "_association") &&
+		!strings.Contains(resourceTypeLower, "load_balancer")
+}
@@ -18,7 +18,8 @@ var _ datasource.DataSource = &DiagramDataSource{}
 
 // DiagramDataSource defines the data source implementation.
 type DiagramDataSource struct {
-	generator *DiagramGenerator
+	generator      *DiagramGenerator
+	providerConfig *CartographyProviderModel
 }
 
 func NewDiagramDataSource() datasource.DataSource {
@@ -55,7 +56,7 @@ func (d *DiagramDataSource) Schema(ctx context.Context, req datasource.SchemaReq
 				MarkdownDescription: "Data source identifier",
 			},
 			"state_path": schema.StringAttribute{
-				MarkdownDescription: "Path to terraform.tfstate file. If not provided, will attempt to read from config_path.",
+				MarkdownDescription: "Path to terraform.tfstate file or an HTTP(S) URL serving it. If not provided, will attempt to read from config_path.",
 				Optional:            true,
 				Validators: []validator.String{
 					stringvalidator.LengthAtLeast(1),
@@ -71,14 +72,15 @@ func (d *DiagramDataSource) Schema(ctx context.Context, req datasource.SchemaReq
 				},
 			},
 			"output_path": schema.StringAttribute{
-				MarkdownDescription: "Path where the diagram will be saved.",
+				MarkdownDescription: "Path where the diagram will be saved. Relative paths are resolved against the provider `output_dir` when set. May be a Go template using `.Dir` (input directory name), `.Provider`, `.Format` and `.Workspace`, e.g. `diagrams/{{.Dir}}.{{.Format}}`; missing directories of the expanded path are created. Writing to stdout (`-`) is not supported.",
 				Required:            true,
 				Validators: []validator.String{
 					stringvalidator.LengthAtLeast(1),
+					stringvalidator.NoneOf(StdoutOutputPath),
 				},
 			},
 			"format": schema.StringAttribute{
-				MarkdownDescription: "Output format: 'svg', 'png', 'jpg', or 'jpeg'. Default is 'svg'. Note: PNG and JPEG export requires resvg, inkscape, or imagemagick to be installed for high quality output.",
+				MarkdownDescription: "Output format: 'svg', 'png', 'jpg', or 'jpeg'. Default is 'svg'. PNG and JPEG are drawn by the built-in raster renderer, which draws plain boxes without icons.",
 				Optional:            true,
 				Validators: []validator.String{
 					stringvalidator.OneOf("svg", "png", "jpg", "jpeg"),
@@ -112,6 +114,29 @@ func (d *DiagramDataSource) Schema(ctx context.Context, req datasource.SchemaReq
 }
 
 func (d *DiagramDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Provider data is nil during validation, before the provider is configured
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerConfig, ok := req.ProviderData.(*CartographyProviderModel)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *CartographyProviderModel, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.providerConfig = providerConfig
+}
+
+// outputDir returns the provider-level default output directory, if configured
+func (d *DiagramDataSource) outputDir() string {
+	if d.providerConfig == nil || d.providerConfig.OutputDir.IsNull() {
+		return ""
+	}
+	return d.providerConfig.OutputDir.ValueString()
 }
 
 func (d *DiagramDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
@@ -151,6 +176,7 @@ func (d *DiagramDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		StatePath:     data.StatePath.ValueString(),
 		ConfigPath:    data.ConfigPath.ValueString(),
 		OutputPath:    data.OutputPath.ValueString(),
+		OutputDir:     d.outputDir(),
 		Format:        data.Format.ValueString(),
 		Direction:     data.Direction.ValueString(),
 		IncludeLabels: data.IncludeLabels.ValueBool(),
@@ -158,9 +184,12 @@ func (d *DiagramDataSource) Read(ctx context.Context, req datasource.ReadRequest
 		UseIcons:      useIcons,
 	})
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to generate diagram", err.Error())
+		resp.Diagnostics.AddError(generateErrorSummary(err), err.Error())
 		return
 	}
+	for _, warning := range result.Warnings {
+		resp.Diagnostics.AddWarning("Skipped configuration file", warning)
+	}
 
 	// Set resource count from result
 	data.ResourceCount = types.Int64Value(result.ResourceCount)
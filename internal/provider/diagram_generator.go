@@ -5,7 +5,21 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
 	"github.com/ankek/terraform-provider-cartography/internal/parser"
@@ -22,18 +36,99 @@ type DiagramGenerator struct{}
 type DiagramConfig struct {
 	StatePath     string
 	ConfigPath    string
-	OutputPath    string
+	OutputPath    string // Optional; when empty the diagram is only returned in GenerateResult.Data, "-" writes it to stdout. May be a template, see OutputPathData
+	OutputDir     string // Base directory for relative OutputPath values (provider default)
 	Format        string
 	Direction     string
 	IncludeLabels bool
 	Title         string
 	UseIcons      bool
+	JPEGQuality   int // Quality (1-100) of JPEG output (0 = default)
+
+	// SkipImplicitConnections limits edges to explicit Terraform dependencies
+	SkipImplicitConnections bool
+	// IncludeAssociations draws association resources as nodes instead of edges
+	IncludeAssociations bool
+
+	// Focus limits the diagram to the resource with this ID and the resources connected
+	// to it in either direction, up to FocusDepth edges away (0 = unlimited)
+	Focus      string
+	FocusDepth int
+
+	// GraphTransforms post-process the graph after it is built and focused, before
+	// layout; they run in order, each receiving the graph returned by the previous one
+	GraphTransforms []GraphTransform
+
+	// BackendConfig overrides settings of the backend found in ConfigPath (e.g. the S3 key).
+	// When set, resources are loaded from the backend state instead of the configuration files.
+	BackendConfig  map[string]string
+	ProviderConfig *CartographyProviderModel // Credentials for remote backends
+
+	// Workspaces loads and merges the states of these workspaces of the remote (Terraform
+	// Cloud/Enterprise) backend found in ConfigPath, in place of its configured workspace.
+	// Resource IDs are prefixed with the workspace name, e.g. "prod:aws_vpc.main".
+	Workspaces []string
+
+	// EmitManifest writes a DiagramManifest to <OutputPath>.manifest.json next to the
+	// diagram (ignored when the diagram is not written to a file)
+	EmitManifest bool
+
+	// Timeout bounds the whole generation, including remote state fetches (0 = no limit)
+	Timeout time.Duration
+
+	// Logger receives structured debug events from each generation phase, such as the
+	// number of resources parsed and filtered (nil = discard)
+	Logger *slog.Logger
+
+	// templateDir overrides OutputPathData.Dir (set by GenerateAll)
+	templateDir string
+
+	// configDirOnly parses only the files directly in ConfigPath (set by GenerateAll)
+	configDirOnly bool
 }
 
+// logger returns cfg.Logger, or a logger that discards all events when it is nil
+func (cfg DiagramConfig) logger() *slog.Logger {
+	if cfg.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return cfg.Logger
+}
+
+// GraphTransform returns a modified copy of the graph, or the graph itself after
+// modifying it in place, e.g. to add nodes or relabel relationships. It must not return nil.
+type GraphTransform func(*graph.Graph) *graph.Graph
+
+// StdoutOutputPath is the OutputPath that writes the rendered diagram to os.Stdout
+// instead of a file, e.g. for piping it to another command
+const StdoutOutputPath = "-"
+
+// ErrGenerateTimeout is returned, wrapping context.DeadlineExceeded, when generation
+// exceeds DiagramConfig.Timeout. Cancellation of the caller's context is not reported as it.
+var ErrGenerateTimeout = errors.New("diagram generation timed out")
+
 // GenerateResult contains the results of diagram generation
 type GenerateResult struct {
 	ResourceCount int64
 	OutputPath    string
+	Data          []byte           // Rendered diagram in the requested format
+	Layout        *renderer.Layout // Node positions and edge paths the diagram was drawn from
+	Warnings      []string         // Non-fatal problems, e.g. configuration files that were skipped
+	InputHash     string           // Hash of the effective inputs, see DiagramGenerator.InputHash
+}
+
+// ManifestSuffix is appended to the output path to name the manifest written with
+// DiagramConfig.EmitManifest
+const ManifestSuffix = ".manifest.json"
+
+// DiagramManifest is machine-readable metadata about a generated diagram
+type DiagramManifest struct {
+	InputPath     string   `json:"input_path"` // State file, state URL, or configuration directory
+	Format        string   `json:"format"`
+	ResourceCount int64    `json:"resource_count"`
+	NodeIDs       []string `json:"node_ids"` // IDs of the drawn nodes, sorted
+	OutputPath    string   `json:"output_path"`
+	SHA256        string   `json:"sha256"` // Hex-encoded SHA-256 of the diagram content
 }
 
 // Generate creates a diagram from Terraform state or config files.
@@ -42,76 +137,574 @@ type GenerateResult struct {
 // It performs the following steps:
 //  1. Validates input and output paths
 //  2. Parses Terraform state or config files
-//  3. Builds a resource dependency graph
-//  4. Renders the diagram to the specified format
+//  3. Builds a resource dependency graph and applies cfg.GraphTransforms
+//  4. Renders the diagram to the specified format, writing it to OutputPath if set
 //
-// Returns GenerateResult with resource count and output path, or an error if any step fails.
+// Returns GenerateResult with resource count, output path and rendered data, or an error if any step fails.
+// When cfg.Timeout is set and exceeded, the error wraps ErrGenerateTimeout.
 func (g *DiagramGenerator) Generate(ctx context.Context, cfg DiagramConfig) (*GenerateResult, error) {
-	// Validate output path
-	if err := validation.ValidateOutputPath(cfg.OutputPath); err != nil {
-		return nil, fmt.Errorf("invalid output path: %w", err)
+	if cfg.Timeout <= 0 {
+		return g.generate(ctx, cfg)
 	}
 
-	// Validate input paths
-	if cfg.StatePath != "" {
-		if err := validation.ValidateInputPath(cfg.StatePath, false); err != nil {
-			return nil, fmt.Errorf("invalid state path: %w", err)
+	ctx, cancel := context.WithTimeoutCause(ctx, cfg.Timeout, ErrGenerateTimeout)
+	defer cancel()
+
+	result, err := g.generate(ctx, cfg)
+	if err != nil && errors.Is(context.Cause(ctx), ErrGenerateTimeout) {
+		return nil, fmt.Errorf("%w after %s: %w", ErrGenerateTimeout, cfg.Timeout, err)
+	}
+	return result, err
+}
+
+// batchDiagramName is the base name of each diagram written by GenerateAll
+const batchDiagramName = "diagram"
+
+// GenerateAll generates one diagram per directory under rootDir that contains .tf or
+// .tf.json files, using cfg for every diagram with ConfigPath set to that directory.
+// Each diagram holds the resources of its directory only, not of its subdirectories.
+// Hidden directories such as .terraform are skipped.
+//
+// cfg.OutputPath is the root of a mirrored output tree: the diagram for rootDir/a/b is
+// written to <OutputPath>/a/b/diagram.<format>, creating directories as needed. When
+// OutputPath is empty the diagrams are only returned in memory. A template OutputPath is
+// expanded for each directory instead, with .Dir set to its path relative to rootDir
+// ("a/b"), or the base name of rootDir for rootDir itself.
+//
+// Failures of individual directories don't stop the batch; they are returned joined in
+// the error alongside the results of the directories that succeeded. Cancelling ctx
+// stops the batch before the next directory.
+func (g *DiagramGenerator) GenerateAll(ctx context.Context, rootDir string, cfg DiagramConfig) ([]*GenerateResult, error) {
+	if cfg.OutputPath == StdoutOutputPath {
+		return nil, fmt.Errorf("cannot write multiple diagrams to stdout")
+	}
+
+	configDirs, err := findConfigDirs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputRoot := ResolveOutputPath(cfg.OutputDir, cfg.OutputPath)
+	format := cfg.Format
+	if format == "" {
+		format = "svg"
+	}
+
+	var results []*GenerateResult
+	var errs []error
+	for _, dir := range configDirs {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
 		}
-	} else if cfg.ConfigPath != "" {
-		if err := validation.ValidateInputPath(cfg.ConfigPath, true); err != nil {
-			return nil, fmt.Errorf("invalid config path: %w", err)
+
+		dirCfg := cfg
+		dirCfg.StatePath = ""
+		dirCfg.ConfigPath = dir
+		dirCfg.OutputDir = ""
+		dirCfg.OutputPath = ""
+		dirCfg.configDirOnly = true
+		if IsOutputPathTemplate(outputRoot) {
+			rel, err := filepath.Rel(rootDir, dir)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", dir, err))
+				continue
+			}
+			if rel == "." {
+				rel = inputDirName(rootDir)
+			}
+			dirCfg.OutputPath = outputRoot
+			dirCfg.templateDir = filepath.ToSlash(rel)
+		} else if outputRoot != "" {
+			rel, err := filepath.Rel(rootDir, dir)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", dir, err))
+				continue
+			}
+			dirCfg.OutputPath = filepath.Join(outputRoot, rel, batchDiagramName+"."+format)
 		}
+
+		result, err := g.Generate(ctx, dirCfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", dir, err))
+			continue
+		}
+		results = append(results, result)
 	}
 
-	// Parse resources from state or config
-	resources, err := g.parseResources(ctx, cfg)
+	return results, errors.Join(errs...)
+}
+
+// findConfigDirs returns rootDir and its subdirectories that contain configuration
+// files, in lexical order, skipping hidden directories
+func findConfigDirs(rootDir string) ([]string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != rootDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if parser.IsConfigFile(path) {
+			if dir := filepath.Dir(path); !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s for configuration directories: %w", rootDir, err)
+	}
+	return dirs, nil
+}
+
+// generate performs the steps of Generate under the caller's (possibly bounded) context
+func (g *DiagramGenerator) generate(ctx context.Context, cfg DiagramConfig) (*GenerateResult, error) {
+	// Resolve relative output paths against the provider-level output directory
+	cfg.OutputPath = ResolveOutputPath(cfg.OutputDir, cfg.OutputPath)
+
+	// Reject unsafe output paths up front (an empty path keeps the diagram in memory
+	// only); templates are checked once expanded. Directories are only created once
+	// there is a diagram to write.
+	templated := IsOutputPathTemplate(cfg.OutputPath)
+	if cfg.OutputPath != "" && cfg.OutputPath != StdoutOutputPath && !templated {
+		if err := validation.CheckOutputPath(cfg.OutputPath); err != nil {
+			return nil, fmt.Errorf("invalid output path: %w", err)
+		}
+	}
+
+	resources, warnings, err := g.collectResources(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(resources) == 0 {
-		return nil, fmt.Errorf("no resources found to diagram")
+	if templated {
+		cfg.OutputPath, err = expandOutputPath(cfg.OutputPath, outputPathData(cfg, resources))
+		if err != nil {
+			return nil, err
+		}
+		if err := validation.CheckOutputPath(cfg.OutputPath); err != nil {
+			return nil, fmt.Errorf("invalid output path: %w", err)
+		}
+	}
+	hash, err := inputHash(resources, cfg)
+	if err != nil {
+		return nil, err
 	}
 
+	logger := cfg.logger()
+
 	// Build resource dependency graph
-	resourceGraph := graph.BuildGraph(ctx, resources)
+	graphOpts := graph.DefaultGraphOptions()
+	graphOpts.DetectImplicit = !cfg.SkipImplicitConnections
+	graphOpts.IncludeAssociations = cfg.IncludeAssociations
+	resourceGraph := graph.BuildGraphWithOptions(ctx, resources, graphOpts)
+	logger.DebugContext(ctx, "graph built",
+		"nodes", len(resourceGraph.Nodes),
+		"edges", len(resourceGraph.Edges),
+		"filtered", len(resources)-len(resourceGraph.Nodes),
+	)
+
+	// Restrict the graph to the neighborhood of the focus resource
+	if cfg.Focus != "" {
+		if resourceGraph.Nodes[cfg.Focus] == nil {
+			return nil, fmt.Errorf("focus resource %q not found in diagram", cfg.Focus)
+		}
+
+		maxDepth := cfg.FocusDepth
+		if maxDepth <= 0 {
+			maxDepth = -1
+		}
+		resourceGraph = resourceGraph.ReachableWithOptions(cfg.Focus, graph.TraversalOptions{
+			MaxDepth:      maxDepth,
+			Bidirectional: true,
+		})
+		logger.DebugContext(ctx, "focus applied",
+			"focus", cfg.Focus,
+			"nodes", len(resourceGraph.Nodes),
+			"edges", len(resourceGraph.Edges),
+		)
+	}
 
-	// Render diagram to file
+	// Apply user-supplied transforms in order
+	for i, transform := range cfg.GraphTransforms {
+		resourceGraph = transform(resourceGraph)
+		if resourceGraph == nil {
+			return nil, fmt.Errorf("graph transform %d returned no graph", i)
+		}
+	}
+	if len(cfg.GraphTransforms) > 0 {
+		logger.DebugContext(ctx, "graph transforms applied",
+			"transforms", len(cfg.GraphTransforms),
+			"nodes", len(resourceGraph.Nodes),
+			"edges", len(resourceGraph.Edges),
+		)
+	}
+
+	// Render diagram
 	renderOpts := renderer.RenderOptions{
-		Format:        cfg.Format,
-		Direction:     cfg.Direction,
-		IncludeLabels: cfg.IncludeLabels,
-		Title:         cfg.Title,
-		UseIcons:      cfg.UseIcons,
+		Format:         cfg.Format,
+		Direction:      cfg.Direction,
+		IncludeLabels:  cfg.IncludeLabels,
+		Title:          cfg.Title,
+		UseIcons:       cfg.UseIcons,
+		MaxLabelLength: renderer.DefaultMaxLabelLength,
+		MaxTypeLength:  renderer.DefaultMaxTypeLength,
+		JPEGQuality:    cfg.JPEGQuality,
+		Logger:         logger,
 	}
 
-	if err := renderer.RenderDiagram(ctx, resourceGraph, cfg.OutputPath, renderOpts); err != nil {
+	data, layout, err := renderer.RenderDiagramBytesWithLayout(ctx, resourceGraph, renderOpts)
+	if err != nil {
 		return nil, fmt.Errorf("failed to render diagram: %w", err)
 	}
 
-	return &GenerateResult{
+	// Create the output directory, including missing ancestors, right before writing
+	if cfg.OutputPath != "" && cfg.OutputPath != StdoutOutputPath {
+		if err := validation.EnsureOutputDir(cfg.OutputPath); err != nil {
+			return nil, fmt.Errorf("invalid output path: %w", err)
+		}
+		if err := validation.ValidateOutputPath(cfg.OutputPath); err != nil {
+			return nil, fmt.Errorf("invalid output path: %w", err)
+		}
+	}
+	if err := writeOutput(cfg.OutputPath, data); err != nil {
+		return nil, fmt.Errorf("failed to write diagram: %w", err)
+	}
+
+	result := &GenerateResult{
 		ResourceCount: int64(len(resources)),
 		OutputPath:    cfg.OutputPath,
-	}, nil
+		Data:          data,
+		Layout:        layout,
+		Warnings:      warnings,
+		InputHash:     hash,
+	}
+
+	if cfg.EmitManifest && cfg.OutputPath != "" && cfg.OutputPath != StdoutOutputPath {
+		if err := writeManifest(cfg, result); err != nil {
+			return nil, fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+
+	return result, nil
 }
 
-// parseResources parses resources from either state file or config directory
-func (g *DiagramGenerator) parseResources(ctx context.Context, cfg DiagramConfig) ([]parser.Resource, error) {
+// collectResources validates the input paths of cfg and returns the resources to
+// diagram: those parsed from the state or configuration, less the ones matching the
+// .cartographyignore file next to the input
+func (g *DiagramGenerator) collectResources(ctx context.Context, cfg DiagramConfig) ([]parser.Resource, []string, error) {
+	// Validate input paths
+	if cfg.StatePath != "" && !parser.IsStateURL(cfg.StatePath) {
+		if err := validation.ValidateInputPath(cfg.StatePath, false); err != nil {
+			return nil, nil, fmt.Errorf("invalid state path: %w", err)
+		}
+	} else if cfg.ConfigPath != "" {
+		if err := validation.ValidateInputPath(cfg.ConfigPath, true); err != nil {
+			return nil, nil, fmt.Errorf("invalid config path: %w", err)
+		}
+	}
+
+	// Parse resources from state or config
+	resources, warnings, err := g.parseResources(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger := cfg.logger()
+	logger.DebugContext(ctx, "resources parsed", "resources", len(resources), "warnings", len(warnings))
+
+	// Drop resources matching the .cartographyignore file next to the input
+	if ignoreDir := ignoreFileDir(cfg); ignoreDir != "" {
+		patterns, err := parser.LoadIgnorePatterns(ignoreDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		parsed := len(resources)
+		resources = parser.FilterIgnoredResources(resources, patterns)
+		if len(patterns) > 0 {
+			logger.DebugContext(ctx, "ignore file applied", "patterns", len(patterns), "ignored", parsed-len(resources))
+		}
+	}
+
+	if len(resources) == 0 {
+		return nil, nil, fmt.Errorf("no resources found to diagram")
+	}
+
+	return resources, warnings, nil
+}
+
+// InputHash returns the hex-encoded SHA-256 of the effective inputs of the diagram cfg
+// describes, without rendering it: the resources parsed from the state or configuration
+// (after the ignore file is applied) and the options that change the rendered output.
+// OutputPath, GraphTransforms, EmitManifest and Timeout are not part of it. Generate
+// reports the same value in GenerateResult.InputHash.
+func (g *DiagramGenerator) InputHash(ctx context.Context, cfg DiagramConfig) (string, error) {
+	resources, _, err := g.collectResources(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	return inputHash(resources, cfg)
+}
+
+// hashedInputs are the inputs covered by DiagramGenerator.InputHash
+type hashedInputs struct {
+	Resources               []parser.Resource `json:"resources"`
+	Format                  string            `json:"format"`
+	Direction               string            `json:"direction"`
+	IncludeLabels           bool              `json:"include_labels"`
+	Title                   string            `json:"title"`
+	UseIcons                bool              `json:"use_icons"`
+	JPEGQuality             int               `json:"jpeg_quality"`
+	SkipImplicitConnections bool              `json:"skip_implicit_connections"`
+	IncludeAssociations     bool              `json:"include_associations"`
+	Focus                   string            `json:"focus"`
+	FocusDepth              int               `json:"focus_depth"`
+}
+
+// inputHash hashes the resources, in ID order, together with the rendering options of cfg
+func inputHash(resources []parser.Resource, cfg DiagramConfig) (string, error) {
+	sorted := slices.Clone(resources)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	data, err := json.Marshal(hashedInputs{
+		Resources:               sorted,
+		Format:                  cfg.Format,
+		Direction:               cfg.Direction,
+		IncludeLabels:           cfg.IncludeLabels,
+		Title:                   cfg.Title,
+		UseIcons:                cfg.UseIcons,
+		JPEGQuality:             cfg.JPEGQuality,
+		SkipImplicitConnections: cfg.SkipImplicitConnections,
+		IncludeAssociations:     cfg.IncludeAssociations,
+		Focus:                   cfg.Focus,
+		FocusDepth:              cfg.FocusDepth,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash diagram inputs: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeManifest writes the DiagramManifest of a generated diagram to
+// <OutputPath>.manifest.json
+func writeManifest(cfg DiagramConfig, result *GenerateResult) error {
+	inputPath := cfg.StatePath
+	if inputPath == "" {
+		inputPath = cfg.ConfigPath
+	}
+	format := cfg.Format
+	if format == "" {
+		format = "svg"
+	}
+
+	nodeIDs := make([]string, 0, len(result.Layout.Nodes))
+	for id := range result.Layout.Nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	sum := sha256.Sum256(result.Data)
+	manifest := DiagramManifest{
+		InputPath:     inputPath,
+		Format:        format,
+		ResourceCount: result.ResourceCount,
+		NodeIDs:       nodeIDs,
+		OutputPath:    result.OutputPath,
+		SHA256:        hex.EncodeToString(sum[:]),
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(result.OutputPath+ManifestSuffix, append(data, '\n'), 0644)
+}
+
+// writeOutput writes the rendered diagram to outputPath, or to os.Stdout when it is
+// StdoutOutputPath. Binary formats such as PNG are written as raw bytes. An empty path
+// writes nothing.
+func writeOutput(outputPath string, data []byte) error {
+	switch outputPath {
+	case "":
+		return nil
+	case StdoutOutputPath:
+		_, err := os.Stdout.Write(data)
+		return err
+	default:
+		return os.WriteFile(outputPath, data, 0644)
+	}
+}
+
+// ignoreFileDir returns the directory searched for the .cartographyignore file: the
+// configuration directory, or the directory of a local state file (none for state URLs)
+func ignoreFileDir(cfg DiagramConfig) string {
+	switch {
+	case cfg.StatePath != "" && parser.IsStateURL(cfg.StatePath):
+		return ""
+	case cfg.StatePath != "":
+		return filepath.Dir(cfg.StatePath)
+	default:
+		return cfg.ConfigPath
+	}
+}
+
+// ResolveOutputPath joins a relative output path with the provider default output directory.
+// Absolute paths, StdoutOutputPath, and any path when no output directory is configured,
+// are returned unchanged.
+func ResolveOutputPath(outputDir, outputPath string) string {
+	if outputDir == "" || outputPath == "" || outputPath == StdoutOutputPath || filepath.IsAbs(outputPath) {
+		return outputPath
+	}
+	return filepath.Join(outputDir, outputPath)
+}
+
+// OutputPathData is the data an OutputPath containing text/template actions is executed
+// against once the resources are read, e.g. "diagrams/{{.Dir}}-{{.Provider}}.{{.Format}}".
+// Missing parent directories of the expanded path are created.
+type OutputPathData struct {
+	Dir       string // Base name of the configuration directory, or of the local state file's directory
+	Provider  string // Provider of most resources, e.g. "aws" (the first alphabetically on a tie)
+	Format    string // Output format, e.g. "svg"
+	Workspace string // Workspaces whose states are merged, joined with "+" (empty without Workspaces)
+}
+
+// IsOutputPathTemplate reports whether an output path contains text/template actions
+func IsOutputPathTemplate(outputPath string) bool {
+	return strings.Contains(outputPath, "{{")
+}
+
+// ParseOutputPathTemplate parses an output path template and checks that it only refers
+// to fields of OutputPathData
+func ParseOutputPathTemplate(outputPath string) (*template.Template, error) {
+	tmpl, err := template.New("output_path").Option("missingkey=error").Parse(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output path template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, OutputPathData{}); err != nil {
+		return nil, fmt.Errorf("invalid output path template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// expandOutputPath executes an output path template against data
+func expandOutputPath(outputPath string, data OutputPathData) (string, error) {
+	tmpl, err := ParseOutputPathTemplate(outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	var expanded strings.Builder
+	if err := tmpl.Execute(&expanded, data); err != nil {
+		return "", fmt.Errorf("failed to expand output path template: %w", err)
+	}
+	if expanded.Len() == 0 {
+		return "", fmt.Errorf("output path template %q expanded to an empty path", outputPath)
+	}
+	return expanded.String(), nil
+}
+
+// outputPathData describes the diagram of cfg, drawn from resources, for output path templates
+func outputPathData(cfg DiagramConfig, resources []parser.Resource) OutputPathData {
+	data := OutputPathData{
+		Dir:       cfg.templateDir,
+		Format:    cfg.Format,
+		Workspace: strings.Join(cfg.Workspaces, "+"),
+	}
+	if data.Format == "" {
+		data.Format = "svg"
+	}
+	if data.Dir == "" {
+		if cfg.StatePath != "" && !parser.IsStateURL(cfg.StatePath) {
+			data.Dir = inputDirName(filepath.Dir(cfg.StatePath))
+		} else if cfg.ConfigPath != "" {
+			data.Dir = inputDirName(cfg.ConfigPath)
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, res := range resources {
+		counts[res.Provider]++
+	}
+	for provider, count := range counts {
+		if count > counts[data.Provider] || (count == counts[data.Provider] && provider < data.Provider) {
+			data.Provider = provider
+		}
+	}
+
+	return data
+}
+
+// inputDirName returns the base name of dir, resolving "." and other relative paths
+// against the working directory
+func inputDirName(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Base(dir)
+}
+
+// parseResources parses resources from either state file or config directory.
+// Configuration files that fail to parse are skipped and reported as warnings.
+func (g *DiagramGenerator) parseResources(ctx context.Context, cfg DiagramConfig) ([]parser.Resource, []string, error) {
 	// Check context before proceeding
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, nil, ctx.Err()
 	default:
 	}
 
 	// Determine input source
 	if cfg.StatePath != "" {
-		return parser.ParseStateFile(ctx, cfg.StatePath)
+		if parser.IsStateURL(cfg.StatePath) {
+			resources, err := parser.ParseStateURL(ctx, cfg.StatePath, newRemoteStateConfig(cfg.ProviderConfig, nil))
+			return resources, nil, err
+		}
+		resources, err := parser.ParseStateFile(ctx, cfg.StatePath)
+		return resources, nil, err
+	}
+
+	if cfg.ConfigPath != "" && (len(cfg.BackendConfig) > 0 || len(cfg.Workspaces) > 0) {
+		backend, err := parser.ParseBackendConfig(cfg.ConfigPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse backend configuration: %w", err)
+		}
+
+		if len(cfg.Workspaces) > 0 {
+			remoteConfig := newRemoteStateConfig(cfg.ProviderConfig, mergeBackendConfig(backend, cfg.BackendConfig))
+			resources, err := parser.LoadWorkspaceStates(ctx, remoteConfig, cfg.Workspaces)
+			return resources, nil, err
+		}
+
+		resources, err := loadFromBackend(ctx, cfg.ProviderConfig, backend, cfg.BackendConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load state from %s backend: %w", backend.Type, err)
+		}
+		return resources, nil, nil
 	}
 
 	if cfg.ConfigPath != "" {
-		return parser.ParseConfigDirectory(ctx, cfg.ConfigPath)
+		resources, fileErrs, err := parser.ParseConfigDirectoryWithOptions(ctx, cfg.ConfigPath, parser.ConfigParseOptions{
+			NonRecursive: cfg.configDirOnly,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var warnings []string
+		for _, fileErr := range fileErrs {
+			warnings = append(warnings, fileErr.Error())
+		}
+		return resources, warnings, nil
 	}
 
-	return nil, fmt.Errorf("either state_path or config_path must be provided")
+	return nil, nil, fmt.Errorf("either state_path or config_path must be provided")
 }
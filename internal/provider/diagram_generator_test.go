@@ -1,10 +1,25 @@
 package provider
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
 )
 
 func TestDiagramGenerator_Generate(t *testing.T) {
@@ -68,11 +83,33 @@ func TestDiagramGenerator_Generate(t *testing.T) {
 			wantErr: true,
 		},
 		{
+			// Missing directories are created, but not below a file
 			name: "invalid output path",
 			config: DiagramConfig{
 				StatePath:  stateFile,
-				OutputPath: "/nonexistent/directory/diagram.svg",
+				OutputPath: filepath.Join(stateFile, "diagram.svg"),
+				Format:     "svg",
+			},
+			wantErr: true,
+		},
+		{
+			name: "focus on existing resource",
+			config: DiagramConfig{
+				StatePath:  stateFile,
+				OutputPath: filepath.Join(tmpDir, "focus.svg"),
+				Format:     "svg",
+				Focus:      "aws_instance.web",
+				FocusDepth: 1,
+			},
+			wantErr: false,
+		},
+		{
+			name: "focus on unknown resource",
+			config: DiagramConfig{
+				StatePath:  stateFile,
+				OutputPath: filepath.Join(tmpDir, "focus.svg"),
 				Format:     "svg",
+				Focus:      "aws_instance.missing",
 			},
 			wantErr: true,
 		},
@@ -119,6 +156,60 @@ func TestDiagramGenerator_Generate(t *testing.T) {
 	}
 }
 
+func TestDiagramGenerator_Generate_Raster(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	generator := &DiagramGenerator{}
+	generate := func(format string, quality int) []byte {
+		t.Helper()
+		outputPath := filepath.Join(tmpDir, fmt.Sprintf("diagram-%d.%s", quality, format))
+		if _, err := generator.Generate(context.Background(), DiagramConfig{
+			StatePath:   stateFile,
+			OutputPath:  outputPath,
+			Format:      format,
+			Direction:   "TB",
+			JPEGQuality: quality,
+		}); err != nil {
+			t.Fatalf("Generate() as %s error = %v", format, err)
+		}
+		data, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read %s output: %v", format, err)
+		}
+		return data
+	}
+
+	if _, err := png.Decode(bytes.NewReader(generate("png", 0))); err != nil {
+		t.Errorf("PNG output does not decode: %v", err)
+	}
+
+	best := generate("jpg", 100)
+	if _, err := jpeg.Decode(bytes.NewReader(best)); err != nil {
+		t.Fatalf("JPEG output does not decode: %v", err)
+	}
+	if low := generate("jpg", 10); len(low) >= len(best) {
+		t.Errorf("JPEG at quality 10 is %d bytes, want smaller than quality 100's %d", len(low), len(best))
+	}
+}
+
 func TestDiagramGenerator_Generate_ContextCancellation(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -167,6 +258,204 @@ func TestDiagramGenerator_Generate_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestDiagramGenerator_Generate_Timeout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	generator := &DiagramGenerator{}
+	config := DiagramConfig{
+		StatePath: stateFile,
+		Format:    "svg",
+		Direction: "TB",
+		Timeout:   time.Nanosecond,
+	}
+
+	_, err := generator.Generate(context.Background(), config)
+	if !errors.Is(err, ErrGenerateTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Generate() error = %v, want ErrGenerateTimeout wrapping context.DeadlineExceeded", err)
+	}
+
+	// Cancellation by the caller is not reported as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	config.Timeout = time.Minute
+	_, err = generator.Generate(ctx, config)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrGenerateTimeout) {
+		t.Errorf("Generate() error = %v, want context.Canceled without ErrGenerateTimeout", err)
+	}
+
+	// A generous timeout does not affect successful generation
+	if _, err := generator.Generate(context.Background(), config); err != nil {
+		t.Errorf("Generate() with timeout error = %v", err)
+	}
+}
+
+func TestDiagramGenerator_Generate_Layout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "vpc-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}, "dependencies": ["aws_vpc.main"]}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	generator := &DiagramGenerator{}
+	config := DiagramConfig{StatePath: stateFile, Format: "svg", Direction: "TB"}
+
+	result, err := generator.Generate(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	layout := result.Layout
+	if layout == nil {
+		t.Fatal("Generate() returned no layout")
+	}
+
+	for _, id := range []string{"aws_vpc.main", "aws_instance.web"} {
+		node := layout.Nodes[id]
+		if node == nil {
+			t.Errorf("layout has no position for %s", id)
+			continue
+		}
+		if node.Position.X < 0 || node.Position.Y < 0 ||
+			node.Position.X+node.Width > layout.Width || node.Position.Y+node.Height > layout.Height {
+			t.Errorf("node %s at %v (%vx%v) lies outside the layout bounds %vx%v",
+				id, node.Position, node.Width, node.Height, layout.Width, layout.Height)
+		}
+	}
+
+	// Positions are stable across runs
+	again, err := generator.Generate(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for id, node := range layout.Nodes {
+		if other := again.Layout.Nodes[id]; other == nil || other.Position != node.Position {
+			t.Errorf("node %s position changed between runs", id)
+		}
+	}
+}
+
+func TestDiagramGenerator_Generate_Logger(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "vpc-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}, "dependencies": ["aws_vpc.main"]}]
+			},
+			{
+				"mode": "managed",
+				"type": "tls_private_key",
+				"name": "ssh",
+				"provider": "provider[\"registry.terraform.io/hashicorp/tls\"]",
+				"instances": [{"attributes": {"id": "key"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	generator := &DiagramGenerator{}
+	_, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath: stateFile,
+		Format:    "svg",
+		Direction: "TB",
+		Logger:    slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	events := make(map[string]map[string]interface{})
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event map[string]interface{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		events[event["msg"].(string)] = event
+	}
+
+	want := map[string]map[string]float64{
+		"resources parsed": {"resources": 3},
+		"graph built":      {"nodes": 2, "edges": 1, "filtered": 1},
+		"layout computed":  {"nodes": 2, "edges": 1},
+	}
+	for msg, attrs := range want {
+		event, ok := events[msg]
+		if !ok {
+			t.Errorf("no %q event logged", msg)
+			continue
+		}
+		for key, value := range attrs {
+			if event[key] != value {
+				t.Errorf("%q event %s = %v, want %v", msg, key, event[key], value)
+			}
+		}
+	}
+
+	if event, ok := events["layout computed"]; ok {
+		if width, _ := event["width"].(float64); width <= 0 {
+			t.Errorf("layout computed width = %v, want > 0", event["width"])
+		}
+	}
+	if event, ok := events["diagram rendered"]; !ok || event["renderer"] != "svg" {
+		t.Errorf("diagram rendered event = %v, want renderer svg", event)
+	}
+}
+
 func TestParseResources(t *testing.T) {
 	tmpDir := t.TempDir()
 	generator := &DiagramGenerator{}
@@ -216,10 +505,24 @@ resource "aws_instance" "web" {
 		t.Fatalf("Failed to create .tf file: %v", err)
 	}
 
+	// Create a config directory with one valid and one invalid .tf file
+	partialDir := filepath.Join(tmpDir, "partial")
+	if err := os.MkdirAll(partialDir, 0755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(partialDir, "main.tf"), []byte(tfContent), 0644); err != nil {
+		t.Fatalf("Failed to create .tf file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(partialDir, "broken.tf"), []byte(`resource "aws_instance" {`), 0644); err != nil {
+		t.Fatalf("Failed to create .tf file: %v", err)
+	}
+
 	tests := []struct {
-		name    string
-		config  DiagramConfig
-		wantErr bool
+		name          string
+		config        DiagramConfig
+		wantResources int
+		wantWarnings  int
+		wantErr       bool
 	}{
 		{
 			name: "parse state file",
@@ -233,7 +536,34 @@ resource "aws_instance" "web" {
 			config: DiagramConfig{
 				ConfigPath: configDir,
 			},
-			wantErr: false,
+			wantResources: 1,
+			wantErr:       false,
+		},
+		{
+			name: "config directory with invalid file",
+			config: DiagramConfig{
+				ConfigPath: partialDir,
+			},
+			wantResources: 1,
+			wantWarnings:  1,
+			wantErr:       false,
+		},
+		{
+			name: "backend config overrides local state path",
+			config: DiagramConfig{
+				ConfigPath:    configDir,
+				BackendConfig: map[string]string{"path": filepath.Join("..", "terraform.tfstate")},
+			},
+			wantResources: 1,
+			wantErr:       false,
+		},
+		{
+			name: "backend config with missing state",
+			config: DiagramConfig{
+				ConfigPath:    configDir,
+				BackendConfig: map[string]string{"path": "missing.tfstate"},
+			},
+			wantErr: true,
 		},
 		{
 			name:    "no input",
@@ -244,12 +574,19 @@ resource "aws_instance" "web" {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := generator.parseResources(ctx, tt.config)
+			resources, warnings, err := generator.parseResources(ctx, tt.config)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseResources() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+
+			if tt.wantResources > 0 && len(resources) != tt.wantResources {
+				t.Errorf("parseResources() got %d resources, want %d", len(resources), tt.wantResources)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("parseResources() got %d warnings, want %d", len(warnings), tt.wantWarnings)
+			}
 		})
 	}
 }
@@ -316,3 +653,659 @@ func TestDiagramConfig_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveOutputPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		outputDir  string
+		outputPath string
+		want       string
+	}{
+		{
+			name:       "no output dir",
+			outputDir:  "",
+			outputPath: "diagram.svg",
+			want:       "diagram.svg",
+		},
+		{
+			name:       "relative path joins output dir",
+			outputDir:  "/tmp/diagrams",
+			outputPath: "network/diagram.svg",
+			want:       filepath.Join("/tmp/diagrams", "network/diagram.svg"),
+		},
+		{
+			name:       "absolute path used as-is",
+			outputDir:  "/tmp/diagrams",
+			outputPath: "/var/out/diagram.svg",
+			want:       "/var/out/diagram.svg",
+		},
+		{
+			name:       "stdout used as-is",
+			outputDir:  "/tmp/diagrams",
+			outputPath: StdoutOutputPath,
+			want:       StdoutOutputPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveOutputPath(tt.outputDir, tt.outputPath); got != tt.want {
+				t.Errorf("ResolveOutputPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagramGenerator_Generate_OutputDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	outputDir := filepath.Join(tmpDir, "diagrams")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+
+	generator := &DiagramGenerator{}
+	result, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath:  stateFile,
+		OutputPath: "infra.svg",
+		OutputDir:  outputDir,
+		Format:     "svg",
+		Direction:  "TB",
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	want := filepath.Join(outputDir, "infra.svg")
+	if result.OutputPath != want {
+		t.Errorf("Generate() OutputPath = %v, want %v", result.OutputPath, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("Generate() did not create output file at %s: %v", want, err)
+	}
+
+	// Missing directories of the output path are created
+	result, err = generator.Generate(context.Background(), DiagramConfig{
+		StatePath:  stateFile,
+		OutputPath: filepath.Join("prod", "network", "infra.svg"),
+		OutputDir:  outputDir,
+		Format:     "svg",
+		Direction:  "TB",
+	})
+	if err != nil {
+		t.Fatalf("Generate() into a missing directory error = %v", err)
+	}
+	want = filepath.Join(outputDir, "prod", "network", "infra.svg")
+	if result.OutputPath != want {
+		t.Errorf("Generate() OutputPath = %v, want %v", result.OutputPath, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("Generate() did not create output file at %s: %v", want, err)
+	}
+
+	// A failed generation leaves no directories behind
+	if _, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath:  filepath.Join(tmpDir, "missing.tfstate"),
+		OutputPath: filepath.Join("staging", "network", "infra.svg"),
+		OutputDir:  outputDir,
+		Format:     "svg",
+		Direction:  "TB",
+	}); err == nil {
+		t.Fatal("Generate() with a missing state file should return error")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "staging")); !os.IsNotExist(err) {
+		t.Errorf("failed Generate() created the output directory: %v", err)
+	}
+}
+
+func TestDiagramGenerator_GenerateAll(t *testing.T) {
+	rootDir := t.TempDir()
+	outputRoot := filepath.Join(t.TempDir(), "diagrams")
+
+	files := map[string]string{
+		"network/main.tf":                           `resource "aws_vpc" "main" { cidr_block = "10.0.0.0/16" }`,
+		"network/peering/main.tf":                   `resource "aws_vpc_peering_connection" "main" {}`,
+		"services/app/main.tf":                      `resource "aws_instance" "web" { ami = "ami-12345" }`,
+		"services/app/variables.tf":                 `variable "env" {}`,
+		"services/app/.terraform/modules/x/main.tf": `resource "aws_instance" "module" {}`,
+		"dns/main.tf.json":                          `{"resource": {"aws_route53_zone": {"main": {"name": "example.com"}}}}`,
+		"empty/variables.tf":                        `variable "region" {}`,
+		".terraform/modules/vpc/main.tf":            `resource "aws_vpc" "module" {}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(rootDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create .tf file: %v", err)
+		}
+	}
+
+	generator := &DiagramGenerator{}
+	results, err := generator.GenerateAll(context.Background(), rootDir, DiagramConfig{
+		OutputPath: outputRoot,
+		Format:     "svg",
+		Direction:  "TB",
+	})
+
+	// The directory without resources fails without stopping the batch
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(rootDir, "empty")) {
+		t.Errorf("GenerateAll() error = %v, want an error for the empty directory", err)
+	}
+
+	wantOutputs := []string{
+		filepath.Join(outputRoot, "dns", "diagram.svg"),
+		filepath.Join(outputRoot, "network", "diagram.svg"),
+		filepath.Join(outputRoot, "network", "peering", "diagram.svg"),
+		filepath.Join(outputRoot, "services", "app", "diagram.svg"),
+	}
+	if len(results) != len(wantOutputs) {
+		t.Fatalf("GenerateAll() returned %d results, want %d", len(results), len(wantOutputs))
+	}
+	for i, want := range wantOutputs {
+		if results[i].OutputPath != want {
+			t.Errorf("results[%d].OutputPath = %v, want %v", i, results[i].OutputPath, want)
+		}
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("GenerateAll() did not create output file at %s: %v", want, err)
+		}
+		// Each diagram holds only its own directory's resource, not those of nested
+		// configurations or hidden module directories
+		if results[i].ResourceCount != 1 {
+			t.Errorf("results[%d].ResourceCount = %d, want 1", i, results[i].ResourceCount)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(outputRoot, ".terraform")); !os.IsNotExist(err) {
+		t.Error("GenerateAll() generated a diagram for a hidden directory")
+	}
+	if _, err := os.Stat(filepath.Join(outputRoot, "empty")); !os.IsNotExist(err) {
+		t.Error("GenerateAll() created an output directory for a directory that failed")
+	}
+
+	// A template output path is expanded per directory
+	templateRoot := t.TempDir()
+	results, _ = generator.GenerateAll(context.Background(), rootDir, DiagramConfig{
+		OutputPath: filepath.Join(templateRoot, "{{.Dir}}.{{.Format}}"),
+		Format:     "svg",
+		Direction:  "TB",
+	})
+	wantOutputs = []string{
+		filepath.Join(templateRoot, "dns.svg"),
+		filepath.Join(templateRoot, "network.svg"),
+		filepath.Join(templateRoot, "network", "peering.svg"),
+		filepath.Join(templateRoot, "services", "app.svg"),
+	}
+	if len(results) != len(wantOutputs) {
+		t.Fatalf("GenerateAll() with template returned %d results, want %d", len(results), len(wantOutputs))
+	}
+	for i, want := range wantOutputs {
+		if results[i].OutputPath != want {
+			t.Errorf("results[%d].OutputPath = %v, want %v", i, results[i].OutputPath, want)
+		}
+	}
+
+	// A cancelled context stops the batch before any directory is generated
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = generator.GenerateAll(ctx, rootDir, DiagramConfig{Format: "svg", Direction: "TB"})
+	if !errors.Is(err, context.Canceled) || len(results) != 0 {
+		t.Errorf("GenerateAll() with cancelled context = %d results, %v; want none and context.Canceled", len(results), err)
+	}
+}
+
+func TestDiagramGenerator_Generate_IgnoreFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_cloudwatch_log_group",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "/aws/web"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".cartographyignore"), []byte("# noisy\naws_cloudwatch_*\n"), 0644); err != nil {
+		t.Fatalf("Failed to create ignore file: %v", err)
+	}
+
+	generator := &DiagramGenerator{}
+	result, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath: stateFile,
+		Format:    "svg",
+		Direction: "TB",
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if result.ResourceCount != 1 {
+		t.Errorf("Generate() ResourceCount = %d, want 1", result.ResourceCount)
+	}
+	if _, ok := result.Layout.Nodes["aws_cloudwatch_log_group.web"]; ok {
+		t.Error("ignored resource aws_cloudwatch_log_group.web is in the diagram")
+	}
+	if _, ok := result.Layout.Nodes["aws_instance.web"]; !ok {
+		t.Error("aws_instance.web is missing from the diagram")
+	}
+}
+
+func TestDiagramGenerator_Generate_Stdout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	captured := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		captured <- data
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	generator := &DiagramGenerator{}
+	result, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath:  stateFile,
+		OutputPath: StdoutOutputPath,
+		OutputDir:  tmpDir,
+		Format:     "svg",
+		Direction:  "TB",
+	})
+	os.Stdout = stdout
+	writer.Close()
+	written := <-captured
+
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if result.OutputPath != StdoutOutputPath {
+		t.Errorf("Generate() OutputPath = %v, want %v", result.OutputPath, StdoutOutputPath)
+	}
+	if !strings.Contains(string(written), "<svg") || !bytes.Equal(written, result.Data) {
+		t.Errorf("Generate() wrote %d bytes to stdout, want the %d rendered SVG bytes", len(written), len(result.Data))
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, StdoutOutputPath)); !os.IsNotExist(err) {
+		t.Error("Generate() created a file named - instead of writing to stdout")
+	}
+
+	if _, err := generator.GenerateAll(context.Background(), tmpDir, DiagramConfig{OutputPath: StdoutOutputPath}); err == nil {
+		t.Error("GenerateAll() with stdout output succeeded, want an error")
+	}
+}
+
+func TestDiagramGenerator_Generate_EmitManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_s3_bucket",
+				"name": "assets",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "assets"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "diagram.svg")
+	generator := &DiagramGenerator{}
+	result, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath:    stateFile,
+		OutputPath:   outputPath,
+		Format:       "svg",
+		Direction:    "TB",
+		EmitManifest: true,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath + ManifestSuffix)
+	if err != nil {
+		t.Fatalf("Generate() did not write the manifest: %v", err)
+	}
+	var manifest DiagramManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+
+	if manifest.ResourceCount != result.ResourceCount {
+		t.Errorf("manifest resource_count = %d, want %d", manifest.ResourceCount, result.ResourceCount)
+	}
+	wantIDs := []string{"aws_instance.web", "aws_s3_bucket.assets"}
+	if strings.Join(manifest.NodeIDs, ",") != strings.Join(wantIDs, ",") {
+		t.Errorf("manifest node_ids = %v, want %v", manifest.NodeIDs, wantIDs)
+	}
+	if manifest.InputPath != stateFile || manifest.OutputPath != outputPath || manifest.Format != "svg" {
+		t.Errorf("manifest = %+v, want input %s, output %s, format svg", manifest, stateFile, outputPath)
+	}
+	sum := sha256.Sum256(result.Data)
+	if manifest.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("manifest sha256 = %s, want the hash of the diagram", manifest.SHA256)
+	}
+}
+
+func TestDiagramGenerator_Generate_GraphTransforms(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	addBackup := func(g *graph.Graph) *graph.Graph {
+		backup := &graph.Node{
+			ID:           "external.backup",
+			Type:         "external",
+			Name:         "offsite-backup",
+			Provider:     "external",
+			ResourceType: parser.ResourceTypeStorage,
+			Attributes:   map[string]interface{}{},
+		}
+		edge := &graph.Edge{From: g.Nodes["aws_instance.web"], To: backup, Relationship: "depends_on"}
+		g.Nodes[backup.ID] = backup
+		g.Edges = append(g.Edges, edge)
+		edge.From.Edges = append(edge.From.Edges, edge)
+		return g
+	}
+	relabel := func(g *graph.Graph) *graph.Graph {
+		// Runs after addBackup, so it sees the added edge
+		for _, edge := range g.Edges {
+			if edge.To.ID == "external.backup" {
+				edge.Relationship = "backs_up_to"
+			}
+		}
+		return g
+	}
+
+	generator := &DiagramGenerator{}
+	result, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath:       stateFile,
+		Format:          "svg",
+		Direction:       "TB",
+		IncludeLabels:   true,
+		GraphTransforms: []GraphTransform{addBackup, relabel},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if _, ok := result.Layout.Nodes["external.backup"]; !ok {
+		t.Error("node added by a transform is missing from the layout")
+	}
+	if !strings.Contains(string(result.Data), "offsite-backup") {
+		t.Error("node added by a transform is not rendered")
+	}
+	relabeled := false
+	for _, edgeLayout := range result.Layout.Edges {
+		if edgeLayout.Edge.To.ID == "external.backup" && edgeLayout.Edge.Relationship == "backs_up_to" {
+			relabeled = true
+		}
+	}
+	if !relabeled {
+		t.Error("transforms did not run in order: the added edge was not relabeled")
+	}
+
+	// A transform returning nil fails generation
+	_, err = generator.Generate(context.Background(), DiagramConfig{
+		StatePath:       stateFile,
+		Format:          "svg",
+		GraphTransforms: []GraphTransform{func(*graph.Graph) *graph.Graph { return nil }},
+	})
+	if err == nil {
+		t.Error("Generate() with a transform returning nil succeeded, want an error")
+	}
+}
+
+func TestDiagramGenerator_InputHash(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	writeState := func(instanceType string) {
+		t.Helper()
+		stateContent := `{
+			"version": 4,
+			"terraform_version": "1.0.0",
+			"resources": [
+				{
+					"mode": "managed",
+					"type": "aws_instance",
+					"name": "web",
+					"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+					"instances": [{"attributes": {"id": "i-12345", "instance_type": "` + instanceType + `"}}]
+				}
+			]
+		}`
+		if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+			t.Fatalf("Failed to create test state file: %v", err)
+		}
+	}
+	writeState("t2.micro")
+
+	generator := &DiagramGenerator{}
+	ctx := context.Background()
+	cfg := DiagramConfig{
+		StatePath:     stateFile,
+		OutputPath:    filepath.Join(tmpDir, "diagram.svg"),
+		Format:        "svg",
+		Direction:     "TB",
+		IncludeLabels: true,
+	}
+
+	first, err := generator.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(first.InputHash) != 64 {
+		t.Fatalf("InputHash = %q, want a hex SHA-256", first.InputHash)
+	}
+
+	// Identical inputs hash the same, whether or not the diagram is rendered or written
+	second, err := generator.Generate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if second.InputHash != first.InputHash {
+		t.Errorf("InputHash changed between identical runs: %s, %s", first.InputHash, second.InputHash)
+	}
+	inMemory := cfg
+	inMemory.OutputPath = ""
+	if hash, err := generator.InputHash(ctx, inMemory); err != nil || hash != first.InputHash {
+		t.Errorf("InputHash() = %q, %v, want %q", hash, err, first.InputHash)
+	}
+
+	changes := map[string]func(*DiagramConfig){
+		"direction":            func(c *DiagramConfig) { c.Direction = "LR" },
+		"title":                func(c *DiagramConfig) { c.Title = "Production" },
+		"labels":               func(c *DiagramConfig) { c.IncludeLabels = false },
+		"implicit connections": func(c *DiagramConfig) { c.SkipImplicitConnections = true },
+	}
+	for name, change := range changes {
+		changed := cfg
+		change(&changed)
+		hash, err := generator.InputHash(ctx, changed)
+		if err != nil {
+			t.Fatalf("%s: InputHash() error = %v", name, err)
+		}
+		if hash == first.InputHash {
+			t.Errorf("%s: InputHash did not change with the option", name)
+		}
+	}
+
+	writeState("t3.large")
+	hash, err := generator.InputHash(ctx, cfg)
+	if err != nil {
+		t.Fatalf("InputHash() error = %v", err)
+	}
+	if hash == first.InputHash {
+		t.Error("InputHash did not change with the state")
+	}
+}
+
+func TestDiagramGenerator_Generate_OutputPathTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	stateDir := filepath.Join(tmpDir, "network")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	stateFile := filepath.Join(stateDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "vpc-12345"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "digitalocean_droplet",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/digitalocean/digitalocean\"]",
+				"instances": [{"attributes": {"id": "12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	generator := &DiagramGenerator{}
+	result, err := generator.Generate(context.Background(), DiagramConfig{
+		StatePath:  stateFile,
+		OutputPath: "diagrams/{{.Dir}}-{{.Provider}}.{{.Format}}",
+		OutputDir:  tmpDir,
+		Format:     "svg",
+		Direction:  "TB",
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// The diagrams directory does not exist yet and is created
+	want := filepath.Join(tmpDir, "diagrams", "network-aws.svg")
+	if result.OutputPath != want {
+		t.Errorf("Generate() OutputPath = %v, want %v", result.OutputPath, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("Generate() did not create output file at %s: %v", want, err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "workspace and format", template: "out/{{.Workspace}}.{{.Format}}", want: "out/prod+staging.png"},
+		{name: "functions", template: `{{printf "%s_%s" .Dir .Provider}}.svg`, want: "app_aws.svg"},
+		{name: "unknown field", template: "{{.Module}}.svg", wantErr: true},
+		{name: "syntax error", template: "{{.Dir.svg", wantErr: true},
+		{name: "empty expansion", template: "{{if not .Dir}}{{.Dir}}{{end}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := OutputPathData{Dir: "app", Provider: "aws", Format: "png", Workspace: "prod+staging"}
+			got, err := expandOutputPath(tt.template, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandOutputPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("expandOutputPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,24 +2,36 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &DiagramResource{}
 var _ resource.ResourceWithImportState = &DiagramResource{}
+var _ resource.ResourceWithValidateConfig = &DiagramResource{}
+var _ resource.ResourceWithModifyPlan = &DiagramResource{}
 
 // DiagramResource defines the resource implementation.
 type DiagramResource struct {
-	generator *DiagramGenerator
+	generator      *DiagramGenerator
+	providerConfig *CartographyProviderModel
 }
 
 // NewDiagramResource creates a new diagram resource with a generator
@@ -40,6 +52,17 @@ type DiagramResourceModel struct {
 	IncludeLabels types.Bool   `tfsdk:"include_labels"`
 	Title         types.String `tfsdk:"title"`
 	UseIcons      types.Bool   `tfsdk:"use_icons"`
+	JPEGQuality   types.Int64  `tfsdk:"jpeg_quality"`
+
+	ImplicitConnections types.Bool   `tfsdk:"implicit_connections"`
+	IncludeAssociations types.Bool   `tfsdk:"include_associations"`
+	BackendConfig       types.Map    `tfsdk:"backend_config"`
+	Workspaces          types.List   `tfsdk:"workspaces"`
+	Focus               types.String `tfsdk:"focus"`
+	FocusDepth          types.Int64  `tfsdk:"focus_depth"`
+	Timeout             types.String `tfsdk:"timeout"`
+	SVG                 types.String `tfsdk:"svg"`
+	InputHash           types.String `tfsdk:"input_hash"`
 }
 
 func (r *DiagramResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -59,7 +82,12 @@ func (r *DiagramResource) Schema(ctx context.Context, req resource.SchemaRequest
 				},
 			},
 			"state_path": schema.StringAttribute{
-				MarkdownDescription: "Path to terraform.tfstate file. If not provided, will attempt to read from config_path.",
+				MarkdownDescription: "Path to terraform.tfstate file or an HTTP(S) URL serving it. If not provided, will attempt to read from config_path.",
+				Optional:            true,
+			},
+			"backend_config": schema.MapAttribute{
+				MarkdownDescription: "Settings that override or extend the backend configured in config_path (e.g. `key` or `workspace_key_prefix` for S3). When set, resources are loaded from the backend state instead of the configuration files.",
+				ElementType:         types.StringType,
 				Optional:            true,
 			},
 			"config_path": schema.StringAttribute{
@@ -67,21 +95,70 @@ func (r *DiagramResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Optional:            true,
 			},
 			"output_path": schema.StringAttribute{
-				MarkdownDescription: "Path where the diagram will be saved.",
-				Required:            true,
+				MarkdownDescription: "Path where the diagram will be saved. Relative paths are resolved against the provider `output_dir` when set. May be a Go template using `.Dir` (input directory name), `.Provider`, `.Format` and `.Workspace`, e.g. `diagrams/{{.Dir}}.{{.Format}}`; missing directories of the expanded path are created. Optional when `format` is 'svg', in which case the diagram can be read from the `svg` attribute instead. Writing to stdout (`-`) is not supported.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.NoneOf(StdoutOutputPath),
+				},
+			},
+			"focus": schema.StringAttribute{
+				MarkdownDescription: "Resource address (e.g. `aws_lb.main`) to focus on. Only this resource and the resources connected to it, in either direction, are drawn.",
+				Optional:            true,
+			},
+			"focus_depth": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of connections between the `focus` resource and any drawn resource. Default is unlimited.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+					int64validator.AlsoRequires(path.MatchRoot("focus")),
+				},
 			},
 			"format": schema.StringAttribute{
-				MarkdownDescription: "Output format: 'png' or 'svg'. Default is 'png'.",
+				MarkdownDescription: "Output format: 'png', 'jpg', 'jpeg' or 'svg'. Default is 'png'. PNG and JPEG are drawn by the built-in raster renderer, which draws plain boxes without icons.",
 				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf("svg", "png", "jpg", "jpeg"),
+				},
 			},
 			"direction": schema.StringAttribute{
 				MarkdownDescription: "Diagram direction: 'TB' (top to bottom), 'LR' (left to right), 'BT' (bottom to top), or 'RL' (right to left). Default is 'TB'.",
 				Optional:            true,
 			},
+			"jpeg_quality": schema.Int64Attribute{
+				MarkdownDescription: "Quality of JPEG output (`format` 'jpg' or 'jpeg'), from 1 (smallest file) to 100 (best quality). Default is 95.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 100),
+				},
+			},
+			"implicit_connections": schema.BoolAttribute{
+				MarkdownDescription: "Detect connections that are not declared as Terraform dependencies (e.g., security group attachments, attribute references). Set to false to show only explicit dependencies. Default is true.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"include_associations": schema.BoolAttribute{
+				MarkdownDescription: "Draw association resources (e.g. `aws_route_table_association`, `aws_volume_attachment`) as nodes. By default they are shown only as connections between the resources they link. Default is false.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"input_hash": schema.StringAttribute{
+				MarkdownDescription: "SHA-256 of the resources read from the state or configuration and the options affecting the rendered diagram. The diagram is only regenerated when it changes or the output file is missing.",
+				Computed:            true,
+			},
 			"include_labels": schema.BoolAttribute{
 				MarkdownDescription: "Include resource names and attributes as labels. Default is true.",
 				Optional:            true,
 			},
+			"svg": schema.StringAttribute{
+				MarkdownDescription: "Rendered SVG document. Only set when `format` is 'svg'.",
+				Computed:            true,
+			},
+			"timeout": schema.StringAttribute{
+				MarkdownDescription: "Maximum time to spend generating the diagram, as a duration such as `30s` or `2m`. Parsing, graph building and rendering are aborted once it is exceeded. Default is no limit.",
+				Optional:            true,
+			},
 			"title": schema.StringAttribute{
 				MarkdownDescription: "Title for the diagram.",
 				Optional:            true,
@@ -90,22 +167,153 @@ func (r *DiagramResource) Schema(ctx context.Context, req resource.SchemaRequest
 				MarkdownDescription: "Use official cloud provider icons if available. Falls back to colored boxes if icons not found. Default is false.",
 				Optional:            true,
 			},
+			"workspaces": schema.ListAttribute{
+				MarkdownDescription: "Terraform Cloud/Enterprise workspaces whose states are merged into one diagram, in place of the workspace of the `remote` backend configured in config_path. Resource addresses are prefixed with the workspace name (e.g. `prod:aws_vpc.main`).",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.UniqueValues(),
+					listvalidator.AlsoRequires(path.MatchRoot("config_path")),
+				},
+			},
 		},
 	}
 }
 
 func (r *DiagramResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Provider data is nil during validation, before the provider is configured
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerConfig, ok := req.ProviderData.(*CartographyProviderModel)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *CartographyProviderModel, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.providerConfig = providerConfig
 }
 
-func (r *DiagramResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+func (r *DiagramResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
 	var data DiagramResourceModel
 
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Set defaults
+	// Without an output file the diagram is only available through the svg attribute
+	if data.OutputPath.IsNull() && !data.Format.IsUnknown() && data.Format.ValueString() != "svg" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("output_path"),
+			"Missing output path",
+			"output_path is required unless format is \"svg\", in which case the diagram is available in the svg attribute.",
+		)
+	}
+
+	if !data.Timeout.IsUnknown() {
+		_, diags := generateTimeout(data.Timeout)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	if !data.OutputPath.IsUnknown() && IsOutputPathTemplate(data.OutputPath.ValueString()) {
+		if _, err := ParseOutputPathTemplate(data.OutputPath.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("output_path"), "Invalid output path template", err.Error())
+		}
+	}
+}
+
+// outputDir returns the provider-level default output directory, if configured
+func (r *DiagramResource) outputDir() string {
+	if r.providerConfig == nil || r.providerConfig.OutputDir.IsNull() {
+		return ""
+	}
+	return r.providerConfig.OutputDir.ValueString()
+}
+
+// outputFileMissing reports whether the diagram file at outputPath has been removed.
+// Inline-only diagrams and diagrams written to stdout have no file, and templated paths
+// are only known once expanded during generation, so none of them is reported missing.
+func (r *DiagramResource) outputFileMissing(outputPath types.String) bool {
+	path := ResolveOutputPath(r.outputDir(), outputPath.ValueString())
+	if path == "" || path == StdoutOutputPath || IsOutputPathTemplate(path) {
+		return false
+	}
+	_, err := os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+// svgValue returns the rendered diagram as the svg attribute value, or null for other formats
+func svgValue(format string, data []byte) types.String {
+	if format != "svg" {
+		return types.StringNull()
+	}
+	return types.StringValue(string(data))
+}
+
+// generateErrorSummary returns the diagnostic summary for a diagram generation error,
+// naming the failure mode when the parser identified one
+func generateErrorSummary(err error) string {
+	switch {
+	case errors.Is(err, parser.ErrNoStateFound):
+		return "Terraform state not found"
+	case errors.Is(err, parser.ErrBackendAuth):
+		return "Backend authentication failed"
+	case errors.Is(err, parser.ErrUnsupportedBackend):
+		return "Unsupported backend"
+	case errors.Is(err, parser.ErrParse):
+		return "Failed to parse Terraform files"
+	}
+	return "Failed to generate diagram"
+}
+
+// backendOverrides converts the backend_config attribute into backend setting overrides
+func backendOverrides(ctx context.Context, value types.Map) (map[string]string, diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return nil, nil
+	}
+
+	overrides := make(map[string]string, len(value.Elements()))
+	diags := value.ElementsAs(ctx, &overrides, false)
+	return overrides, diags
+}
+
+// workspaceNames converts the workspaces attribute into workspace names
+func workspaceNames(ctx context.Context, value types.List) ([]string, diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(value.Elements()))
+	diags := value.ElementsAs(ctx, &names, false)
+	return names, diags
+}
+
+// generateTimeout parses the timeout attribute; null means no limit
+func generateTimeout(value types.String) (time.Duration, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if value.IsNull() || value.IsUnknown() {
+		return 0, diags
+	}
+
+	timeout, err := time.ParseDuration(value.ValueString())
+	if err != nil || timeout <= 0 {
+		diags.AddAttributeError(
+			path.Root("timeout"),
+			"Invalid timeout",
+			fmt.Sprintf("timeout must be a positive duration such as \"30s\" or \"2m\", got %q.", value.ValueString()),
+		)
+	}
+	return timeout, diags
+}
+
+// setDefaults fills in the defaults of unset optional attributes
+func setDefaults(data *DiagramResourceModel) {
 	if data.Format.IsNull() {
 		data.Format = types.StringValue("png")
 	}
@@ -118,22 +326,72 @@ func (r *DiagramResource) Create(ctx context.Context, req resource.CreateRequest
 	if data.UseIcons.IsNull() {
 		data.UseIcons = types.BoolValue(false)
 	}
+}
 
-	// Use the generator to create the diagram
-	result, err := r.generator.Generate(ctx, DiagramConfig{
+// diagramConfig converts the resource attributes, with defaults set, into the generator
+// configuration
+func (r *DiagramResource) diagramConfig(ctx context.Context, data DiagramResourceModel) (DiagramConfig, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	backendConfig, d := backendOverrides(ctx, data.BackendConfig)
+	diags.Append(d...)
+	workspaces, d := workspaceNames(ctx, data.Workspaces)
+	diags.Append(d...)
+	timeout, d := generateTimeout(data.Timeout)
+	diags.Append(d...)
+
+	return DiagramConfig{
 		StatePath:     data.StatePath.ValueString(),
 		ConfigPath:    data.ConfigPath.ValueString(),
 		OutputPath:    data.OutputPath.ValueString(),
+		OutputDir:     r.outputDir(),
 		Format:        data.Format.ValueString(),
 		Direction:     data.Direction.ValueString(),
 		IncludeLabels: data.IncludeLabels.ValueBool(),
 		Title:         data.Title.ValueString(),
 		UseIcons:      data.UseIcons.ValueBool(),
-	})
+		JPEGQuality:   int(data.JPEGQuality.ValueInt64()),
+
+		SkipImplicitConnections: !data.ImplicitConnections.ValueBool(),
+		IncludeAssociations:     data.IncludeAssociations.ValueBool(),
+
+		Focus:      data.Focus.ValueString(),
+		FocusDepth: int(data.FocusDepth.ValueInt64()),
+
+		BackendConfig:  backendConfig,
+		ProviderConfig: r.providerConfig,
+		Workspaces:     workspaces,
+		Timeout:        timeout,
+	}, diags
+}
+
+func (r *DiagramResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data DiagramResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	setDefaults(&data)
+
+	cfg, diags := r.diagramConfig(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Use the generator to create the diagram
+	result, err := r.generator.Generate(ctx, cfg)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to generate diagram", err.Error())
+		resp.Diagnostics.AddError(generateErrorSummary(err), err.Error())
 		return
 	}
+	for _, warning := range result.Warnings {
+		resp.Diagnostics.AddWarning("Skipped configuration file", warning)
+	}
+	data.SVG = svgValue(data.Format.ValueString(), result.Data)
+	data.InputHash = types.StringValue(result.InputHash)
 
 	// Generate ID from output path and format
 	data.ID = types.StringValue(fmt.Sprintf("%s_%s", result.OutputPath, data.Format.ValueString()))
@@ -150,7 +408,7 @@ func (r *DiagramResource) Read(ctx context.Context, req resource.ReadRequest, re
 	}
 
 	// Check if output file still exists
-	if _, err := os.Stat(data.OutputPath.ValueString()); os.IsNotExist(err) {
+	if r.outputFileMissing(data.OutputPath) {
 		resp.State.RemoveResource(ctx)
 		return
 	}
@@ -158,6 +416,46 @@ func (r *DiagramResource) Read(ctx context.Context, req resource.ReadRequest, re
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// ModifyPlan compares the input hash of the planned diagram with the one it was last
+// generated from: unchanged inputs keep the rendered diagram, so no update is planned
+// for them, while changed inputs (e.g. a new state file) plan a regeneration even
+// though the configuration is the same.
+func (r *DiagramResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to compare on create or destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state DiagramResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || state.InputHash.IsNull() || !req.Config.Raw.IsFullyKnown() {
+		return
+	}
+
+	planned := plan
+	setDefaults(&planned)
+	cfg, diags := r.diagramConfig(ctx, planned)
+	if diags.HasError() {
+		return
+	}
+	// Inputs that cannot be read now are reported when the diagram is generated
+	hash, err := r.generator.InputHash(ctx, cfg)
+	if err != nil {
+		return
+	}
+
+	if hash == state.InputHash.ValueString() && !r.outputFileMissing(plan.OutputPath) {
+		plan.SVG = state.SVG
+		plan.InputHash = state.InputHash
+	} else {
+		plan.SVG = types.StringUnknown()
+		plan.InputHash = types.StringUnknown()
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
 func (r *DiagramResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var data DiagramResourceModel
 
@@ -166,35 +464,25 @@ func (r *DiagramResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
-	// Set defaults
-	if data.Format.IsNull() {
-		data.Format = types.StringValue("png")
-	}
-	if data.Direction.IsNull() {
-		data.Direction = types.StringValue("TB")
-	}
-	if data.IncludeLabels.IsNull() {
-		data.IncludeLabels = types.BoolValue(true)
-	}
-	if data.UseIcons.IsNull() {
-		data.UseIcons = types.BoolValue(false)
+	setDefaults(&data)
+
+	cfg, diags := r.diagramConfig(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Use the generator to update the diagram
-	result, err := r.generator.Generate(ctx, DiagramConfig{
-		StatePath:     data.StatePath.ValueString(),
-		ConfigPath:    data.ConfigPath.ValueString(),
-		OutputPath:    data.OutputPath.ValueString(),
-		Format:        data.Format.ValueString(),
-		Direction:     data.Direction.ValueString(),
-		IncludeLabels: data.IncludeLabels.ValueBool(),
-		Title:         data.Title.ValueString(),
-		UseIcons:      data.UseIcons.ValueBool(),
-	})
+	result, err := r.generator.Generate(ctx, cfg)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to generate diagram", err.Error())
+		resp.Diagnostics.AddError(generateErrorSummary(err), err.Error())
 		return
 	}
+	for _, warning := range result.Warnings {
+		resp.Diagnostics.AddWarning("Skipped configuration file", warning)
+	}
+	data.SVG = svgValue(data.Format.ValueString(), result.Data)
+	data.InputHash = types.StringValue(result.InputHash)
 
 	// Preserve or generate ID
 	if data.ID.IsNull() {
@@ -0,0 +1,331 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// newResourcePlan builds a plan for the diagram resource schema with the given
+// attribute values; all other attributes are null, computed ones unknown, and those
+// with a default set to it, as Terraform plans them.
+func newResourcePlan(ctx context.Context, t *testing.T, r resource.Resource, values map[string]tftypes.Value) (tfsdk.Plan, tfsdk.State) {
+	t.Helper()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	if schemaResp.Diagnostics.HasError() {
+		t.Fatalf("Schema() diagnostics: %v", schemaResp.Diagnostics)
+	}
+	s := schemaResp.Schema
+
+	attrs := make(map[string]tftypes.Value, len(s.Attributes))
+	for name, attribute := range s.Attributes {
+		attrType := attribute.GetType().TerraformType(ctx)
+		switch {
+		case values[name].Type() != nil:
+			attrs[name] = values[name]
+		case attribute.IsComputed() && !attribute.IsOptional():
+			attrs[name] = tftypes.NewValue(attrType, tftypes.UnknownValue)
+		case isBoolWithDefault(attribute):
+			defaultResp := &defaults.BoolResponse{}
+			attribute.(schema.BoolAttribute).Default.DefaultBool(ctx, defaults.BoolRequest{}, defaultResp)
+			attrs[name] = tftypes.NewValue(attrType, defaultResp.PlanValue.ValueBool())
+		default:
+			attrs[name] = tftypes.NewValue(attrType, nil)
+		}
+	}
+
+	objectType := s.Type().TerraformType(ctx)
+	plan := tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(objectType, attrs)}
+	state := tfsdk.State{Schema: s, Raw: tftypes.NewValue(objectType, nil)}
+
+	return plan, state
+}
+
+// isBoolWithDefault reports whether attribute is a bool attribute with a default value
+func isBoolWithDefault(attribute schema.Attribute) bool {
+	boolAttribute, ok := attribute.(schema.BoolAttribute)
+	return ok && boolAttribute.Default != nil
+}
+
+func TestDiagramResource_Create_SVGAttribute(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		outputPath string
+	}{
+		{name: "inline only", outputPath: ""},
+		{name: "inline and file", outputPath: filepath.Join(tmpDir, "diagram.svg")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewDiagramResource()
+
+			values := map[string]tftypes.Value{
+				"state_path": tftypes.NewValue(tftypes.String, stateFile),
+				"format":     tftypes.NewValue(tftypes.String, "svg"),
+			}
+			if tt.outputPath != "" {
+				values["output_path"] = tftypes.NewValue(tftypes.String, tt.outputPath)
+			}
+			plan, state := newResourcePlan(ctx, t, r, values)
+
+			resp := &resource.CreateResponse{State: state}
+			r.Create(ctx, resource.CreateRequest{Plan: plan}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Create() diagnostics: %v", resp.Diagnostics)
+			}
+
+			var svg string
+			resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("svg"), &svg)...)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("GetAttribute(svg) diagnostics: %v", resp.Diagnostics)
+			}
+			if !strings.Contains(svg, "<svg") {
+				t.Errorf("svg attribute = %q, want an SVG document", svg)
+			}
+
+			if tt.outputPath != "" {
+				if _, err := os.Stat(tt.outputPath); err != nil {
+					t.Errorf("Create() did not write output file: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagramResource_Create_UnsetDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+				"instances": [{"attributes": {"id": "i-12345"}}]
+			}
+		]
+	}`
+	if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+		t.Fatalf("Failed to create test state file: %v", err)
+	}
+
+	r := NewDiagramResource()
+	plan, state := newResourcePlan(ctx, t, r, map[string]tftypes.Value{
+		"state_path": tftypes.NewValue(tftypes.String, stateFile),
+		"format":     tftypes.NewValue(tftypes.String, "svg"),
+	})
+
+	resp := &resource.CreateResponse{State: state}
+	r.Create(ctx, resource.CreateRequest{Plan: plan}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Create() diagnostics: %v", resp.Diagnostics)
+	}
+
+	// Attributes left unset are planned with their defaults, and the applied state must
+	// match the plan or Terraform reports an inconsistent result
+	for name, want := range map[string]bool{"implicit_connections": true, "include_associations": false} {
+		var planned, applied types.Bool
+		resp.Diagnostics.Append(plan.GetAttribute(ctx, path.Root(name), &planned)...)
+		resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root(name), &applied)...)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("GetAttribute(%s) diagnostics: %v", name, resp.Diagnostics)
+		}
+		if !planned.Equal(types.BoolValue(want)) {
+			t.Errorf("%s planned as %s, want the default %v", name, planned, want)
+		}
+		if !applied.Equal(planned) {
+			t.Errorf("%s applied as %s, want the planned %s", name, applied, planned)
+		}
+	}
+}
+
+func TestDiagramResource_ModifyPlan_InputHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+
+	stateFile := filepath.Join(tmpDir, "terraform.tfstate")
+	writeState := func(instanceID string) {
+		t.Helper()
+		stateContent := `{
+			"version": 4,
+			"terraform_version": "1.0.0",
+			"resources": [
+				{
+					"mode": "managed",
+					"type": "aws_instance",
+					"name": "web",
+					"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+					"instances": [{"attributes": {"id": "` + instanceID + `"}}]
+				}
+			]
+		}`
+		if err := os.WriteFile(stateFile, []byte(stateContent), 0644); err != nil {
+			t.Fatalf("Failed to create test state file: %v", err)
+		}
+	}
+	writeState("i-12345")
+
+	r := NewDiagramResource()
+	outputPath := filepath.Join(tmpDir, "diagram.svg")
+	plan, state := newResourcePlan(ctx, t, r, map[string]tftypes.Value{
+		"state_path":  tftypes.NewValue(tftypes.String, stateFile),
+		"output_path": tftypes.NewValue(tftypes.String, outputPath),
+		"format":      tftypes.NewValue(tftypes.String, "svg"),
+	})
+
+	createResp := &resource.CreateResponse{State: state}
+	r.Create(ctx, resource.CreateRequest{Plan: plan}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("Create() diagnostics: %v", createResp.Diagnostics)
+	}
+	var storedHash string
+	createResp.Diagnostics.Append(createResp.State.GetAttribute(ctx, path.Root("input_hash"), &storedHash)...)
+	if storedHash == "" {
+		t.Fatalf("Create() did not store input_hash: %v", createResp.Diagnostics)
+	}
+
+	// The configuration leaves the computed attributes null
+	configRaw, err := tftypes.Transform(plan.Raw, func(_ *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		if !v.IsKnown() {
+			return tftypes.NewValue(v.Type(), nil), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// plannedHash runs ModifyPlan against the created state, with the computed
+	// attributes unknown as they are after a configuration change
+	plannedHash := func() types.String {
+		t.Helper()
+		req := resource.ModifyPlanRequest{
+			Config: tfsdk.Config{Schema: plan.Schema, Raw: configRaw},
+			Plan:   plan,
+			State:  createResp.State,
+		}
+		resp := &resource.ModifyPlanResponse{Plan: plan}
+		r.(resource.ResourceWithModifyPlan).ModifyPlan(ctx, req, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("ModifyPlan() diagnostics: %v", resp.Diagnostics)
+		}
+		var hash types.String
+		resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("input_hash"), &hash)...)
+		return hash
+	}
+
+	if hash := plannedHash(); hash.ValueString() != storedHash {
+		t.Errorf("unchanged inputs planned input_hash %s, want the stored %s", hash, storedHash)
+	}
+
+	if err := os.Remove(outputPath); err != nil {
+		t.Fatal(err)
+	}
+	if hash := plannedHash(); !hash.IsUnknown() {
+		t.Errorf("missing output file planned input_hash %s, want unknown", hash)
+	}
+
+	writeState("i-67890")
+	if hash := plannedHash(); !hash.IsUnknown() {
+		t.Errorf("changed state planned input_hash %s, want unknown", hash)
+	}
+}
+
+func TestDiagramResource_OutputPathStdout(t *testing.T) {
+	ctx := context.Background()
+	r := NewDiagramResource()
+
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	outputPath, ok := schemaResp.Schema.Attributes["output_path"].(schema.StringAttribute)
+	if !ok {
+		t.Fatal("output_path is not a string attribute")
+	}
+
+	// The plugin's stdout is not the user's terminal, so "-" is rejected
+	validate := func(value string) bool {
+		t.Helper()
+		req := validator.StringRequest{Path: path.Root("output_path"), ConfigValue: types.StringValue(value)}
+		for _, v := range outputPath.Validators {
+			resp := &validator.StringResponse{}
+			v.ValidateString(ctx, req, resp)
+			if resp.Diagnostics.HasError() {
+				return false
+			}
+		}
+		return true
+	}
+	if validate(StdoutOutputPath) {
+		t.Errorf("output_path %q passed validation, want an error", StdoutOutputPath)
+	}
+	if !validate("diagram.svg") {
+		t.Error("output_path \"diagram.svg\" failed validation")
+	}
+
+	// A stdout path stored before the validator existed must not force regeneration
+	if r.(*DiagramResource).outputFileMissing(types.StringValue(StdoutOutputPath)) {
+		t.Errorf("outputFileMissing(%q) = true, want false", StdoutOutputPath)
+	}
+}
+
+func TestGenerateErrorSummary(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed to load state from local backend: %w", parser.ErrNoStateFound), "Terraform state not found"},
+		{fmt.Errorf("failed to load state from gcs backend: %w", parser.ErrBackendAuth), "Backend authentication failed"},
+		{errors.Join(errors.New("other"), fmt.Errorf("dir: %w", parser.ErrUnsupportedBackend)), "Unsupported backend"},
+		{fmt.Errorf("failed to parse state file: %w", parser.ErrParse), "Failed to parse Terraform files"},
+		{errors.New("no resources found to diagram"), "Failed to generate diagram"},
+	}
+
+	for _, tt := range tests {
+		if got := generateErrorSummary(tt.err); got != tt.want {
+			t.Errorf("generateErrorSummary(%q) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -24,12 +24,19 @@ type CartographyProvider struct {
 // CartographyProviderModel describes the provider data model.
 type CartographyProviderModel struct {
 	// Authentication credentials for remote backends
-	TerraformToken types.String `tfsdk:"terraform_token"`
-	AWSAccessKey   types.String `tfsdk:"aws_access_key"`
-	AWSSecretKey   types.String `tfsdk:"aws_secret_key"`
-	AzureAccount   types.String `tfsdk:"azure_account"`
-	AzureKey       types.String `tfsdk:"azure_key"`
-	GCPCredentials types.String `tfsdk:"gcp_credentials"`
+	TerraformToken  types.String `tfsdk:"terraform_token"`
+	AWSAccessKey    types.String `tfsdk:"aws_access_key"`
+	AWSSecretKey    types.String `tfsdk:"aws_secret_key"`
+	AWSSessionToken types.String `tfsdk:"aws_session_token"`
+	AWSProfile      types.String `tfsdk:"aws_profile"`
+	AzureAccount    types.String `tfsdk:"azure_account"`
+	AzureKey        types.String `tfsdk:"azure_key"`
+	GCPCredentials  types.String `tfsdk:"gcp_credentials"`
+	HTTPUsername    types.String `tfsdk:"http_username"`
+	HTTPPassword    types.String `tfsdk:"http_password"`
+
+	// Defaults shared by diagram resources and data sources
+	OutputDir types.String `tfsdk:"output_dir"`
 }
 
 func (p *CartographyProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -56,6 +63,15 @@ func (p *CartographyProvider) Schema(ctx context.Context, req provider.SchemaReq
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"aws_session_token": schema.StringAttribute{
+				Description: "AWS session token for temporary S3 backend credentials. Can also be set via AWS_SESSION_TOKEN environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"aws_profile": schema.StringAttribute{
+				Description: "AWS shared config profile for S3 backend. Can also be set via AWS_PROFILE environment variable.",
+				Optional:    true,
+			},
 			"azure_account": schema.StringAttribute{
 				Description: "Azure Storage account name for azurerm backend.",
 				Optional:    true,
@@ -66,10 +82,23 @@ func (p *CartographyProvider) Schema(ctx context.Context, req provider.SchemaReq
 				Sensitive:   true,
 			},
 			"gcp_credentials": schema.StringAttribute{
-				Description: "GCP service account credentials (JSON) for GCS backend. Can also be set via GOOGLE_APPLICATION_CREDENTIALS environment variable.",
+				Description: "GCP credentials (JSON or file path) for GCS backend: a service account key or a workload identity federation credential configuration. Can also be set via GOOGLE_APPLICATION_CREDENTIALS environment variable.",
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"http_username": schema.StringAttribute{
+				Description: "Username for basic authentication against HTTP backends and state_path URLs.",
+				Optional:    true,
+			},
+			"http_password": schema.StringAttribute{
+				Description: "Password for basic authentication against HTTP backends and state_path URLs.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"output_dir": schema.StringAttribute{
+				Description: "Base directory for diagram output. Relative output_path values are resolved against this directory; absolute paths are used as-is.",
+				Optional:    true,
+			},
 		},
 	}
 }
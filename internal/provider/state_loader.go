@@ -13,6 +13,9 @@ import (
 func LoadResources(ctx context.Context, providerConfig *CartographyProviderModel, statePath, configPath types.String) ([]parser.Resource, error) {
 	// Priority 1: If state_path is explicitly provided, use it
 	if !statePath.IsNull() && statePath.ValueString() != "" {
+		if parser.IsStateURL(statePath.ValueString()) {
+			return parser.ParseStateURL(ctx, statePath.ValueString(), newRemoteStateConfig(providerConfig, nil))
+		}
 		return parser.ParseStateFile(ctx, statePath.ValueString())
 	}
 
@@ -28,7 +31,7 @@ func LoadResources(ctx context.Context, providerConfig *CartographyProviderModel
 		}
 
 		// Try to load from backend
-		resources, err := loadFromBackend(ctx, providerConfig, backend)
+		resources, err := loadFromBackend(ctx, providerConfig, backend, nil)
 		if err != nil {
 			// If backend loading fails, fall back to HCL parsing
 			return parser.ParseConfigDirectory(ctx, configDir)
@@ -43,7 +46,7 @@ func LoadResources(ctx context.Context, providerConfig *CartographyProviderModel
 	// Try backend detection in current directory
 	backend, err := parser.ParseBackendConfig(workingDir)
 	if err == nil {
-		resources, err := loadFromBackend(ctx, providerConfig, backend)
+		resources, err := loadFromBackend(ctx, providerConfig, backend, nil)
 		if err == nil {
 			return resources, nil
 		}
@@ -64,8 +67,11 @@ func LoadResources(ctx context.Context, providerConfig *CartographyProviderModel
 	return resources, nil
 }
 
-// loadFromBackend loads resources from a backend configuration
-func loadFromBackend(ctx context.Context, providerConfig *CartographyProviderModel, backend *parser.BackendConfig) ([]parser.Resource, error) {
+// loadFromBackend loads resources from a backend configuration.
+// Overrides replace or add backend settings (e.g. the S3 key) before the state is loaded.
+func loadFromBackend(ctx context.Context, providerConfig *CartographyProviderModel, backend *parser.BackendConfig, overrides map[string]string) ([]parser.Resource, error) {
+	backend = mergeBackendConfig(backend, overrides)
+
 	// For local backend, use file-based loading
 	if parser.BackendType(backend.Type) == parser.BackendTypeLocal {
 		statePath, err := parser.GetStatePath(backend)
@@ -76,33 +82,52 @@ func loadFromBackend(ctx context.Context, providerConfig *CartographyProviderMod
 	}
 
 	// For remote backends, fetch state and parse
+	return parser.LoadStateFromBackend(ctx, newRemoteStateConfig(providerConfig, backend))
+}
+
+// mergeBackendConfig returns a copy of backend with overrides applied to its settings.
+// The original backend is not modified; it is returned as-is when there are no overrides.
+func mergeBackendConfig(backend *parser.BackendConfig, overrides map[string]string) *parser.BackendConfig {
+	if len(overrides) == 0 {
+		return backend
+	}
+
+	merged := *backend
+	merged.Config = make(map[string]interface{}, len(backend.Config)+len(overrides))
+	for key, value := range backend.Config {
+		merged.Config[key] = value
+	}
+	for key, value := range overrides {
+		merged.Config[key] = value
+	}
+
+	return &merged
+}
+
+// newRemoteStateConfig builds the remote state configuration for a backend,
+// carrying over any credentials set in the provider configuration
+func newRemoteStateConfig(providerConfig *CartographyProviderModel, backend *parser.BackendConfig) *parser.RemoteStateConfig {
 	remoteConfig := &parser.RemoteStateConfig{
 		Backend: backend,
 	}
 
-	// Set credentials from provider configuration or environment
-	if providerConfig != nil {
-		if !providerConfig.TerraformToken.IsNull() {
-			remoteConfig.TerraformToken = providerConfig.TerraformToken.ValueString()
-		}
-		if !providerConfig.AWSAccessKey.IsNull() {
-			remoteConfig.AWSAccessKey = providerConfig.AWSAccessKey.ValueString()
-		}
-		if !providerConfig.AWSSecretKey.IsNull() {
-			remoteConfig.AWSSecretKey = providerConfig.AWSSecretKey.ValueString()
-		}
-		if !providerConfig.AzureAccount.IsNull() {
-			remoteConfig.AzureAccount = providerConfig.AzureAccount.ValueString()
-		}
-		if !providerConfig.AzureKey.IsNull() {
-			remoteConfig.AzureKey = providerConfig.AzureKey.ValueString()
-		}
-		if !providerConfig.GCPCredentials.IsNull() {
-			remoteConfig.GCPCredentials = providerConfig.GCPCredentials.ValueString()
-		}
+	if providerConfig == nil {
+		return remoteConfig
 	}
 
-	return parser.LoadStateFromBackend(ctx, remoteConfig)
+	// Set credentials from provider configuration (backend config and environment are resolved by the fetcher)
+	remoteConfig.TerraformToken = providerConfig.TerraformToken.ValueString()
+	remoteConfig.AWSAccessKey = providerConfig.AWSAccessKey.ValueString()
+	remoteConfig.AWSSecretKey = providerConfig.AWSSecretKey.ValueString()
+	remoteConfig.AWSSessionToken = providerConfig.AWSSessionToken.ValueString()
+	remoteConfig.AWSProfile = providerConfig.AWSProfile.ValueString()
+	remoteConfig.AzureAccount = providerConfig.AzureAccount.ValueString()
+	remoteConfig.AzureKey = providerConfig.AzureKey.ValueString()
+	remoteConfig.GCPCredentials = providerConfig.GCPCredentials.ValueString()
+	remoteConfig.HTTPUsername = providerConfig.HTTPUsername.ValueString()
+	remoteConfig.HTTPPassword = providerConfig.HTTPPassword.ValueString()
+
+	return remoteConfig
 }
 
 // ResolveWorkingDirectory resolves the working directory from state_path or config_path
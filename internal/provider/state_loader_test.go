@@ -2,8 +2,12 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ankek/terraform-provider-cartography/internal/parser"
@@ -256,7 +260,7 @@ func TestLoadFromBackend(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			resources, err := loadFromBackend(ctx, nil, tt.backend)
+			resources, err := loadFromBackend(ctx, nil, tt.backend, nil)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("loadFromBackend() error = %v, wantErr %v", err, tt.wantErr)
@@ -270,6 +274,59 @@ func TestLoadFromBackend(t *testing.T) {
 	}
 }
 
+func TestMergeBackendConfig_OverrideS3Key(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	backendContent := `
+terraform {
+  backend "s3" {
+    bucket = "my-terraform-state"
+    key    = "prod/terraform.tfstate"
+    region = "us-east-1"
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "backend.tf"), []byte(backendContent), 0644); err != nil {
+		t.Fatalf("Failed to create backend file: %v", err)
+	}
+
+	backend, err := parser.ParseBackendConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("ParseBackendConfig() error = %v", err)
+	}
+
+	merged := mergeBackendConfig(backend, map[string]string{
+		"key":     "staging/terraform.tfstate",
+		"profile": "staging",
+	})
+
+	remoteConfig := newRemoteStateConfig(nil, merged)
+	if got := remoteConfig.Backend.Config["key"]; got != "staging/terraform.tfstate" {
+		t.Errorf("merged key = %v, want staging/terraform.tfstate", got)
+	}
+	if got := remoteConfig.Backend.Config["profile"]; got != "staging" {
+		t.Errorf("merged profile = %v, want staging", got)
+	}
+	if got := remoteConfig.Backend.Config["bucket"]; got != "my-terraform-state" {
+		t.Errorf("merged bucket = %v, want my-terraform-state", got)
+	}
+	if merged.Type != "s3" {
+		t.Errorf("merged type = %s, want s3", merged.Type)
+	}
+
+	// The parsed backend must be left untouched
+	if got := backend.Config["key"]; got != "prod/terraform.tfstate" {
+		t.Errorf("original key = %v, want prod/terraform.tfstate", got)
+	}
+	if _, ok := backend.Config["profile"]; ok {
+		t.Error("original backend should not gain override keys")
+	}
+
+	if got := mergeBackendConfig(backend, nil); got != backend {
+		t.Error("mergeBackendConfig() without overrides should return the backend unchanged")
+	}
+}
+
 func TestResolveWorkingDirectory(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -387,3 +444,100 @@ func TestLoadResources_ProviderConfig(t *testing.T) {
 		t.Errorf("LoadResources() got %d resources, want 1", len(resources))
 	}
 }
+
+func TestNewRemoteStateConfig(t *testing.T) {
+	backend := &parser.BackendConfig{Type: "s3", Config: map[string]interface{}{}}
+
+	remoteConfig := newRemoteStateConfig(&CartographyProviderModel{
+		TerraformToken:  types.StringValue("tfe-token"),
+		AWSAccessKey:    types.StringValue("access"),
+		AWSSecretKey:    types.StringValue("secret"),
+		AWSSessionToken: types.StringValue("session"),
+		AWSProfile:      types.StringValue("prod"),
+		AzureAccount:    types.StringValue("account"),
+		AzureKey:        types.StringValue("azure-key"),
+		GCPCredentials:  types.StringValue("{}"),
+	}, backend)
+
+	if remoteConfig.Backend != backend {
+		t.Error("newRemoteStateConfig() did not keep backend")
+	}
+	if remoteConfig.TerraformToken != "tfe-token" {
+		t.Errorf("TerraformToken = %q, want %q", remoteConfig.TerraformToken, "tfe-token")
+	}
+	if remoteConfig.AWSSessionToken != "session" || remoteConfig.AWSProfile != "prod" {
+		t.Errorf("AWS session/profile = %q/%q, want session/prod", remoteConfig.AWSSessionToken, remoteConfig.AWSProfile)
+	}
+	if remoteConfig.AzureAccount != "account" || remoteConfig.AzureKey != "azure-key" {
+		t.Errorf("Azure account/key = %q/%q, want account/azure-key", remoteConfig.AzureAccount, remoteConfig.AzureKey)
+	}
+	if remoteConfig.GCPCredentials != "{}" {
+		t.Errorf("GCPCredentials = %q, want {}", remoteConfig.GCPCredentials)
+	}
+
+	// Nil provider config leaves credentials empty
+	if empty := newRemoteStateConfig(nil, backend); empty.TerraformToken != "" {
+		t.Errorf("TerraformToken = %q, want empty", empty.TerraformToken)
+	}
+}
+
+func TestRemoteBackend_UsesProviderToken(t *testing.T) {
+	t.Setenv("TFE_TOKEN", "")
+
+	stateContent := `{
+		"version": 4,
+		"terraform_version": "1.0.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{
+						"mode": "managed",
+						"type": "aws_instance",
+						"name": "web",
+						"provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+						"instances": [{"attributes": {"id": "i-12345"}}]
+					}
+				]
+			}
+		}
+	}`
+
+	// Mock Terraform Cloud API that only accepts the provider-supplied token
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer provider-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v2/organizations/acme/workspaces/prod":
+			fmt.Fprint(w, `{"data":{"relationships":{"current-state-version":{"data":{"id":"sv-1"}}}}}`)
+		case "/api/v2/state-versions/sv-1/download":
+			fmt.Fprint(w, stateContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend := &parser.BackendConfig{
+		Type: "remote",
+		Config: map[string]interface{}{
+			"hostname":     strings.TrimPrefix(server.URL, "https://"),
+			"organization": "acme",
+			"workspaces":   map[string]interface{}{"name": "prod"},
+		},
+	}
+
+	remoteConfig := newRemoteStateConfig(&CartographyProviderModel{
+		TerraformToken: types.StringValue("provider-token"),
+	}, backend)
+	remoteConfig.HTTPClient = server.Client()
+
+	resources, err := parser.LoadStateFromBackend(context.Background(), remoteConfig)
+	if err != nil {
+		t.Fatalf("LoadStateFromBackend() error = %v", err)
+	}
+	if len(resources) != 1 {
+		t.Errorf("LoadStateFromBackend() got %d resources, want 1", len(resources))
+	}
+}
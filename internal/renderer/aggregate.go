@@ -0,0 +1,150 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+)
+
+// aggregateIdenticalNodes replaces each group of identical siblings (nodes of the same
+// provider and type connected to exactly the same neighbors by the same relationships)
+// with a single counted node, e.g. "droplet ×50". Edges to the group are rewired to the
+// aggregate, and each merged edge records the number of edges it stands for in
+// Metadata["count"]. Nodes without edges are never aggregated. A new graph is returned when any
+// group is found; otherwise g is returned unchanged. g is never modified.
+func aggregateIdenticalNodes(g *graph.Graph) *graph.Graph {
+	signatures := make(map[string][]string)
+	for _, edge := range g.Edges {
+		if edge.From.ID == edge.To.ID {
+			continue
+		}
+		signatures[edge.From.ID] = append(signatures[edge.From.ID], "out|"+edge.Relationship+"|"+edge.To.ID)
+		signatures[edge.To.ID] = append(signatures[edge.To.ID], "in|"+edge.Relationship+"|"+edge.From.ID)
+	}
+
+	byKey := make(map[string][]*graph.Node)
+	for id, sig := range signatures {
+		node := g.Nodes[id]
+		if node == nil {
+			continue
+		}
+		sort.Strings(sig)
+		key := node.Provider + "\x00" + node.Type + "\x00" + strings.Join(sig, "\x00")
+		byKey[key] = append(byKey[key], node)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key, members := range byKey {
+		if len(members) >= 2 {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return g
+	}
+	sort.Strings(keys)
+
+	// Map every aggregated node to the node standing in for its group
+	replacements := make(map[string]*graph.Node)
+	result := &graph.Graph{
+		Nodes: make(map[string]*graph.Node, len(g.Nodes)),
+		Edges: make([]*graph.Edge, 0, len(g.Edges)),
+	}
+	for _, key := range keys {
+		members := byKey[key]
+		sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+
+		aggregate := newIdenticalAggregateNode(members)
+		result.Nodes[aggregate.ID] = aggregate
+		for _, member := range members {
+			replacements[member.ID] = aggregate
+		}
+	}
+
+	// Shallow-copy the remaining nodes so edge lists can be rebuilt without touching g
+	for id, node := range g.Nodes {
+		if replacements[id] != nil {
+			continue
+		}
+		nodeCopy := *node
+		nodeCopy.Edges = make([]*graph.Edge, 0, len(node.Edges))
+		result.Nodes[id] = &nodeCopy
+	}
+
+	resolve := func(node *graph.Node) *graph.Node {
+		if aggregate := replacements[node.ID]; aggregate != nil {
+			return aggregate
+		}
+		return result.Nodes[node.ID]
+	}
+
+	// Rewire edges; the members' identical edges collapse into one per neighbor
+	merged := make(map[string]*graph.Edge)
+	for _, edge := range g.Edges {
+		from, to := resolve(edge.From), resolve(edge.To)
+		key := from.ID + "|" + to.ID + "|" + edge.Relationship
+		if existing := merged[key]; existing != nil {
+			existing.Metadata = withEdgeCount(existing.Metadata, edgeCount(existing)+edgeCount(edge))
+			continue
+		}
+
+		edgeCopy := &graph.Edge{
+			From:         from,
+			To:           to,
+			Relationship: edge.Relationship,
+			Metadata:     edge.Metadata,
+		}
+		merged[key] = edgeCopy
+		result.Edges = append(result.Edges, edgeCopy)
+		from.Edges = append(from.Edges, edgeCopy)
+	}
+
+	return result
+}
+
+// edgeCount returns the number of connections an edge stands for: its Metadata["count"]
+// when it merges several, otherwise 1
+func edgeCount(edge *graph.Edge) int {
+	if count, err := strconv.Atoi(edge.Metadata["count"]); err == nil && count > 0 {
+		return count
+	}
+	return 1
+}
+
+// withEdgeCount returns a copy of metadata with "count" set, leaving the original (which
+// may be shared with the source graph) untouched
+func withEdgeCount(metadata map[string]string, count int) map[string]string {
+	result := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		result[k] = v
+	}
+	result["count"] = strconv.Itoa(count)
+	return result
+}
+
+// newIdenticalAggregateNode creates the counted node standing in for identical members.
+// It is labeled with the members' shared name (count instances all share one), or with
+// the resource type name when the names differ.
+func newIdenticalAggregateNode(members []*graph.Node) *graph.Node {
+	first := members[0]
+	name := first.Name
+	for _, member := range members[1:] {
+		if member.Name != name {
+			name = strings.ToLower(getResourceTypeName(first.Type))
+			break
+		}
+	}
+
+	return &graph.Node{
+		ID:           first.ID + ".aggregate",
+		Type:         first.Type,
+		Name:         fmt.Sprintf("%s ×%d", name, len(members)),
+		Provider:     first.Provider,
+		ResourceType: first.ResourceType,
+		Attributes:   map[string]interface{}{},
+		Edges:        make([]*graph.Edge, 0),
+	}
+}
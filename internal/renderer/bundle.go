@@ -0,0 +1,105 @@
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+)
+
+// minBundledSources is the number of sources whose edges enter the same side of a node
+// from which LayoutOptions.BundleSharedTargets routes them through a shared junction
+const minBundledSources = 3
+
+// junctionDistance is how far in front of a target's entry point bundled edges meet
+const junctionDistance = 40.0
+
+// findJunctions returns the junction of every edge entering the same side of its target
+// as the edges of at least minBundledSources-1 other sources, placed junctionDistance in
+// front of the middle of that side
+func (er *EdgeRouter) findJunctions(g *graph.Graph) map[*graph.Edge]Point {
+	type entry struct {
+		target string
+		point  Point
+	}
+	groups := make(map[entry][]*graph.Edge)
+	sources := make(map[entry]map[string]bool)
+	for _, edge := range g.Edges {
+		fromNode := er.layout.Nodes[edge.From.ID]
+		toNode := er.layout.Nodes[edge.To.ID]
+		if fromNode == nil || toNode == nil || fromNode == toNode {
+			continue
+		}
+
+		_, end := er.getConnectionPointsWithOffset(fromNode, toNode, 0, 0)
+		key := entry{target: edge.To.ID, point: end}
+		groups[key] = append(groups[key], edge)
+		if sources[key] == nil {
+			sources[key] = make(map[string]bool)
+		}
+		sources[key][edge.From.ID] = true
+	}
+
+	junctions := make(map[*graph.Edge]Point)
+	for key, edges := range groups {
+		if len(sources[key]) < minBundledSources {
+			continue
+		}
+		junction := junctionPoint(er.layout.Nodes[key.target], key.point)
+		for _, edge := range edges {
+			junctions[edge] = junction
+		}
+	}
+	return junctions
+}
+
+// junctionPoint returns the point junctionDistance away from node in front of entry,
+// an entry point on one of its sides
+func junctionPoint(node *NodeLayout, entry Point) Point {
+	switch {
+	case entry.Y < node.Position.Y:
+		return Point{X: entry.X, Y: entry.Y - junctionDistance}
+	case entry.Y > node.Position.Y+node.Height:
+		return Point{X: entry.X, Y: entry.Y + junctionDistance}
+	case entry.X < node.Position.X:
+		return Point{X: entry.X - junctionDistance, Y: entry.Y}
+	default:
+		return Point{X: entry.X + junctionDistance, Y: entry.Y}
+	}
+}
+
+// routeThroughJunction routes an edge to junction in the router's edge style, then along
+// the trunk it shares with the other bundled edges into the middle of the target's side
+func (er *EdgeRouter) routeThroughJunction(from, to *NodeLayout, junction Point, sourceOffset float64) []Point {
+	start, end := er.getConnectionPointsWithOffset(from, to, sourceOffset, 0)
+
+	var branch []Point
+	switch er.edgeStyle {
+	case EdgeStyleStraight:
+		branch = er.routeStraightWithOffset(start, junction, 0)
+	case EdgeStyleOrthogonal:
+		branch = er.routeOrthogonal(start, junction, 0, from, to)
+	default:
+		branch = er.routeCurvedWithOffset(start, junction, 0)
+	}
+	if len(branch) < 3 {
+		// Paths of 3 points are drawn as a curve bending toward the middle one, which
+		// would miss the junction; go through the middle of the branch instead
+		middle := Point{X: (start.X + junction.X) / 2, Y: (start.Y + junction.Y) / 2}
+		branch = []Point{start, middle, junction}
+	}
+
+	return append(branch, end)
+}
+
+// renderJunctions draws a dot at every junction shared by bundled edges
+func (r *SVGRenderer) renderJunctions(edges []*EdgeLayout, padding float64) {
+	drawn := make(map[Point]bool)
+	for _, edge := range edges {
+		if edge.Junction == nil || drawn[*edge.Junction] {
+			continue
+		}
+		drawn[*edge.Junction] = true
+		r.buf.WriteString(fmt.Sprintf(`<circle class="junction" cx="%.2f" cy="%.2f" r="4" fill="#495057"/>
+`, edge.Junction.X+padding, edge.Junction.Y+padding))
+	}
+}
@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
@@ -38,6 +39,73 @@ func getAccentColor(node *graph.Node) string {
 	}
 }
 
+// neutralTint is the cluster tint for providers without a brand color
+const neutralTint = "#ADB5BD"
+
+// providerTints maps provider names to brand colors used to tint provider clusters
+var providerTints = map[string]string{
+	"aws":          "#FF9900", // AWS Orange
+	"azure":        "#0078D4", // Azure Blue
+	"gcp":          "#4285F4", // Google Blue (primary of the multi-color brand)
+	"digitalocean": "#0080FF", // DigitalOcean Blue
+	"alicloud":     "#FF6A00", // Alibaba Cloud Orange
+}
+
+// getProviderTint returns the brand tint for a provider cluster
+func getProviderTint(provider string) string {
+	if tint, ok := providerTints[provider]; ok {
+		return tint
+	}
+	return neutralTint
+}
+
+// noRegionColor is the node color for resources without a region with RenderOptions.ColorByRegion
+const noRegionColor = "#ADB5BD"
+
+// groupPalette holds distinct colors assigned to regions or tag values in sorted order;
+// it repeats when a diagram spans more groups than colors
+var groupPalette = []string{
+	"#1E88E5", // Blue
+	"#E53935", // Red
+	"#43A047", // Green
+	"#FB8C00", // Orange
+	"#8E24AA", // Purple
+	"#00ACC1", // Cyan
+	"#FDD835", // Yellow
+	"#6D4C41", // Brown
+	"#D81B60", // Pink
+	"#3949AB", // Indigo
+}
+
+// assignRegionColors maps each distinct region in g to a color from groupPalette.
+// Regions are sorted first so the same set of regions always gets the same colors.
+func assignRegionColors(g *graph.Graph) map[string]string {
+	return assignGroupColors(g, nodeRegion)
+}
+
+// assignGroupColors maps each distinct non-empty group of the nodes in g to a color
+// from groupPalette, in sorted group order
+func assignGroupColors(g *graph.Graph, groupOf func(*graph.Node) string) map[string]string {
+	seen := make(map[string]bool)
+	for _, node := range g.Nodes {
+		if group := groupOf(node); group != "" {
+			seen[group] = true
+		}
+	}
+
+	groups := make([]string, 0, len(seen))
+	for group := range seen {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	colors := make(map[string]string, len(groups))
+	for i, group := range groups {
+		colors[group] = groupPalette[i%len(groupPalette)]
+	}
+	return colors
+}
+
 // lightenColor lightens a hex color by a percentage
 func lightenColor(hexColor string, percent int) string {
 	// Parse hex color
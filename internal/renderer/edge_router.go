@@ -2,18 +2,29 @@ package renderer
 
 import (
 	"math"
+	"sort"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
 )
 
 // EdgeRouter handles intelligent edge routing to prevent overlaps
 type EdgeRouter struct {
-	layout    *Layout
-	edges     []*EdgeRoute
-	nodeWidth float64
+	layout     *Layout
+	edges      []*EdgeRoute
+	nodeWidth  float64
 	nodeHeight float64
+	edgeStyle  string // One of the EdgeStyle* constants; empty means EdgeStyleCurved
+	simple     bool   // Draw curves as lines of at most 3 points (see LayoutOptions.SimpleEdgeThreshold)
+	bundle     bool   // Route fan-in through shared junctions (see LayoutOptions.BundleSharedTargets)
 }
 
+// Edge routing strategies selectable through RenderOptions.EdgeStyle
+const (
+	EdgeStyleCurved     = "curved"     // Choose per edge: straight, orthogonal, or curved (default)
+	EdgeStyleStraight   = "straight"   // Always draw direct lines
+	EdgeStyleOrthogonal = "orthogonal" // Always draw right-angle paths
+)
+
 // EdgeRoute represents a routed edge with multiple segments
 type EdgeRoute struct {
 	edge     *graph.Edge
@@ -35,7 +46,39 @@ func NewEdgeRouter(layout *Layout, nodeWidth, nodeHeight float64) *EdgeRouter {
 		edges:      make([]*EdgeRoute, 0),
 		nodeWidth:  nodeWidth,
 		nodeHeight: nodeHeight,
+		edgeStyle:  EdgeStyleCurved,
+	}
+}
+
+// DefaultSimpleEdgeThreshold is the number of edges above which curved edges are drawn
+// as simple lines unless LayoutOptions.SimpleEdgeThreshold says otherwise
+const DefaultSimpleEdgeThreshold = 500
+
+// newLayoutRouter creates an edge router for a layout of a graph with edgeCount edges,
+// applying the edge style, simple edge threshold and edge bundling of opts
+func newLayoutRouter(layout *Layout, nodeWidth, nodeHeight float64, opts LayoutOptions, edgeCount int) *EdgeRouter {
+	router := NewEdgeRouter(layout, nodeWidth, nodeHeight)
+	if opts.EdgeStyle != "" {
+		router.edgeStyle = opts.EdgeStyle
+	}
+
+	threshold := opts.SimpleEdgeThreshold
+	if threshold == 0 {
+		threshold = DefaultSimpleEdgeThreshold
 	}
+	router.simple = threshold > 0 && edgeCount > threshold
+	router.bundle = opts.BundleSharedTargets
+
+	return router
+}
+
+// validEdgeStyle reports whether style is a supported edge style (empty selects the default)
+func validEdgeStyle(style string) bool {
+	switch style {
+	case "", EdgeStyleCurved, EdgeStyleStraight, EdgeStyleOrthogonal:
+		return true
+	}
+	return false
 }
 
 // RouteEdges routes all edges to avoid overlaps
@@ -43,10 +86,24 @@ func (er *EdgeRouter) RouteEdges(g *graph.Graph) []*EdgeLayout {
 	// First pass: identify parallel edges and assign offsets
 	er.identifyParallelEdges(g)
 
-	// Group edges by target node for connection point distribution
+	// Group edges by target and source node for connection point distribution
 	edgesByTarget := make(map[string][]*graph.Edge)
+	edgesBySource := make(map[string][]*graph.Edge)
 	for _, edge := range g.Edges {
 		edgesByTarget[edge.To.ID] = append(edgesByTarget[edge.To.ID], edge)
+		edgesBySource[edge.From.ID] = append(edgesBySource[edge.From.ID], edge)
+	}
+	// Order each source's exit points like its targets, so the fanned-out edges don't cross
+	for _, edges := range edgesBySource {
+		sort.SliceStable(edges, func(i, j int) bool {
+			return er.crossAxisCenter(edges[i].To.ID) < er.crossAxisCenter(edges[j].To.ID)
+		})
+	}
+
+	// Edges converging on one side of a target share a junction and a trunk into it
+	var junctions map[*graph.Edge]Point
+	if er.bundle {
+		junctions = er.findJunctions(g)
 	}
 
 	// Second pass: route each edge avoiding overlaps
@@ -69,29 +126,21 @@ func (er *EdgeRouter) RouteEdges(g *graph.Graph) []*EdgeLayout {
 			}
 		}
 
-		// Calculate connection point offset if multiple edges target same node
-		connectionOffset := 0.0
-		targetEdges := edgesByTarget[edge.To.ID]
-		if len(targetEdges) > 1 {
-			// Find this edge's index among edges to same target
-			edgeIndex := -1
-			for i, e := range targetEdges {
-				if e == edge {
-					edgeIndex = i
-					break
-				}
-			}
-			if edgeIndex >= 0 {
-				// Distribute connection points across the target node's top edge
-				// Center the distribution around the middle
-				spacing := 30.0 // pixels between connection points
-				totalWidth := float64(len(targetEdges)-1) * spacing
-				connectionOffset = (float64(edgeIndex) * spacing) - (totalWidth / 2.0)
-			}
+		// Distribute connection points when multiple edges target or leave the same node
+		connectionOffset := distributedOffset(edgesByTarget[edge.To.ID], edge)
+		sourceOffset := distributedOffset(edgesBySource[edge.From.ID], edge)
+
+		if junction, ok := junctions[edge]; ok {
+			layouts = append(layouts, &EdgeLayout{
+				Edge:     edge,
+				Points:   er.routeThroughJunction(fromNode, toNode, junction, sourceOffset),
+				Junction: &junction,
+			})
+			continue
 		}
 
-		// Route the edge with both offsets
-		points := er.routeEdgeWithConnection(fromNode, toNode, offset, connectionOffset)
+		// Route the edge with all offsets
+		points := er.routeEdgeWithConnection(fromNode, toNode, offset, sourceOffset, connectionOffset)
 
 		layouts = append(layouts, &EdgeLayout{
 			Edge:   edge,
@@ -102,6 +151,36 @@ func (er *EdgeRouter) RouteEdges(g *graph.Graph) []*EdgeLayout {
 	return layouts
 }
 
+// distributedOffset returns the offset of edge's connection point from the middle of
+// a node side shared by edges, spreading the points evenly around the middle
+func distributedOffset(edges []*graph.Edge, edge *graph.Edge) float64 {
+	if len(edges) < 2 {
+		return 0
+	}
+
+	for i, e := range edges {
+		if e == edge {
+			spacing := 30.0 // pixels between connection points
+			totalWidth := float64(len(edges)-1) * spacing
+			return (float64(i) * spacing) - (totalWidth / 2.0)
+		}
+	}
+	return 0
+}
+
+// crossAxisCenter returns the center of a laid-out node across the layout direction:
+// its X for vertical layouts, its Y for horizontal ones
+func (er *EdgeRouter) crossAxisCenter(id string) float64 {
+	node := er.layout.Nodes[id]
+	if node == nil {
+		return 0
+	}
+	if er.layout.Direction == "LR" || er.layout.Direction == "RL" {
+		return node.Position.Y + node.Height/2
+	}
+	return node.Position.X + node.Width/2
+}
+
 // identifyParallelEdges finds edges that connect the same nodes and assigns offsets
 func (er *EdgeRouter) identifyParallelEdges(g *graph.Graph) {
 	// Group edges by node pairs (considering both directions as same connection)
@@ -137,10 +216,19 @@ func (er *EdgeRouter) identifyParallelEdges(g *graph.Graph) {
 	}
 }
 
-// routeEdgeWithConnection routes a single edge with path offset and connection point offset
-func (er *EdgeRouter) routeEdgeWithConnection(from, to *NodeLayout, pathOffset, connectionOffset float64) []Point {
-	// Determine connection points based on direction with connection offset
-	startPoint, endPoint := er.getConnectionPointsWithOffset(from, to, connectionOffset)
+// routeEdgeWithConnection routes a single edge with path offset and source and target
+// connection point offsets
+func (er *EdgeRouter) routeEdgeWithConnection(from, to *NodeLayout, pathOffset, sourceOffset, connectionOffset float64) []Point {
+	// Determine connection points based on direction with connection offsets
+	startPoint, endPoint := er.getConnectionPointsWithOffset(from, to, sourceOffset, connectionOffset)
+
+	// A fixed edge style overrides the per-edge heuristics below
+	switch er.edgeStyle {
+	case EdgeStyleStraight:
+		return er.routeStraightWithOffset(startPoint, endPoint, pathOffset)
+	case EdgeStyleOrthogonal:
+		return er.routeOrthogonal(startPoint, endPoint, pathOffset, from, to)
+	}
 
 	// Calculate distance and angle
 	dx := endPoint.X - startPoint.X
@@ -168,8 +256,9 @@ func (er *EdgeRouter) routeEdgeWithConnection(from, to *NodeLayout, pathOffset,
 	return er.routeCurvedWithOffset(startPoint, endPoint, pathOffset)
 }
 
-// getConnectionPointsWithOffset determines connection points with horizontal offset for the target
-func (er *EdgeRouter) getConnectionPointsWithOffset(from, to *NodeLayout, connectionOffset float64) (Point, Point) {
+// getConnectionPointsWithOffset determines connection points, offset along the exit side
+// of the source and the entry side of the target
+func (er *EdgeRouter) getConnectionPointsWithOffset(from, to *NodeLayout, sourceOffset, connectionOffset float64) (Point, Point) {
 	var startPoint, endPoint Point
 
 	// Calculate centers
@@ -194,12 +283,12 @@ func (er *EdgeRouter) getConnectionPointsWithOffset(from, to *NodeLayout, connec
 		// Vertical layout - prefer top/bottom connections
 		if to.Position.Y > from.Position.Y+from.Height {
 			// To is below From - connect from bottom to top with clearance
-			// Apply horizontal offset to target connection point
-			startPoint = Point{X: fromCenter.X, Y: from.Position.Y + from.Height}
+			// Apply horizontal offsets to both connection points
+			startPoint = Point{X: fromCenter.X + sourceOffset, Y: from.Position.Y + from.Height}
 			endPoint = Point{X: toCenter.X + connectionOffset, Y: to.Position.Y - arrowClearance}
 		} else if to.Position.Y+to.Height < from.Position.Y {
 			// To is above From - connect from top to bottom with clearance
-			startPoint = Point{X: fromCenter.X, Y: from.Position.Y}
+			startPoint = Point{X: fromCenter.X + sourceOffset, Y: from.Position.Y}
 			endPoint = Point{X: toCenter.X + connectionOffset, Y: to.Position.Y + to.Height + arrowClearance}
 		} else {
 			// Side-by-side - use side connections with clearance
@@ -215,20 +304,20 @@ func (er *EdgeRouter) getConnectionPointsWithOffset(from, to *NodeLayout, connec
 	case "LR", "RL":
 		// Horizontal layout - prefer left/right connections
 		if to.Position.X > from.Position.X+from.Width {
-			// To is right of From - add clearance and vertical offset
-			startPoint = Point{X: from.Position.X + from.Width, Y: fromCenter.Y}
+			// To is right of From - add clearance and vertical offsets
+			startPoint = Point{X: from.Position.X + from.Width, Y: fromCenter.Y + sourceOffset}
 			endPoint = Point{X: to.Position.X - arrowClearance, Y: toCenter.Y + connectionOffset}
 		} else if to.Position.X+to.Width < from.Position.X {
-			// To is left of From - add clearance and vertical offset
-			startPoint = Point{X: from.Position.X, Y: fromCenter.Y}
+			// To is left of From - add clearance and vertical offsets
+			startPoint = Point{X: from.Position.X, Y: fromCenter.Y + sourceOffset}
 			endPoint = Point{X: to.Position.X + to.Width + arrowClearance, Y: toCenter.Y + connectionOffset}
 		} else {
 			// Stacked - use top/bottom connections with clearance and horizontal offset
 			if toCenter.Y > fromCenter.Y {
-				startPoint = Point{X: fromCenter.X, Y: from.Position.Y + from.Height}
+				startPoint = Point{X: fromCenter.X + sourceOffset, Y: from.Position.Y + from.Height}
 				endPoint = Point{X: toCenter.X + connectionOffset, Y: to.Position.Y - arrowClearance}
 			} else {
-				startPoint = Point{X: fromCenter.X, Y: from.Position.Y}
+				startPoint = Point{X: fromCenter.X + sourceOffset, Y: from.Position.Y}
 				endPoint = Point{X: toCenter.X + connectionOffset, Y: to.Position.Y + to.Height + arrowClearance}
 			}
 		}
@@ -320,8 +409,12 @@ func (er *EdgeRouter) routeOrthogonal(start, end Point, offset float64, from, to
 	return points
 }
 
-// routeCurvedWithOffset creates a curved path with offset for parallel edges
+// routeCurvedWithOffset creates a curved path with offset for parallel edges.
+// Simple routers draw a straight line instead, bent at its middle by the offset.
 func (er *EdgeRouter) routeCurvedWithOffset(start, end Point, offset float64) []Point {
+	if er.simple {
+		return er.routeStraightWithOffset(start, end, offset)
+	}
 	if offset == 0 {
 		// No offset - use standard Bezier curve
 		return er.generateBezierCurve(start, end)
@@ -379,6 +472,10 @@ func (er *EdgeRouter) routeCurvedWithOffset(start, end Point, offset float64) []
 func (er *EdgeRouter) routeCurvedAvoidance(start, end Point, offset float64, from, to *NodeLayout) []Point {
 	// Find intermediate waypoint to avoid nodes
 	waypoint := er.findAvoidanceWaypoint(start, end, from, to)
+	if er.simple {
+		// Drawn as a single quadratic curve bending toward the waypoint
+		return []Point{start, waypoint, end}
+	}
 
 	// Create two curves: start->waypoint and waypoint->end
 	curve1 := er.routeCurvedWithOffset(start, waypoint, offset)
@@ -6,10 +6,161 @@ import (
 	"strings"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
+	"github.com/ankek/terraform-provider-cartography/internal/validation"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-// ExportDiagram exports a diagram in SVG format with context support
+// ExportDiagram exports a diagram in the format of opts.Format (SVG, PNG or JPEG) with
+// context support
 func ExportDiagram(ctx context.Context, g *graph.Graph, outputPath string, opts RenderOptions) error {
+	data, err := renderGraph(ctx, g, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outputPath, data, opts)
+}
+
+// ExportDiagramWithLayout exports a diagram in the format of opts.Format using a layout precomputed by
+// ComputeLayout, so the same graph can be rendered repeatedly without laying it out again.
+// The layout must come from the same graph and layout options (Direction, EdgeStyle,
+// MaxLayers, ShowBadges, NestContainers, Compact, LayoutAlgorithm, LayoutCenter); AggregateIdentical, FilterByTag and MaxNodes are not applied since
+// the layout fixes the drawn nodes.
+func ExportDiagramWithLayout(ctx context.Context, g *graph.Graph, layout *Layout, outputPath string, opts RenderOptions) error {
+	if err := checkRender(ctx, opts); err != nil {
+		return err
+	}
+
+	data, err := renderLayout(layout, g, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(outputPath, data, opts)
+}
+
+// writeOutput writes rendered data to outputPath, creating its parent directory
+// unless opts.SkipCreateDirs is set
+func writeOutput(outputPath string, data []byte, opts RenderOptions) error {
+	if !opts.SkipCreateDirs {
+		if err := validation.EnsureOutputDir(outputPath); err != nil {
+			return err
+		}
+	}
+
+	return writeFile(outputPath, data)
+}
+
+// ComputeLayout positions the nodes and routes the edges of g using the layout-related
+// fields of opts. The result can be passed to ExportDiagramWithLayout any number of times.
+func ComputeLayout(g *graph.Graph, opts RenderOptions) *Layout {
+	layoutOpts := LayoutOptions{
+		EdgeStyle:           opts.EdgeStyle,
+		MaxLayers:           opts.MaxLayers,
+		NestContainers:      opts.NestContainers,
+		LaneBy:              opts.LaneBy,
+		SimpleEdgeThreshold: opts.SimpleEdgeThreshold,
+		BundleSharedTargets: opts.BundleSharedTargets,
+	}
+
+	// Calculate layout with improved algorithm (prevents overlaps, adds curves)
+	nodeWidth := 220.0   // Slightly wider for better visibility
+	nodeHeight := 160.0  // Taller for better icon display
+	horizontalSpacing := 140.0  // More space between nodes
+	verticalSpacing := 120.0    // More vertical space
+	if opts.Compact {
+		nodeWidth, nodeHeight = compactNodeWidth, compactNodeHeight
+		horizontalSpacing, verticalSpacing = compactSpacing, compactSpacing
+	} else if opts.ShowBadges {
+		nodeHeight += badgeHeight + 4 // Room for the badge below the type label
+	}
+
+	if opts.LayoutAlgorithm == LayoutAlgorithmRadial {
+		return CalculateRadialLayout(g, opts.LayoutCenter, nodeWidth, nodeHeight, horizontalSpacing, layoutOpts)
+	}
+	return CalculateImprovedLayoutWithOptions(g, opts.Direction, nodeWidth, nodeHeight, horizontalSpacing, verticalSpacing, layoutOpts)
+}
+
+// GenerateFromResources builds a graph from resources and renders it in memory,
+// returning the diagram bytes in the requested format without touching the filesystem.
+func GenerateFromResources(ctx context.Context, resources []parser.Resource, opts RenderOptions) ([]byte, error) {
+	g := graph.BuildGraph(ctx, resources)
+
+	return renderGraph(ctx, g, opts)
+}
+
+// renderGraph lays out and renders a graph to bytes in the requested format
+func renderGraph(ctx context.Context, g *graph.Graph, opts RenderOptions) ([]byte, error) {
+	data, _, err := renderGraphWithLayout(ctx, g, opts)
+	return data, err
+}
+
+// renderGraphWithLayout lays out and renders a graph, returning the layout it was drawn from
+func renderGraphWithLayout(ctx context.Context, g *graph.Graph, opts RenderOptions) ([]byte, *Layout, error) {
+	if err := checkRender(ctx, opts); err != nil {
+		return nil, nil, err
+	}
+
+	g, layout, err := layoutGraph(ctx, g, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := renderLayout(layout, g, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	rendererName := "svg"
+	if isRasterFormat(opts.Format) {
+		rendererName = "raster"
+	}
+	opts.logger().DebugContext(ctx, "diagram rendered", "renderer", rendererName, "bytes", len(data))
+
+	return data, layout, nil
+}
+
+// layoutGraph applies the tag filter, aggregation and node limit to a graph and lays out
+// the result, returning the graph to draw along with its layout
+func layoutGraph(ctx context.Context, g *graph.Graph, opts RenderOptions) (*graph.Graph, *Layout, error) {
+	// Filter, aggregate and enforce the node limit before layout, which dominates
+	// rendering time for large graphs
+	if len(opts.FilterByTag) > 0 {
+		g = g.Filter(func(node *graph.Node) bool { return matchesTags(node, opts.FilterByTag) })
+	}
+	if opts.AggregateIdentical {
+		g = aggregateIdenticalNodes(g)
+	}
+	g, err := applyNodeLimit(g, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.ShowOutputs && len(opts.Outputs) > 0 {
+		g = withOutputNodes(g, opts.Outputs)
+	}
+	if opts.LayoutAlgorithm == LayoutAlgorithmRadial && g.Nodes[opts.LayoutCenter] == nil {
+		return nil, nil, fmt.Errorf("layout center %q not found in diagram", opts.LayoutCenter)
+	}
+
+	layout := ComputeLayout(g, opts)
+	opts.logger().DebugContext(ctx, "layout computed",
+		"nodes", len(layout.Nodes),
+		"edges", len(layout.Edges),
+		"width", layout.Width,
+		"height", layout.Height,
+		"direction", layout.Direction,
+	)
+	if layout.Truncated {
+		tflog.Warn(ctx, "Diagram exceeds the layer limit; deeper resources share the last layer", map[string]interface{}{
+			"max_layers": opts.MaxLayers,
+		})
+	}
+
+	return g, layout, nil
+}
+
+// checkRender fails if the context is done or the options cannot be rendered
+func checkRender(ctx context.Context, opts RenderOptions) error {
 	format := strings.ToLower(opts.Format)
 
 	// Check context before starting
@@ -19,25 +170,81 @@ func ExportDiagram(ctx context.Context, g *graph.Graph, outputPath string, opts
 	default:
 	}
 
-	// Only SVG format is supported
-	if format != "svg" {
-		return fmt.Errorf("unsupported format: %s (only SVG is supported)", format)
+	if format != "svg" && !isRasterFormat(format) {
+		return fmt.Errorf("unsupported format: %s (must be svg, png, jpg, or jpeg)", format)
 	}
 
-	// Calculate layout with improved algorithm (prevents overlaps, adds curves)
-	nodeWidth := 220.0   // Slightly wider for better visibility
-	nodeHeight := 160.0  // Taller for better icon display
-	horizontalSpacing := 140.0  // More space between nodes
-	verticalSpacing := 120.0    // More vertical space
+	return checkRenderOptions(opts)
+}
+
+// checkRenderOptions fails if the layout or styling options are invalid, whatever the
+// output format
+func checkRenderOptions(opts RenderOptions) error {
+	if !validEdgeStyle(opts.EdgeStyle) {
+		return fmt.Errorf("unsupported edge style: %s (must be %q, %q, or %q)", opts.EdgeStyle, EdgeStyleCurved, EdgeStyleStraight, EdgeStyleOrthogonal)
+	}
+
+	if !validLayoutAlgorithm(opts.LayoutAlgorithm) {
+		return fmt.Errorf("unsupported layout algorithm: %s (must be %q or %q)", opts.LayoutAlgorithm, LayoutAlgorithmLayered, LayoutAlgorithmRadial)
+	}
+	if opts.LayoutAlgorithm == LayoutAlgorithmRadial && opts.LayoutCenter == "" {
+		return fmt.Errorf("radial layout requires a layout center")
+	}
+
+	if (opts.HighlightPath[0] == "") != (opts.HighlightPath[1] == "") {
+		return fmt.Errorf("highlight path requires two node IDs, got %q and %q", opts.HighlightPath[0], opts.HighlightPath[1])
+	}
+
+	if !validLaneBy(opts.LaneBy) {
+		return fmt.Errorf("unsupported lane grouping: %s (must be %q, %q, or %q)", opts.LaneBy, LaneByType, LaneByProvider, LaneByNone)
+	}
 
-	layout := CalculateImprovedLayout(g, opts.Direction, nodeWidth, nodeHeight, horizontalSpacing, verticalSpacing)
+	if !validJPEGQuality(opts.JPEGQuality) {
+		return fmt.Errorf("invalid JPEG quality: %d (must be between 1 and 100)", opts.JPEGQuality)
+	}
+
+	if opts.FontURL != "" {
+		if opts.FontFamily == "" {
+			return fmt.Errorf("font URL requires a font family")
+		}
+		if strings.ContainsAny(opts.FontURL, "\"\\\n") || strings.Contains(opts.FontURL, "]]>") {
+			return fmt.Errorf("invalid font URL: %s", opts.FontURL)
+		}
+	}
+
+	return nil
+}
 
-	// Generate SVG
+// isRasterFormat reports whether format is drawn by the raster renderer (PNG or JPEG)
+func isRasterFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "png", "jpg", "jpeg":
+		return true
+	}
+	return false
+}
+
+// renderLayout renders a laid-out graph in the format of opts.Format: PNG and JPEG with
+// the raster renderer, SVG otherwise
+func renderLayout(layout *Layout, g *graph.Graph, opts RenderOptions) ([]byte, error) {
+	if !isRasterFormat(opts.Format) {
+		return renderSVG(layout, g, opts)
+	}
+
+	data, err := NewPNGRenderer(opts).Render(layout, g)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s: %w", strings.ToUpper(opts.Format), err)
+	}
+	return data, nil
+}
+
+// renderSVG renders a laid-out graph to SVG bytes
+func renderSVG(layout *Layout, g *graph.Graph, opts RenderOptions) ([]byte, error) {
 	svgRenderer := NewSVGRenderer(opts)
 	svgData, err := svgRenderer.Render(layout, g)
 	if err != nil {
-		return fmt.Errorf("failed to generate SVG: %w", err)
+		return nil, fmt.Errorf("failed to generate SVG: %w", err)
 	}
 
-	return writeFile(outputPath, svgData)
-}
+	return svgData, nil
+}
\ No newline at end of file
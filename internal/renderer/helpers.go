@@ -8,9 +8,15 @@ import (
 	"github.com/ankek/terraform-provider-cartography/internal/parser"
 )
 
-// formatEdgeLabel creates a label for an edge
-func formatEdgeLabel(edge *graph.Edge) string {
-	parts := []string{edge.Relationship}
+// formatEdgeLabel creates a label for an edge, displaying its relationship through the
+// labels mapping when it has an entry for it. Edges merged by aggregation show the number
+// of connections they stand for, e.g. "routes_to ×12".
+func formatEdgeLabel(edge *graph.Edge, labels map[string]string) string {
+	relationship := edge.Relationship
+	if label, ok := labels[relationship]; ok {
+		relationship = label
+	}
+	parts := []string{relationship}
 
 	// Add port information
 	if port, ok := edge.Metadata["port"]; ok && port != "" {
@@ -19,6 +25,9 @@ func formatEdgeLabel(edge *graph.Edge) string {
 	if protocol, ok := edge.Metadata["protocol"]; ok && protocol != "" {
 		parts = append(parts, protocol)
 	}
+	if count := edgeCount(edge); count > 1 {
+		parts = append(parts, fmt.Sprintf("×%d", count))
+	}
 
 	if len(parts) > 1 {
 		return strings.Join(parts, " ")
@@ -26,6 +35,32 @@ func formatEdgeLabel(edge *graph.Edge) string {
 	return ""
 }
 
+// shouldLabelEdge reports whether an edge's relationship is in the labeled set
+// (an empty set labels every edge)
+func shouldLabelEdge(edge *graph.Edge, relationships []string) bool {
+	if len(relationships) == 0 {
+		return true
+	}
+	for _, relationship := range relationships {
+		if edge.Relationship == relationship {
+			return true
+		}
+	}
+	return false
+}
+
+// appendEdgeSource appends the attribute that created the edge (Metadata["via"]) to its label
+func appendEdgeSource(label string, edge *graph.Edge) string {
+	via := edge.Metadata["via"]
+	if via == "" {
+		return label
+	}
+	if label == "" {
+		label = edge.Relationship
+	}
+	return fmt.Sprintf("%s via %s", label, via)
+}
+
 // getNodeColor returns the color for a node based on its type
 func getNodeColor(node *graph.Node) string {
 	switch node.ResourceType {
@@ -62,6 +97,7 @@ func getResourceTypeName(resourceType string) string {
 	name = strings.TrimPrefix(name, "aws_")
 	name = strings.TrimPrefix(name, "google_")
 	name = strings.TrimPrefix(name, "digitalocean_")
+	name = strings.TrimPrefix(name, "alicloud_")
 
 	name = strings.ReplaceAll(name, "_", " ")
 	words := strings.Fields(name)
@@ -74,10 +110,104 @@ func getResourceTypeName(resourceType string) string {
 	return strings.Join(words, " ")
 }
 
-// truncate truncates a string to a maximum length
+// badgeRegionAttributes are checked in order for a node's region badge
+var badgeRegionAttributes = []string{"region", "location"}
+
+// regionAttributes are checked in order for the region a node is colored by
+var regionAttributes = []string{"region", "location", "zone", "availability_zone"}
+
+// nodeRegion returns the region, location or zone of a node, or "" when it has none
+func nodeRegion(node *graph.Node) string {
+	for _, key := range regionAttributes {
+		if value, ok := parser.GetStringAttribute(node.Attributes, key); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// tagAttributes are checked in order for a node's tags (GCP calls them labels)
+var tagAttributes = []string{"tags", "labels"}
+
+// nodeTag returns the value of a node's tag, or "" when it has no such tag
+func nodeTag(node *graph.Node, key string) string {
+	for _, attr := range tagAttributes {
+		if tags, ok := parser.GetMapAttribute(node.Attributes, attr); ok {
+			if value, ok := parser.GetStringAttribute(tags, key); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// matchesTags reports whether a node carries every tag in tags with the given value
+func matchesTags(node *graph.Node, tags map[string]string) bool {
+	for key, value := range tags {
+		if nodeTag(node, key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// badgeSizeAttributes are checked in order for a compute node's size badge
+var badgeSizeAttributes = []string{"instance_type", "size", "vm_size", "machine_type"}
+
+// formatNodeBadge returns the badge text for a node (e.g., "us-east-1 | t3.micro"),
+// or an empty string when the node has no region or size attributes
+func formatNodeBadge(node *graph.Node) string {
+	var parts []string
+
+	for _, key := range badgeRegionAttributes {
+		if value, ok := parser.GetStringAttribute(node.Attributes, key); ok && value != "" {
+			parts = append(parts, value)
+			break
+		}
+	}
+
+	if node.ResourceType == parser.ResourceTypeCompute {
+		for _, key := range badgeSizeAttributes {
+			if value, ok := parser.GetStringAttribute(node.Attributes, key); ok && value != "" {
+				parts = append(parts, value)
+				break
+			}
+		}
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// formatSummary returns the footer text summarizing the graph,
+// e.g. "12 resources, 15 connections across 2 providers"
+func formatSummary(g *graph.Graph) string {
+	providers := make(map[string]bool)
+	for _, node := range g.Nodes {
+		providers[node.Provider] = true
+	}
+
+	return fmt.Sprintf("%s, %s across %s",
+		pluralize(len(g.Nodes), "resource"),
+		pluralize(len(g.Edges), "connection"),
+		pluralize(len(providers), "provider"))
+}
+
+// pluralize formats a count with a singular or plural noun
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// truncate truncates a string to a maximum length, ending it with "..." when there is
+// room for it (maxLen <= 0 = no truncation)
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if maxLen <= 0 || len(s) <= maxLen {
 		return s
 	}
+	if maxLen < 3 {
+		return s[:maxLen]
+	}
 	return s[:maxLen-3] + "..."
 }
@@ -1,16 +1,114 @@
 package renderer
 
 import (
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
 	"github.com/ankek/terraform-provider-cartography/internal/parser"
 )
 
+func TestIconedResourceTypes(t *testing.T) {
+	iconed := IconedResourceTypes()
+
+	for provider, types := range iconed {
+		if !sort.StringsAreSorted(types) {
+			t.Errorf("IconedResourceTypes()[%q] is not sorted", provider)
+		}
+		for _, resourceType := range types {
+			if getIconPath(provider, resourceType) == "" {
+				t.Errorf("getIconPath(%s, %s) is empty", provider, resourceType)
+			}
+		}
+	}
+
+	for provider, resourceType := range map[string]string{
+		"aws":   "aws_instance",
+		"azure": "azurerm_virtual_network",
+		"gcp":   "google_compute_instance",
+	} {
+		found := false
+		for _, got := range iconed[provider] {
+			if got == resourceType {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("IconedResourceTypes()[%q] does not contain %s", provider, resourceType)
+		}
+	}
+}
+
+func TestVerifyIcons(t *testing.T) {
+	const bogusPath = "icons/aws/does-not-exist.svg"
+	awsIconMap["aws_bogus_resource"] = bogusPath
+	awsIconMap["aws_other_bogus_resource"] = bogusPath
+	t.Cleanup(func() {
+		delete(awsIconMap, "aws_bogus_resource")
+		delete(awsIconMap, "aws_other_bogus_resource")
+	})
+
+	missing := VerifyIcons()
+	if !sort.StringsAreSorted(missing) {
+		t.Errorf("VerifyIcons() = %v, want sorted paths", missing)
+	}
+	count := 0
+	for _, iconPath := range missing {
+		if iconPath == bogusPath {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("VerifyIcons() reports %s %d times, want once", bogusPath, count)
+	}
+}
+
+func TestSetExternalIconDir(t *testing.T) {
+	iconDir := t.TempDir()
+	iconPath := getIconPath("aws", "aws_instance")
+	iconData := []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)
+	if err := os.MkdirAll(filepath.Join(iconDir, filepath.Dir(iconPath)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(iconDir, iconPath), iconData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	SetIconMode(IconModeExternal)
+	SetExternalIconDir(iconDir)
+	t.Cleanup(func() {
+		SetIconMode(IconModeEmbedded)
+		SetExternalIconDir("")
+	})
+
+	if !IconExists("aws", "aws_instance") {
+		t.Error("IconExists(aws, aws_instance) = false, want true for an icon in the external dir")
+	}
+	if IconExists("aws", "aws_vpc") {
+		t.Error("IconExists(aws, aws_vpc) = true, want false for an icon missing from the external dir")
+	}
+
+	data, err := getIconData(iconPath)
+	if err != nil {
+		t.Fatalf("getIconData() error = %v", err)
+	}
+	if string(data) != string(iconData) {
+		t.Errorf("getIconData() = %q, want %q", data, iconData)
+	}
+
+	SetExternalIconDir("")
+	if externalIconDir != defaultExternalIconDir {
+		t.Errorf("SetExternalIconDir(\"\") left dir %q, want default %q", externalIconDir, defaultExternalIconDir)
+	}
+}
+
 func TestFormatEdgeLabel(t *testing.T) {
 	tests := []struct {
 		name     string
 		edge     *graph.Edge
+		labels   map[string]string
 		expected string
 	}{
 		{
@@ -24,6 +122,17 @@ func TestFormatEdgeLabel(t *testing.T) {
 			},
 			expected: "connects :443 tcp",
 		},
+		{
+			name: "with relationship label",
+			edge: &graph.Edge{
+				Relationship: "protects",
+				Metadata: map[string]string{
+					"port": "22",
+				},
+			},
+			labels:   map[string]string{"protects": "secures", "routes_to": "forwards"},
+			expected: "secures :22",
+		},
 		{
 			name: "with port only",
 			edge: &graph.Edge{
@@ -44,6 +153,16 @@ func TestFormatEdgeLabel(t *testing.T) {
 			},
 			expected: "connects https",
 		},
+		{
+			name: "aggregated edge",
+			edge: &graph.Edge{
+				Relationship: "routes_to",
+				Metadata: map[string]string{
+					"count": "12",
+				},
+			},
+			expected: "routes_to ×12",
+		},
 		{
 			name: "no metadata",
 			edge: &graph.Edge{
@@ -56,7 +175,7 @@ func TestFormatEdgeLabel(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := formatEdgeLabel(tt.edge)
+			got := formatEdgeLabel(tt.edge, tt.labels)
 			if got != tt.expected {
 				t.Errorf("formatEdgeLabel() = %v, want %v", got, tt.expected)
 			}
@@ -64,6 +183,52 @@ func TestFormatEdgeLabel(t *testing.T) {
 	}
 }
 
+func TestAppendEdgeSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		label    string
+		edge     *graph.Edge
+		expected string
+	}{
+		{
+			name:  "appends to existing label",
+			label: "protects :22 tcp",
+			edge: &graph.Edge{
+				Relationship: "protects",
+				Metadata:     map[string]string{"port": "22", "protocol": "tcp", "via": "vpc_security_group_ids"},
+			},
+			expected: "protects :22 tcp via vpc_security_group_ids",
+		},
+		{
+			name:  "falls back to relationship",
+			label: "",
+			edge: &graph.Edge{
+				Relationship: "contains",
+				Metadata:     map[string]string{"via": "subnet_ids"},
+			},
+			expected: "contains via subnet_ids",
+		},
+		{
+			name:  "no source attribute",
+			label: "",
+			edge: &graph.Edge{
+				Relationship: "depends_on",
+				Metadata:     map[string]string{},
+			},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendEdgeSource(tt.label, tt.edge)
+			if got != tt.expected {
+				t.Errorf("appendEdgeSource() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetNodeColor(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -193,6 +358,48 @@ func TestGetResourceTypeName(t *testing.T) {
 	}
 }
 
+func TestFormatSummary(t *testing.T) {
+	vpc := &graph.Node{ID: "aws_vpc.main", Provider: "aws"}
+	subnet := &graph.Node{ID: "aws_subnet.a", Provider: "aws"}
+	vnet := &graph.Node{ID: "azurerm_virtual_network.main", Provider: "azure"}
+
+	tests := []struct {
+		name     string
+		graph    *graph.Graph
+		expected string
+	}{
+		{
+			name:     "empty graph",
+			graph:    &graph.Graph{Nodes: map[string]*graph.Node{}},
+			expected: "0 resources, 0 connections across 0 providers",
+		},
+		{
+			name: "singular counts",
+			graph: &graph.Graph{
+				Nodes: map[string]*graph.Node{vpc.ID: vpc},
+				Edges: []*graph.Edge{{From: vpc, To: vpc}},
+			},
+			expected: "1 resource, 1 connection across 1 provider",
+		},
+		{
+			name: "multiple providers",
+			graph: &graph.Graph{
+				Nodes: map[string]*graph.Node{vpc.ID: vpc, subnet.ID: subnet, vnet.ID: vnet},
+				Edges: []*graph.Edge{{From: vpc, To: subnet}},
+			},
+			expected: "3 resources, 1 connection across 2 providers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSummary(tt.graph); got != tt.expected {
+				t.Errorf("formatSummary() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -224,6 +431,18 @@ func TestTruncate(t *testing.T) {
 			maxLen:   3,
 			expected: "...",
 		},
+		{
+			name:     "shorter than ellipsis",
+			input:    "hello",
+			maxLen:   2,
+			expected: "he",
+		},
+		{
+			name:     "no limit",
+			input:    "hello world this is a test",
+			maxLen:   0,
+			expected: "hello world this is a test",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+)
+
+// pathHighlightColor is the accent color of the nodes and edges on RenderOptions.HighlightPath
+const pathHighlightColor = "#e8590c"
+
+// highlightedPath holds the nodes and edges of the path drawn with RenderOptions.HighlightPath
+type highlightedPath struct {
+	nodes map[string]bool
+	steps map[[2]string]bool // Consecutive node IDs on the path, in both orders
+}
+
+// findHighlightedPath returns the shortest path between the two node IDs of ends in g, or
+// nil when no path is requested or none connects them
+func findHighlightedPath(g *graph.Graph, ends [2]string) *highlightedPath {
+	if ends[0] == "" || ends[1] == "" {
+		return nil
+	}
+	ids := g.ShortestPath(ends[0], ends[1])
+	if ids == nil {
+		return nil
+	}
+
+	path := &highlightedPath{
+		nodes: make(map[string]bool, len(ids)),
+		steps: make(map[[2]string]bool, 2*len(ids)),
+	}
+	for i, id := range ids {
+		path.nodes[id] = true
+		if i > 0 {
+			path.steps[[2]string{ids[i-1], id}] = true
+			path.steps[[2]string{id, ids[i-1]}] = true
+		}
+	}
+	return path
+}
+
+// hasEdge reports whether an edge connects two consecutive nodes of the path; edges
+// between the same nodes in parallel are all on it
+func (p *highlightedPath) hasEdge(edge *graph.Edge) bool {
+	return p != nil && p.steps[[2]string{edge.From.ID, edge.To.ID}]
+}
@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,8 +16,11 @@ type IconMapping struct {
 	Category     string
 }
 
-// ScanAndMapIcons automatically scans icon directories and creates mappings
-func ScanAndMapIcons(iconBaseDir string) (map[string]map[string]string, error) {
+// ScanAndMapIcons automatically scans icon directories and creates mappings.
+// Directories that cannot be scanned are skipped and reported to logger (nil = discard).
+func ScanAndMapIcons(iconBaseDir string, logger *slog.Logger) (map[string]map[string]string, error) {
+	logger = loggerOrDiscard(logger)
+
 	// Result: provider -> (resourceType -> iconPath)
 	mappings := make(map[string]map[string]string)
 	mappings["azure"] = make(map[string]string)
@@ -34,7 +38,7 @@ func ScanAndMapIcons(iconBaseDir string) (map[string]map[string]string, error) {
 
 		iconFiles, err := findIconFiles(providerDir)
 		if err != nil {
-			fmt.Printf("Warning: failed to scan %s icons: %v\n", provider, err)
+			logger.Warn("failed to scan icons", "provider", provider, "error", err)
 			continue
 		}
 
@@ -337,23 +341,29 @@ func UpdateIconMaps(scannedMappings map[string]map[string]string) {
 	}
 }
 
-// InitializeIcons scans and initializes icon mappings
-func InitializeIcons() error {
-	iconBaseDir := "internal/renderer/icons"
+// InitializeIcons scans the icons under the external icon directory (see SetExternalIconDir)
+// and initializes icon mappings, reporting statistics to logger (nil = discard)
+func InitializeIcons(logger *slog.Logger) error {
+	logger = loggerOrDiscard(logger)
+	iconBaseDir := filepath.Join(externalIconDir, "icons")
 
-	mappings, err := ScanAndMapIcons(iconBaseDir)
+	mappings, err := ScanAndMapIcons(iconBaseDir, logger)
 	if err != nil {
 		return fmt.Errorf("failed to scan icons: %w", err)
 	}
 
 	UpdateIconMaps(mappings)
 
-	// Print statistics
-	fmt.Printf("Icon auto-mapping complete:\n")
-	fmt.Printf("  Azure: %d mappings\n", len(azureIconMap))
-	fmt.Printf("  AWS: %d mappings\n", len(awsIconMap))
-	fmt.Printf("  DigitalOcean: %d mappings\n", len(digitaloceanIconMap))
-	fmt.Printf("  GCP: %d mappings\n", len(gcpIconMap))
+	logger.Info("icon auto-mapping complete",
+		"azure", len(azureIconMap),
+		"aws", len(awsIconMap),
+		"digitalocean", len(digitaloceanIconMap),
+		"gcp", len(gcpIconMap),
+	)
+
+	if missing := VerifyIcons(); len(missing) > 0 {
+		logger.Warn("mapped icons missing from the embedded icons", "count", len(missing), "paths", missing)
+	}
 
 	return nil
 }
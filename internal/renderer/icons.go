@@ -4,8 +4,10 @@ import (
 	"embed"
 	"encoding/base64"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 //go:embed icons
@@ -27,6 +29,21 @@ func SetIconMode(mode IconMode) {
 	currentIconMode = mode
 }
 
+// defaultExternalIconDir is the external icon directory relative to the repository root
+const defaultExternalIconDir = "internal/renderer"
+
+var externalIconDir = defaultExternalIconDir
+
+// SetExternalIconDir changes the directory IconModeExternal loads icons from. Icon paths
+// (icons/<provider>/...) are resolved against it; an empty dir restores the default,
+// which only works when running from the repository root.
+func SetExternalIconDir(dir string) {
+	if dir == "" {
+		dir = defaultExternalIconDir
+	}
+	externalIconDir = dir
+}
+
 // Azure icon mappings (using actual downloaded files)
 var azureIconMap = map[string]string{
 	"azurerm_virtual_network":         "icons/azure/networking/10061-icon-service-Virtual-Networks.svg",
@@ -36,6 +53,11 @@ var azureIconMap = map[string]string{
 	"azurerm_virtual_machine":         "icons/azure/compute/10021-icon-service-Virtual-Machine.svg",
 	"azurerm_linux_virtual_machine":   "icons/azure/compute/10021-icon-service-Virtual-Machine.svg",
 	"azurerm_windows_virtual_machine": "icons/azure/compute/10021-icon-service-Virtual-Machine.svg",
+	"azurerm_app_service_plan":        "icons/azure/app services/00046-icon-service-App-Service-Plans.svg",
+	"azurerm_service_plan":            "icons/azure/app services/00046-icon-service-App-Service-Plans.svg",
+	"azurerm_app_service":             "icons/azure/app services/10035-icon-service-App-Services.svg",
+	"azurerm_linux_web_app":           "icons/azure/app services/10035-icon-service-App-Services.svg",
+	"azurerm_function_app":            "icons/azure/compute/10029-icon-service-Function-Apps.svg",
 	"azurerm_lb":                      "icons/azure/networking/10062-icon-service-Load-Balancers.svg",
 	"azurerm_lb_backend_address_pool": "icons/azure/networking/10062-icon-service-Load-Balancers.svg",
 	"azurerm_lb_rule":                 "icons/azure/networking/10062-icon-service-Load-Balancers.svg",
@@ -46,6 +68,7 @@ var azureIconMap = map[string]string{
 	"azurerm_dns_zone":                "icons/azure/networking/10064-icon-service-DNS-Zones.svg",
 	"azurerm_public_ip":               "icons/azure/networking/10069-icon-service-Public-IP-Addresses.svg",
 	"azurerm_network_interface":       "icons/azure/networking/10080-icon-service-Network-Interfaces.svg",
+	"azurerm_private_endpoint":        "icons/azure/networking/10080-icon-service-Network-Interfaces.svg",
 	// Security & Certificates
 	"azurerm_key_vault":             "icons/generic/security.svg",
 	"azurerm_key_vault_certificate": "icons/generic/tls-certificate.svg",
@@ -62,6 +85,12 @@ var awsIconMap = map[string]string{
 	"aws_network_acl":         "icons/aws/Architecture-Service-Icons_07312025/Arch_Security-Identity-Compliance/64/Arch_AWS-Security-Hub_64.svg",
 	"aws_instance":            "icons/aws/Architecture-Service-Icons_07312025/Arch_Compute/64/Arch_Amazon-EC2_64.svg",
 	"aws_launch_template":     "icons/aws/Architecture-Service-Icons_07312025/Arch_Compute/64/Arch_Amazon-EC2_64.svg",
+	"aws_lambda_function":     "icons/aws/Architecture-Service-Icons_07312025/Arch_Compute/64/Arch_AWS-Lambda_64.svg",
+	"aws_ecs_cluster":         "icons/aws/Architecture-Service-Icons_07312025/Arch_Containers/64/Arch_Amazon-Elastic-Container-Service_64.svg",
+	"aws_ecs_service":         "icons/aws/Architecture-Service-Icons_07312025/Arch_Containers/64/Arch_Amazon-Elastic-Container-Service_64.svg",
+	"aws_ecs_task_definition": "icons/aws/Architecture-Service-Icons_07312025/Arch_Containers/64/Arch_Amazon-Elastic-Container-Service_64.svg",
+	"aws_eks_cluster":         "icons/aws/Architecture-Service-Icons_07312025/Arch_Containers/64/Arch_Amazon-Elastic-Kubernetes-Service_64.svg",
+	"aws_eks_node_group":      "icons/aws/Architecture-Service-Icons_07312025/Arch_Containers/64/Arch_Amazon-Elastic-Kubernetes-Service_64.svg",
 	"aws_lb":                  "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Elastic-Load-Balancing_64.svg",
 	"aws_alb":                 "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Elastic-Load-Balancing_64.svg",
 	"aws_lb_target_group":     "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Elastic-Load-Balancing_64.svg",
@@ -69,9 +98,21 @@ var awsIconMap = map[string]string{
 	"aws_s3_bucket":           "icons/aws/Architecture-Service-Icons_07312025/Arch_Storage/64/Arch_Amazon-Simple-Storage-Service_64.svg",
 	"aws_ebs_volume":          "icons/aws/Architecture-Service-Icons_07312025/Arch_Storage/64/Arch_Amazon-Elastic-Block-Store_64.svg",
 	"aws_db_instance":         "icons/aws/Architecture-Service-Icons_07312025/Arch_Database/64/Arch_Amazon-RDS_64.svg",
+	"aws_db_subnet_group":     "icons/aws/Architecture-Service-Icons_07312025/Arch_Database/64/Arch_Amazon-RDS_64.svg",
 	"aws_dynamodb_table":      "icons/aws/Architecture-Service-Icons_07312025/Arch_Database/64/Arch_Amazon-DynamoDB_64.svg",
 	"aws_route53_zone":        "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Amazon-Route-53_64.svg",
 	"aws_route53_record":      "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Amazon-Route-53_64.svg",
+	// Cross-VPC connectivity
+	"aws_vpc_peering_connection":             "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Amazon-Virtual-Private-Cloud_64.svg",
+	"aws_ec2_transit_gateway":                "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_AWS-Transit-Gateway_64.svg",
+	"aws_ec2_transit_gateway_vpc_attachment": "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_AWS-Transit-Gateway_64.svg",
+	// Elastic network interfaces
+	"aws_network_interface": "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Amazon-Virtual-Private-Cloud_64.svg",
+	// IAM roles assumed by compute
+	"aws_iam_role":             "icons/aws/Architecture-Service-Icons_07312025/Arch_Security-Identity-Compliance/64/Arch_AWS-Identity-and-Access-Management_64.svg",
+	"aws_iam_instance_profile": "icons/aws/Architecture-Service-Icons_07312025/Arch_Security-Identity-Compliance/64/Arch_AWS-Identity-and-Access-Management_64.svg",
+	// Content delivery
+	"aws_cloudfront_distribution": "icons/aws/Architecture-Service-Icons_07312025/Arch_Networking-Content-Delivery/64/Arch_Amazon-CloudFront_64.svg",
 	// Security & Certificates
 	"aws_acm_certificate":               "icons/generic/tls-certificate.svg",
 	"aws_acm_certificate_validation":    "icons/generic/certificate-authority.svg",
@@ -105,6 +146,17 @@ var digitaloceanIconMap = map[string]string{
 	"digitalocean_monitor_alert":      "icons/generic/monitoring.svg",
 }
 
+// Alibaba Cloud icon mappings
+var alicloudIconMap = map[string]string{
+	"alicloud_vpc":            "icons/alicloud/vpc.svg",
+	"alicloud_vswitch":        "icons/alicloud/vswitch.svg",
+	"alicloud_security_group": "icons/generic/security.svg",
+	"alicloud_instance":       "icons/alicloud/ecs.svg",
+	"alicloud_slb":            "icons/alicloud/slb.svg",
+	"alicloud_oss_bucket":     "icons/alicloud/oss.svg",
+	"alicloud_db_instance":    "icons/alicloud/rds.svg",
+}
+
 // GCP icon mappings (using actual downloaded files)
 var gcpIconMap = map[string]string{
 	"google_compute_network":         "icons/gcp/Networking/SVG/Networking-512-color-rgb.svg",
@@ -115,6 +167,11 @@ var gcpIconMap = map[string]string{
 	"google_storage_bucket":          "icons/gcp/Cloud Storage/SVG/Cloud_Storage-512-color.svg",
 	"google_container_cluster":       "icons/gcp/GKE/SVG/GKE-512-color.svg",
 	"google_sql_database_instance":   "icons/gcp/Cloud SQL/SVG/CloudSQL-512-color.svg",
+	// Instance groups and load balancing
+	"google_compute_instance_template":      "icons/gcp/Compute Engine/SVG/ComputeEngine-512-color-rgb.svg",
+	"google_compute_instance_group":         "icons/gcp/Compute Engine/SVG/ComputeEngine-512-color-rgb.svg",
+	"google_compute_instance_group_manager": "icons/gcp/Compute Engine/SVG/ComputeEngine-512-color-rgb.svg",
+	"google_compute_backend_service":        "icons/gcp/Cloud Load Balancing/SVG/CloudLoadBalancing-512-color.svg",
 	// Security & Certificates (using generic icons for consistency)
 	"google_compute_ssl_certificate":      "icons/generic/tls-certificate.svg",
 	"google_kms_crypto_key":               "icons/generic/private-key.svg",
@@ -124,20 +181,55 @@ var gcpIconMap = map[string]string{
 	"google_artifact_registry_repository": "icons/generic/container.svg",
 }
 
+// providerIconMaps maps each provider name to its icon mappings
+var providerIconMaps = map[string]map[string]string{
+	"azure":        azureIconMap,
+	"aws":          awsIconMap,
+	"digitalocean": digitaloceanIconMap,
+	"gcp":          gcpIconMap,
+	"alicloud":     alicloudIconMap,
+}
+
+// IconedResourceTypes returns the resource types with a dedicated icon, keyed by
+// provider name, with each list sorted
+func IconedResourceTypes() map[string][]string {
+	iconed := make(map[string][]string, len(providerIconMaps))
+	for provider, iconMap := range providerIconMaps {
+		types := make([]string, 0, len(iconMap))
+		for resourceType := range iconMap {
+			types = append(types, resourceType)
+		}
+		sort.Strings(types)
+		iconed[provider] = types
+	}
+	return iconed
+}
+
+// VerifyIcons returns the icon paths, sorted and without duplicates, that are referenced
+// by an icon mapping but missing from the embedded icons. Resources of those types fall
+// back to plain boxes when icons are embedded, so any result is a packaging error.
+func VerifyIcons() []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, iconMap := range providerIconMaps {
+		for _, iconPath := range iconMap {
+			if seen[iconPath] {
+				continue
+			}
+			seen[iconPath] = true
+			if _, err := fs.Stat(embeddedIcons, iconPath); err != nil {
+				missing = append(missing, iconPath)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
 // getIconPath returns the path to the icon for a given provider and resource type
 func getIconPath(provider, resourceType string) string {
-	var iconMap map[string]string
-
-	switch provider {
-	case "azure":
-		iconMap = azureIconMap
-	case "aws":
-		iconMap = awsIconMap
-	case "digitalocean":
-		iconMap = digitaloceanIconMap
-	case "gcp":
-		iconMap = gcpIconMap
-	default:
+	iconMap, ok := providerIconMaps[provider]
+	if !ok {
 		return ""
 	}
 
@@ -166,7 +258,7 @@ func getIconData(iconPath string) ([]byte, error) {
 	}
 
 	// IconModeExternal: Read from filesystem
-	fullPath := filepath.Join("internal/renderer", iconPath)
+	fullPath := filepath.Join(externalIconDir, iconPath)
 	data, err := os.ReadFile(fullPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read icon file %s: %w", fullPath, err)
@@ -212,7 +304,7 @@ func IconExists(provider, resourceType string) bool {
 		return err == nil
 	}
 
-	fullPath := filepath.Join("internal/renderer", iconPath)
+	fullPath := filepath.Join(externalIconDir, iconPath)
 	_, err := os.Stat(fullPath)
 	return err == nil
 }
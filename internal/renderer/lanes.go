@@ -0,0 +1,190 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
+)
+
+// Lane groupings selectable through RenderOptions.LaneBy
+const (
+	LaneByNone     = "none"     // No lanes (default)
+	LaneByType     = "type"     // One lane per resource category, e.g. "Compute"
+	LaneByProvider = "provider" // One lane per cloud provider
+)
+
+// validLaneBy reports whether laneBy is a supported lane grouping (empty disables lanes)
+func validLaneBy(laneBy string) bool {
+	switch laneBy {
+	case "", LaneByNone, LaneByType, LaneByProvider:
+		return true
+	}
+	return false
+}
+
+// laneGap is the space added between adjacent lanes on top of the usual node spacing,
+// leaving room for the divider
+const laneGap = 60.0
+
+// Lane is a band of the layout holding the nodes of one group across all layers: a
+// column for TB/BT layouts and a row for LR/RL layouts
+type Lane struct {
+	Label string
+	Start float64 // Cross-axis start (X for TB/BT, Y for LR/RL) in layout space
+	End   float64 // Cross-axis end of the lane's widest layer
+}
+
+// laneOf returns the lane of a node and the rank ordering its lane before others of
+// lower priority; lanes of equal rank are ordered by label
+func laneOf(node *graph.Node, laneBy string) (string, int) {
+	if laneBy == LaneByProvider {
+		if node.Provider == "" {
+			return "other", 1
+		}
+		return node.Provider, 0
+	}
+	return resourceTypeLabel(node.ResourceType), getResourceTypePriority(node.ResourceType)
+}
+
+// resourceTypeLabel returns the display name of a resource category
+func resourceTypeLabel(rt parser.ResourceType) string {
+	labels := map[parser.ResourceType]string{
+		parser.ResourceTypeNetwork:      "Network",
+		parser.ResourceTypeSecurity:     "Security",
+		parser.ResourceTypeCompute:      "Compute",
+		parser.ResourceTypeLoadBalancer: "Load Balancer",
+		parser.ResourceTypeStorage:      "Storage",
+		parser.ResourceTypeDatabase:     "Database",
+		parser.ResourceTypeDNS:          "DNS",
+		parser.ResourceTypeCertificate:  "Certificate",
+		parser.ResourceTypeSecret:       "Secret",
+		parser.ResourceTypeContainer:    "Container",
+		parser.ResourceTypeCDN:          "CDN",
+	}
+
+	if label, exists := labels[rt]; exists {
+		return label
+	}
+	return "Other"
+}
+
+// assignLaneCoordinates assigns coordinates like assignCoordinatesWithSpacing, but splits
+// the cross axis into one lane per group of laneBy. Each lane is as wide as its group's
+// largest share of any layer, lanes are laneGap further apart than neighboring nodes,
+// and the nodes of a layer keep their order within their lane, centered in it.
+func (il *ImprovedLayout) assignLaneCoordinates(layers [][]string, g *graph.Graph, laneBy, direction string,
+	nodeWidth, nodeHeight, hSpacing, vSpacing float64) {
+
+	horizontal := direction == "LR" || direction == "RL"
+	reversed := direction == "BT" || direction == "RL"
+	crossSize, crossSpacing, mainStep := nodeWidth, hSpacing, nodeHeight+vSpacing
+	if horizontal {
+		crossSize, crossSpacing, mainStep = nodeHeight, vSpacing, nodeWidth+hSpacing
+	}
+	span := func(count int) float64 {
+		return float64(count)*crossSize + float64(count-1)*crossSpacing
+	}
+
+	type lane struct {
+		label string
+		rank  int
+		size  int              // Most nodes of any layer in this lane
+		nodes map[int][]string // Node IDs by layer, in layer order
+	}
+	lanes := make(map[string]*lane)
+	for layerIdx, layer := range layers {
+		for _, id := range layer {
+			label, rank := laneOf(g.Nodes[id], laneBy)
+			l := lanes[label]
+			if l == nil {
+				l = &lane{label: label, rank: rank, nodes: make(map[int][]string)}
+				lanes[label] = l
+			}
+			l.nodes[layerIdx] = append(l.nodes[layerIdx], id)
+			if len(l.nodes[layerIdx]) > l.size {
+				l.size = len(l.nodes[layerIdx])
+			}
+		}
+	}
+
+	ordered := make([]*lane, 0, len(lanes))
+	for _, l := range lanes {
+		ordered = append(ordered, l)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].rank != ordered[j].rank {
+			return ordered[i].rank < ordered[j].rank
+		}
+		return ordered[i].label < ordered[j].label
+	})
+
+	offset := 0.0
+	for _, l := range ordered {
+		laneSpan := span(l.size)
+		for layerIdx := range layers {
+			ids := l.nodes[layerIdx]
+			start := offset + (laneSpan-span(len(ids)))/2
+			mainIdx := layerIdx
+			if reversed {
+				mainIdx = len(layers) - 1 - layerIdx
+			}
+
+			for i, id := range ids {
+				cross := start + float64(i)*(crossSize+crossSpacing)
+				main := float64(mainIdx) * mainStep
+				node := &NodeLayout{
+					Width:    nodeWidth,
+					Height:   nodeHeight,
+					Layer:    layerIdx,
+					Position: Point{X: cross, Y: main},
+				}
+				if horizontal {
+					node.Position = Point{X: main, Y: cross}
+				}
+				il.Nodes[id] = node
+				il.nodesByLayer[layerIdx] = append(il.nodesByLayer[layerIdx], node)
+			}
+		}
+
+		il.Lanes = append(il.Lanes, Lane{Label: l.label, Start: offset, End: offset + laneSpan})
+		offset += laneSpan + crossSpacing + laneGap
+	}
+
+	maxX, maxY := il.extent()
+	il.Width = maxX + hSpacing
+	il.Height = maxY + vSpacing
+}
+
+// renderLanes draws a faint dashed divider between adjacent lanes of the layout, and
+// labels each lane at the far end of the main axis, in the space after the last layer
+func (r *SVGRenderer) renderLanes(layout *Layout, padding float64) {
+	horizontal := layout.Direction == "LR" || layout.Direction == "RL"
+
+	var b strings.Builder
+	for i, lane := range layout.Lanes {
+		if i > 0 {
+			divider := padding + (layout.Lanes[i-1].End+lane.Start)/2
+			x1, y1, x2, y2 := divider, padding, divider, padding+layout.Height
+			if horizontal {
+				x1, y1, x2, y2 = padding, divider, padding+layout.Width, divider
+			}
+			b.WriteString(fmt.Sprintf(`  <line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="#adb5bd" stroke-opacity="0.6" stroke-width="1" stroke-dasharray="6 6"/>
+`, x1, y1, x2, y2))
+		}
+
+		center := padding + (lane.Start+lane.End)/2
+		x, y, anchor := center, padding+layout.Height-12, "middle"
+		if horizontal {
+			x, y, anchor = padding+layout.Width-12, center+4, "end"
+		}
+		b.WriteString(fmt.Sprintf(`  <text x="%.2f" y="%.2f" font-family="%s" font-size="12" font-weight="600" fill="#868e96" text-anchor="%s">%s</text>
+`, x, y, r.fontFamily(), anchor, escapeXML(lane.Label)))
+	}
+
+	r.buf.WriteString("\n<!-- Lanes -->\n<g class=\"lanes\">\n")
+	r.buf.WriteString(b.String())
+	r.buf.WriteString("</g>\n")
+}
@@ -17,22 +17,33 @@ type NodeLayout struct {
 	Position Point
 	Width    float64
 	Height   float64
-	Layer    int // Hierarchical layer (0 = top/left)
+	Layer    int    // Hierarchical layer (0 = top/left)
+	Parent   string // ID of the container this node is drawn inside (empty = top level)
 }
 
 // EdgeLayout represents the layout information for an edge
 type EdgeLayout struct {
-	Edge   *graph.Edge
-	Points []Point // Control points for the edge path
+	Edge     *graph.Edge
+	Points   []Point // Control points for the edge path
+	Feedback bool    // Edge was ignored during layering to break a cycle
+	Junction *Point  // Junction shared with the other edges bundled into the same target, if any
 }
 
-// Layout represents the complete graph layout
+// Layout represents the complete graph layout.
+//
+// Coordinates are in layout space: the origin is the top-left corner of the node area,
+// X grows to the right and Y downwards, and Position is a node's top-left corner. The
+// SVG renderer offsets everything by SVGPadding on both axes, so a node drawn at
+// (x, y) in the SVG has Position (x-SVGPadding, y-SVGPadding). Width and Height bound
+// every node and edge, before padding.
 type Layout struct {
 	Nodes     map[string]*NodeLayout
 	Edges     []*EdgeLayout
 	Width     float64
 	Height    float64
 	Direction string // TB, LR, BT, RL
+	Truncated bool   // Nodes beyond the layer limit were placed in a shared overflow layer
+	Lanes     []Lane // Lanes of LayoutOptions.LaneBy, in cross-axis order (nil = no lanes)
 }
 
 // CalculateLayout performs hierarchical graph layout
@@ -13,10 +13,41 @@ type ImprovedLayout struct {
 	*Layout
 	nodesByLayer map[int][]*NodeLayout
 	groupings    map[parser.ResourceType][]*NodeLayout
+	feedback     map[*graph.Edge]bool // Edges removed to make layering acyclic
+}
+
+// LayoutOptions controls optional behavior of CalculateImprovedLayoutWithOptions
+type LayoutOptions struct {
+	EdgeStyle string // One of the EdgeStyle* constants; empty means EdgeStyleCurved
+	MaxLayers int    // Maximum number of layers; 0 allows one layer per node (no truncation)
+
+	// NestContainers draws members of "contains"/"member_of" relationships inside their
+	// container instead of connecting them with edges
+	NestContainers bool
+
+	// LaneBy splits the cross axis into one lane per resource category (LaneByType) or
+	// provider (LaneByProvider), recorded in Layout.Lanes; empty or LaneByNone disables
+	// lanes. Ignored for nested layouts.
+	LaneBy string
+
+	// SimpleEdgeThreshold is the number of edges above which curved edges are drawn as
+	// lines of at most 3 points instead of 25-point Bezier curves, shrinking the output
+	// of large graphs (0 = DefaultSimpleEdgeThreshold, negative = always curved)
+	SimpleEdgeThreshold int
+
+	// BundleSharedTargets routes the edges of several sources entering the same side of a
+	// node through a junction in front of it, sharing a single trunk into the node
+	BundleSharedTargets bool
 }
 
 // CalculateImprovedLayout creates a professional layout with proper spacing
 func CalculateImprovedLayout(g *graph.Graph, direction string, nodeWidth, nodeHeight, hSpacing, vSpacing float64) *Layout {
+	return CalculateImprovedLayoutWithOptions(g, direction, nodeWidth, nodeHeight, hSpacing, vSpacing, LayoutOptions{})
+}
+
+// CalculateImprovedLayoutWithOptions creates a layout like CalculateImprovedLayout,
+// with opts controlling edge routing and the layer limit
+func CalculateImprovedLayoutWithOptions(g *graph.Graph, direction string, nodeWidth, nodeHeight, hSpacing, vSpacing float64, opts LayoutOptions) *Layout {
 	// Increase spacing for better visibility
 	enhancedHSpacing := hSpacing * 1.5  // 180px between nodes horizontally
 	enhancedVSpacing := vSpacing * 1.5  // 150px between nodes vertically
@@ -31,6 +62,12 @@ func CalculateImprovedLayout(g *graph.Graph, direction string, nodeWidth, nodeHe
 		return layout
 	}
 
+	if opts.NestContainers {
+		if nested := calculateNestedLayout(g, direction, nodeWidth, nodeHeight, hSpacing, vSpacing, opts); nested != nil {
+			return nested
+		}
+	}
+
 	improved := &ImprovedLayout{
 		Layout:       layout,
 		nodesByLayer: make(map[int][]*NodeLayout),
@@ -38,31 +75,49 @@ func CalculateImprovedLayout(g *graph.Graph, direction string, nodeWidth, nodeHe
 	}
 
 	// Step 1: Assign layers with better distribution
-	layers := improved.assignLayersWithGrouping(g)
+	maxLayers := opts.MaxLayers
+	if maxLayers <= 0 {
+		maxLayers = len(g.Nodes)
+	}
+	layers := improved.assignLayersWithGrouping(g, maxLayers)
 
 	// Step 2: Minimize crossings using barycenter heuristic
 	improved.minimizeCrossings(layers, g)
 
-	// Step 3: Assign coordinates with collision avoidance
-	improved.assignCoordinatesWithSpacing(layers, direction, nodeWidth, nodeHeight, enhancedHSpacing, enhancedVSpacing)
+	// Step 3: Assign coordinates with collision avoidance, in lanes if requested
+	if opts.LaneBy == LaneByType || opts.LaneBy == LaneByProvider {
+		improved.assignLaneCoordinates(layers, g, opts.LaneBy, direction, nodeWidth, nodeHeight, enhancedHSpacing, enhancedVSpacing)
+	} else {
+		improved.assignCoordinatesWithSpacing(layers, direction, nodeWidth, nodeHeight, enhancedHSpacing, enhancedVSpacing)
+	}
 
 	// Step 4: Detect and resolve overlaps
-	improved.resolveOverlaps(nodeWidth, nodeHeight)
+	improved.resolveOverlaps()
 
 	// Step 5: Route edges intelligently to avoid overlaps
-	improved.routeEdgesWithAvoidance(g, nodeWidth, nodeHeight)
+	improved.routeEdgesWithAvoidance(g, opts, nodeWidth, nodeHeight)
+
+	// Edges removed to break cycles are still drawn, but marked for distinct styling
+	for _, edgeLayout := range layout.Edges {
+		edgeLayout.Feedback = improved.feedback[edgeLayout.Edge]
+	}
 
 	return layout
 }
 
 // routeEdgesWithAvoidance uses the edge router to prevent line overlaps
-func (il *ImprovedLayout) routeEdgesWithAvoidance(g *graph.Graph, nodeWidth, nodeHeight float64) {
-	router := NewEdgeRouter(il.Layout, nodeWidth, nodeHeight)
+func (il *ImprovedLayout) routeEdgesWithAvoidance(g *graph.Graph, opts LayoutOptions, nodeWidth, nodeHeight float64) {
+	router := newLayoutRouter(il.Layout, nodeWidth, nodeHeight, opts, len(g.Edges))
 	il.Edges = router.RouteEdges(g)
 }
 
-// assignLayersWithGrouping assigns layers while grouping related resources
-func (il *ImprovedLayout) assignLayersWithGrouping(g *graph.Graph) [][]string {
+// assignLayersWithGrouping assigns layers while grouping related resources.
+// Nodes that would fall beyond maxLayers are placed together in the last layer
+// and the layout is marked as truncated.
+func (il *ImprovedLayout) assignLayersWithGrouping(g *graph.Graph, maxLayers int) [][]string {
+	// Break cycles first so every node can be reached from a root
+	il.feedback = feedbackEdges(g)
+
 	// Calculate in-degree and out-edges
 	inDegree := make(map[string]int)
 	outEdges := make(map[string][]string)
@@ -73,6 +128,9 @@ func (il *ImprovedLayout) assignLayersWithGrouping(g *graph.Graph) [][]string {
 	}
 
 	for _, edge := range g.Edges {
+		if il.feedback[edge] {
+			continue
+		}
 		inDegree[edge.To.ID]++
 		outEdges[edge.From.ID] = append(outEdges[edge.From.ID], edge.To.ID)
 		inEdges[edge.To.ID] = append(inEdges[edge.To.ID], edge.From.ID)
@@ -83,42 +141,36 @@ func (il *ImprovedLayout) assignLayersWithGrouping(g *graph.Graph) [][]string {
 	nodeLayer := make(map[string]int)
 	processed := make(map[string]bool)
 
-	// Start with roots (no incoming edges)
+	// Start with roots (no incoming edges), ordered by resource type priority so the
+	// top layer reads network first, then security, and so on, on every run
 	var currentLayer []string
 	for id, deg := range inDegree {
 		if deg == 0 {
 			currentLayer = append(currentLayer, id)
 		}
 	}
+	currentLayer = il.groupByResourceType(currentLayer, g)
 
-	// If no roots (cycles), start with security/network resources
-	if len(currentLayer) == 0 {
-		for id, node := range g.Nodes {
-			if node.ResourceType == parser.ResourceTypeSecurity ||
-				node.ResourceType == parser.ResourceTypeNetwork {
-				currentLayer = append(currentLayer, id)
-				if len(currentLayer) >= 3 {
-					break
-				}
-			}
-		}
-		// If still empty, just pick any
-		if len(currentLayer) == 0 {
+	layerIdx := 0
+	for len(processed) < len(g.Nodes) {
+		if layerIdx == maxLayers-1 {
+			// Last allowed layer: collect every remaining node into an overflow layer
+			var overflow []string
 			for id := range g.Nodes {
-				currentLayer = append(currentLayer, id)
-				break
+				if !processed[id] {
+					overflow = append(overflow, id)
+				}
 			}
+			il.Truncated = len(overflow) > len(currentLayer)
+			currentLayer = overflow
 		}
-	}
 
-	layerIdx := 0
-	for len(processed) < len(g.Nodes) && layerIdx < 20 {
 		if len(currentLayer) == 0 {
-			// Find unprocessed nodes
+			// Defensive: the graph is acyclic here, but never loop forever.
+			// Pick the smallest unprocessed ID to stay deterministic.
 			for id := range g.Nodes {
-				if !processed[id] {
-					currentLayer = append(currentLayer, id)
-					break
+				if !processed[id] && (len(currentLayer) == 0 || id < currentLayer[0]) {
+					currentLayer = []string{id}
 				}
 			}
 		}
@@ -163,6 +215,99 @@ func (il *ImprovedLayout) assignLayersWithGrouping(g *graph.Graph) [][]string {
 	return layers
 }
 
+// feedbackEdges returns a set of edges whose removal makes the graph acyclic.
+// Cycles are broken deterministically: the first cycle found by a depth-first search
+// in node ID order loses its lexicographically-largest edge ("from->to"), and the
+// search repeats until no cycle remains.
+func feedbackEdges(g *graph.Graph) map[*graph.Edge]bool {
+	removed := make(map[*graph.Edge]bool)
+	for {
+		cycle := findCycle(g, removed)
+		if cycle == nil {
+			return removed
+		}
+
+		largest := cycle[0]
+		for _, edge := range cycle[1:] {
+			if edgeKey(edge) > edgeKey(largest) {
+				largest = edge
+			}
+		}
+		removed[largest] = true
+	}
+}
+
+// findCycle returns the edges of the first cycle found while ignoring removed edges,
+// or nil if the remaining graph is acyclic
+func findCycle(g *graph.Graph, removed map[*graph.Edge]bool) []*graph.Edge {
+	outEdges := make(map[string][]*graph.Edge)
+	for _, edge := range g.Edges {
+		if !removed[edge] {
+			outEdges[edge.From.ID] = append(outEdges[edge.From.ID], edge)
+		}
+	}
+	for _, edges := range outEdges {
+		sort.SliceStable(edges, func(i, j int) bool {
+			return edges[i].To.ID < edges[j].To.ID
+		})
+	}
+
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make(map[string]int)
+	var path []*graph.Edge
+
+	var visit func(id string) []*graph.Edge
+	visit = func(id string) []*graph.Edge {
+		state[id] = onStack
+		for _, edge := range outEdges[id] {
+			switch state[edge.To.ID] {
+			case onStack:
+				// Back edge: the cycle is the path from edge.To to id plus this edge
+				cycle := []*graph.Edge{edge}
+				for i := len(path) - 1; i >= 0 && edge.To.ID != id; i-- {
+					cycle = append(cycle, path[i])
+					if path[i].From.ID == edge.To.ID {
+						break
+					}
+				}
+				return cycle
+			case unvisited:
+				path = append(path, edge)
+				if cycle := visit(edge.To.ID); cycle != nil {
+					return cycle
+				}
+				path = path[:len(path)-1]
+			}
+		}
+		state[id] = done
+		return nil
+	}
+
+	for _, id := range ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// edgeKey identifies an edge by its endpoints for deterministic ordering
+func edgeKey(edge *graph.Edge) string {
+	return edge.From.ID + "->" + edge.To.ID
+}
+
 // groupByResourceType groups nodes by their resource type for better layout
 func (il *ImprovedLayout) groupByResourceType(nodeIDs []string, g *graph.Graph) []string {
 	type nodeWithType struct {
@@ -183,7 +328,10 @@ func (il *ImprovedLayout) groupByResourceType(nodeIDs []string, g *graph.Graph)
 			return getResourceTypePriority(nodes[i].node.ResourceType) <
 				getResourceTypePriority(nodes[j].node.ResourceType)
 		}
-		return nodes[i].node.Name < nodes[j].node.Name
+		if nodes[i].node.Name != nodes[j].node.Name {
+			return nodes[i].node.Name < nodes[j].node.Name
+		}
+		return nodes[i].id < nodes[j].id
 	})
 
 	result := make([]string, len(nodes))
@@ -224,8 +372,8 @@ func (il *ImprovedLayout) minimizeCrossings(layers [][]string, g *graph.Graph) {
 			il.reorderLayerByBarycenter(layers, i, g, true)
 		}
 
-		// Backward pass (bottom to top)
-		for i := len(layers) - 2; i >= 0; i-- {
+		// Backward pass (bottom to top); the root layer keeps its resource type order
+		for i := len(layers) - 2; i >= 1; i-- {
 			il.reorderLayerByBarycenter(layers, i, g, false)
 		}
 	}
@@ -370,52 +518,72 @@ func (il *ImprovedLayout) assignCoordinatesWithSpacing(layers [][]string, direct
 	il.Height = maxY + vSpacing
 }
 
-// resolveOverlaps detects and resolves any remaining overlaps
-func (il *ImprovedLayout) resolveOverlaps(nodeWidth, nodeHeight float64) {
-	// Simple overlap detection and resolution
-	nodes := make([]*NodeLayout, 0, len(il.Nodes))
-	for _, node := range il.Nodes {
-		nodes = append(nodes, node)
+// overlapMargin is the minimum space kept between neighboring nodes in a layer
+const overlapMargin = 10.0
+
+// resolveOverlaps separates overlapping nodes within each layer. Nodes only move along
+// the cross-axis (X for TB/BT, Y for LR/RL), so they stay in their assigned layer.
+// A forward sweep enforces overlapMargin between neighbors in cross-axis order, then
+// the layer is shifted back toward its original center (never below 0).
+// Width and Height grow or shrink with the resulting extent.
+func (il *ImprovedLayout) resolveOverlaps() {
+	horizontal := il.Direction == "LR" || il.Direction == "RL"
+	cross := func(node *NodeLayout) *float64 {
+		if horizontal {
+			return &node.Position.Y
+		}
+		return &node.Position.X
 	}
-
-	// Check for overlaps and adjust
-	for i := 0; i < len(nodes); i++ {
-		for j := i + 1; j < len(nodes); j++ {
-			if il.nodesOverlap(nodes[i], nodes[j]) {
-				// Push nodes apart
-				il.separateNodes(nodes[i], nodes[j], nodeWidth*0.2)
-			}
+	size := func(node *NodeLayout) float64 {
+		if horizontal {
+			return node.Height
 		}
+		return node.Width
 	}
-}
 
-// nodesOverlap checks if two nodes overlap
-func (il *ImprovedLayout) nodesOverlap(n1, n2 *NodeLayout) bool {
-	margin := 10.0 // Minimum space between nodes
+	oldMaxX, oldMaxY := il.extent()
 
-	return !(n1.Position.X+n1.Width+margin < n2.Position.X ||
-		n2.Position.X+n2.Width+margin < n1.Position.X ||
-		n1.Position.Y+n1.Height+margin < n2.Position.Y ||
-		n2.Position.Y+n2.Height+margin < n1.Position.Y)
-}
+	for _, nodes := range il.nodesByLayer {
+		if len(nodes) < 2 {
+			continue
+		}
+
+		ordered := append([]*NodeLayout(nil), nodes...)
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return *cross(ordered[i]) < *cross(ordered[j])
+		})
+
+		first, last := ordered[0], ordered[len(ordered)-1]
+		center := (*cross(first) + *cross(last) + size(last)) / 2
 
-// separateNodes moves nodes apart if they overlap
-func (il *ImprovedLayout) separateNodes(n1, n2 *NodeLayout, distance float64) {
-	// Calculate direction to move
-	dx := n2.Position.X - n1.Position.X
-	dy := n2.Position.Y - n1.Position.Y
-	dist := math.Sqrt(dx*dx + dy*dy)
+		// Pass 1: push each node past its predecessor plus the margin
+		for i := 1; i < len(ordered); i++ {
+			prev := ordered[i-1]
+			if minPos := *cross(prev) + size(prev) + overlapMargin; *cross(ordered[i]) < minPos {
+				*cross(ordered[i]) = minPos
+			}
+		}
 
-	if dist < 1.0 {
-		dist = 1.0
+		// Pass 2: re-center the layer, as pass 1 only moves nodes forward
+		shift := center - (*cross(first)+*cross(last)+size(last))/2
+		shift = math.Max(shift, -*cross(first))
+		for _, node := range ordered {
+			*cross(node) += shift
+		}
 	}
 
-	// Normalize and move
-	dx /= dist
-	dy /= dist
+	maxX, maxY := il.extent()
+	il.Width += maxX - oldMaxX
+	il.Height += maxY - oldMaxY
+}
 
-	n2.Position.X += dx * distance
-	n2.Position.Y += dy * distance
+// extent returns the largest right and bottom edge of the laid-out nodes
+func (il *ImprovedLayout) extent() (maxX, maxY float64) {
+	for _, node := range il.Nodes {
+		maxX = math.Max(maxX, node.Position.X+node.Width)
+		maxY = math.Max(maxY, node.Position.Y+node.Height)
+	}
+	return maxX, maxY
 }
 
 // calculateCurvedEdgePaths creates curved paths for edges
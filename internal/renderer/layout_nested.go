@@ -0,0 +1,234 @@
+package renderer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+)
+
+// Containment relationships drawn as nesting with LayoutOptions.NestContainers.
+// A "contains" edge points from the container to its member, "member_of" the other way.
+const (
+	relationshipContains = "contains"
+	relationshipMemberOf = "member_of"
+)
+
+// Geometry of nested containers
+const (
+	containerPadding = 20.0 // Space between a container's border and its members, and between members
+	containerHeader  = 40.0 // Height of the label band at the top of a container
+)
+
+// containmentParent returns the container and member of a containment edge, or false
+// for any other edge
+func containmentParent(edge *graph.Edge) (container, member *graph.Node, ok bool) {
+	switch edge.Relationship {
+	case relationshipContains:
+		return edge.From, edge.To, true
+	case relationshipMemberOf:
+		return edge.To, edge.From, true
+	}
+	return nil, nil, false
+}
+
+// containmentParents maps each nested node ID to the ID of its container. A node keeps
+// the first container found in edge order; edges that would make a node contain itself,
+// directly or through other containers, are ignored.
+func containmentParents(g *graph.Graph) map[string]string {
+	parents := make(map[string]string)
+	for _, edge := range g.Edges {
+		container, member, ok := containmentParent(edge)
+		if !ok || g.Nodes[container.ID] == nil || g.Nodes[member.ID] == nil {
+			continue
+		}
+		if _, nested := parents[member.ID]; nested {
+			continue
+		}
+
+		cycle := false
+		for id := container.ID; id != ""; id = parents[id] {
+			if id == member.ID {
+				cycle = true
+				break
+			}
+		}
+		if !cycle {
+			parents[member.ID] = container.ID
+		}
+	}
+	return parents
+}
+
+// nestedLayout lays out containment trees: members are arranged in a grid inside their
+// container, and the top-level nodes are laid out as one graph using the largest tree
+// as the cell size
+type nestedLayout struct {
+	parents    map[string]string
+	members    map[string][]string // Member IDs of each container, sorted
+	sizes      map[string]Point    // Width (X) and height (Y) of each node's box
+	nodeWidth  float64
+	nodeHeight float64
+}
+
+// calculateNestedLayout creates a layout like CalculateImprovedLayoutWithOptions in which
+// members of "contains"/"member_of" relationships are drawn inside their container's box
+// (NodeLayout.Parent) and the containment edges are left out. Edges between nodes of
+// different containment trees are laid out between the trees' top-level nodes and drawn
+// between the nodes themselves. It returns nil when g has no containment edges.
+func calculateNestedLayout(g *graph.Graph, direction string, nodeWidth, nodeHeight, hSpacing, vSpacing float64, opts LayoutOptions) *Layout {
+	parents := containmentParents(g)
+	if len(parents) == 0 {
+		return nil
+	}
+
+	nl := &nestedLayout{
+		parents:    parents,
+		members:    make(map[string][]string),
+		sizes:      make(map[string]Point),
+		nodeWidth:  nodeWidth,
+		nodeHeight: nodeHeight,
+	}
+	for member, container := range parents {
+		nl.members[container] = append(nl.members[container], member)
+	}
+	for _, members := range nl.members {
+		sort.Strings(members)
+	}
+
+	// Size every tree bottom-up; the top-level graph uses the largest box as its cell
+	topLevel := &graph.Graph{Nodes: make(map[string]*graph.Node)}
+	cellWidth, cellHeight := nodeWidth, nodeHeight
+	for id, node := range g.Nodes {
+		if _, nested := parents[id]; nested {
+			continue
+		}
+		topLevel.Nodes[id] = node
+		size := nl.measure(id)
+		cellWidth = math.Max(cellWidth, size.X)
+		cellHeight = math.Max(cellHeight, size.Y)
+	}
+
+	// Lift the remaining edges to the top-level nodes of their trees
+	drawn := &graph.Graph{Nodes: g.Nodes}
+	lifted := make(map[string]*graph.Edge)
+	liftedFrom := make(map[*graph.Edge]*graph.Edge)
+	for _, edge := range g.Edges {
+		if _, _, ok := containmentParent(edge); ok {
+			continue
+		}
+		drawn.Edges = append(drawn.Edges, edge)
+
+		from, to := nl.root(edge.From.ID), nl.root(edge.To.ID)
+		if from == to {
+			continue
+		}
+		key := from + "|" + to + "|" + edge.Relationship
+		if lifted[key] == nil {
+			lifted[key] = &graph.Edge{From: g.Nodes[from], To: g.Nodes[to], Relationship: edge.Relationship}
+			topLevel.Edges = append(topLevel.Edges, lifted[key])
+		}
+		liftedFrom[edge] = lifted[key]
+	}
+
+	layout := CalculateImprovedLayoutWithOptions(topLevel, direction, cellWidth, cellHeight, hSpacing, vSpacing, LayoutOptions{
+		EdgeStyle:           opts.EdgeStyle,
+		MaxLayers:           opts.MaxLayers,
+		SimpleEdgeThreshold: opts.SimpleEdgeThreshold,
+	})
+	feedback := make(map[*graph.Edge]bool)
+	for _, edgeLayout := range layout.Edges {
+		feedback[edgeLayout.Edge] = edgeLayout.Feedback
+	}
+
+	// Shrink each top-level cell to its tree's box, centered in the cell, then place the members
+	for id := range topLevel.Nodes {
+		nodeLayout := layout.Nodes[id]
+		size := nl.sizes[id]
+		nodeLayout.Position.X += (cellWidth - size.X) / 2
+		nodeLayout.Position.Y += (cellHeight - size.Y) / 2
+		nodeLayout.Width, nodeLayout.Height = size.X, size.Y
+		nl.place(layout, id)
+	}
+
+	router := newLayoutRouter(layout, nodeWidth, nodeHeight, opts, len(drawn.Edges))
+	layout.Edges = router.RouteEdges(drawn)
+	for _, edgeLayout := range layout.Edges {
+		edgeLayout.Feedback = feedback[liftedFrom[edgeLayout.Edge]]
+	}
+
+	return layout
+}
+
+// root returns the ID of the top-level node of id's containment tree
+func (nl *nestedLayout) root(id string) string {
+	for nl.parents[id] != "" {
+		id = nl.parents[id]
+	}
+	return id
+}
+
+// measure computes and records the box size of a node: a regular node for leaves, or
+// the header plus a padded grid of its members for containers
+func (nl *nestedLayout) measure(id string) Point {
+	members := nl.members[id]
+	if len(members) == 0 {
+		nl.sizes[id] = Point{X: nl.nodeWidth, Y: nl.nodeHeight}
+		return nl.sizes[id]
+	}
+
+	for _, member := range members {
+		nl.measure(member)
+	}
+	width, height := 0.0, 0.0
+	for _, row := range nl.rows(members) {
+		rowWidth, rowHeight := 0.0, 0.0
+		for _, member := range row {
+			rowWidth += nl.sizes[member].X + containerPadding
+			rowHeight = math.Max(rowHeight, nl.sizes[member].Y)
+		}
+		width = math.Max(width, rowWidth+containerPadding)
+		height += rowHeight + containerPadding
+	}
+
+	nl.sizes[id] = Point{
+		X: math.Max(width, nl.nodeWidth),
+		Y: containerHeader + height,
+	}
+	return nl.sizes[id]
+}
+
+// rows splits members into the rows of a near-square grid
+func (nl *nestedLayout) rows(members []string) [][]string {
+	columns := int(math.Ceil(math.Sqrt(float64(len(members)))))
+	var rows [][]string
+	for start := 0; start < len(members); start += columns {
+		end := min(start+columns, len(members))
+		rows = append(rows, members[start:end])
+	}
+	return rows
+}
+
+// place positions the members of a laid-out container inside its box, recursively
+func (nl *nestedLayout) place(layout *Layout, id string) {
+	container := layout.Nodes[id]
+	y := container.Position.Y + containerHeader
+	for _, row := range nl.rows(nl.members[id]) {
+		x := container.Position.X + containerPadding
+		rowHeight := 0.0
+		for _, member := range row {
+			size := nl.sizes[member]
+			layout.Nodes[member] = &NodeLayout{
+				Position: Point{X: x, Y: y},
+				Width:    size.X,
+				Height:   size.Y,
+				Layer:    container.Layer,
+				Parent:   id,
+			}
+			nl.place(layout, member)
+			x += size.X + containerPadding
+			rowHeight = math.Max(rowHeight, size.Y)
+		}
+		y += rowHeight + containerPadding
+	}
+}
@@ -0,0 +1,134 @@
+package renderer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+)
+
+// Layout algorithms selectable through RenderOptions.LayoutAlgorithm
+const (
+	LayoutAlgorithmLayered = "layered" // Hierarchical layers by dependency depth (default)
+	LayoutAlgorithmRadial  = "radial"  // Concentric rings around RenderOptions.LayoutCenter
+)
+
+// validLayoutAlgorithm reports whether algorithm is a supported layout algorithm (empty
+// selects the default)
+func validLayoutAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case "", LayoutAlgorithmLayered, LayoutAlgorithmRadial:
+		return true
+	}
+	return false
+}
+
+// CalculateRadialLayout creates a "spider" layout with the node centerID in the middle and
+// every other node on a concentric ring whose index (NodeLayout.Layer) is its distance from
+// the center, following edges in either direction. Nodes not connected to the center share
+// the outermost ring. The nodes of a ring are spread evenly around it, starting at the top,
+// in the order their inner neighbors were reached, and each ring is at least ringSpacing
+// clear of the previous one and wide enough for its nodes not to overlap. opts.MaxLayers and
+// opts.NestContainers are ignored. The layout is empty when centerID is not in g.
+func CalculateRadialLayout(g *graph.Graph, centerID string, nodeWidth, nodeHeight, ringSpacing float64, opts LayoutOptions) *Layout {
+	layout := &Layout{
+		Nodes:     make(map[string]*NodeLayout),
+		Edges:     []*EdgeLayout{},
+		Direction: "TB",
+	}
+	if g.Nodes[centerID] == nil {
+		return layout
+	}
+
+	// Distances ignore edge direction: dependencies and dependents are both impacted
+	neighbors := make(map[string][]string)
+	for _, edge := range g.Edges {
+		from, to := edge.From.ID, edge.To.ID
+		if from == to || g.Nodes[from] == nil || g.Nodes[to] == nil {
+			continue
+		}
+		neighbors[from] = append(neighbors[from], to)
+		neighbors[to] = append(neighbors[to], from)
+	}
+	for _, ids := range neighbors {
+		sort.Strings(ids)
+	}
+
+	// Breadth-first search, one ring per distance
+	rings := [][]string{{centerID}}
+	reached := map[string]bool{centerID: true}
+	for frontier := rings[0]; len(frontier) > 0; {
+		var next []string
+		for _, id := range frontier {
+			for _, neighbor := range neighbors[id] {
+				if !reached[neighbor] {
+					reached[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		if len(next) > 0 {
+			rings = append(rings, next)
+		}
+		frontier = next
+	}
+
+	var unreachable []string
+	for id := range g.Nodes {
+		if !reached[id] {
+			unreachable = append(unreachable, id)
+		}
+	}
+	if len(unreachable) > 0 {
+		sort.Strings(unreachable)
+		rings = append(rings, unreachable)
+	}
+
+	// A node's diagonal is the room it needs in any direction around its center
+	diagonal := math.Hypot(nodeWidth, nodeHeight)
+	radius := 0.0
+	for ring, ids := range rings {
+		if ring > 0 {
+			radius += diagonal + ringSpacing
+			if len(ids) > 1 {
+				// Adjacent centers must be a diagonal apart along the chord between them
+				radius = math.Max(radius, diagonal/(2*math.Sin(math.Pi/float64(len(ids)))))
+			}
+		}
+
+		for i, id := range ids {
+			angle := -math.Pi/2 + 2*math.Pi*float64(i)/float64(len(ids))
+			layout.Nodes[id] = &NodeLayout{
+				Node: g.Nodes[id],
+				Position: Point{
+					X: radius*math.Cos(angle) - nodeWidth/2,
+					Y: radius*math.Sin(angle) - nodeHeight/2,
+				},
+				Width:  nodeWidth,
+				Height: nodeHeight,
+				Layer:  ring,
+			}
+		}
+	}
+
+	// Move the rings' bounding box to the origin
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, nodeLayout := range layout.Nodes {
+		minX = math.Min(minX, nodeLayout.Position.X)
+		minY = math.Min(minY, nodeLayout.Position.Y)
+		maxX = math.Max(maxX, nodeLayout.Position.X+nodeLayout.Width)
+		maxY = math.Max(maxY, nodeLayout.Position.Y+nodeLayout.Height)
+	}
+	for _, nodeLayout := range layout.Nodes {
+		nodeLayout.Position.X -= minX
+		nodeLayout.Position.Y -= minY
+	}
+	layout.Width = maxX - minX
+	layout.Height = maxY - minY
+
+	router := newLayoutRouter(layout, nodeWidth, nodeHeight, opts, len(g.Edges))
+	layout.Edges = router.RouteEdges(g)
+
+	return layout
+}
@@ -1,6 +1,11 @@
 package renderer
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
@@ -253,6 +258,248 @@ func TestCalculateImprovedLayout_EdgePositions(t *testing.T) {
 	}
 }
 
+func TestCalculateImprovedLayoutWithOptions_EdgeStyle(t *testing.T) {
+	newTwoNodeGraph := func() *graph.Graph {
+		vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws"}
+		web := &graph.Node{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws"}
+		edge := &graph.Edge{From: vpc, To: web, Relationship: "contains"}
+		vpc.Edges = []*graph.Edge{edge}
+
+		return &graph.Graph{
+			Nodes: map[string]*graph.Node{vpc.ID: vpc, web.ID: web},
+			Edges: []*graph.Edge{edge},
+		}
+	}
+
+	isAxisAligned := func(points []Point) bool {
+		for i := 1; i < len(points); i++ {
+			if points[i].X != points[i-1].X && points[i].Y != points[i-1].Y {
+				return false
+			}
+		}
+		return true
+	}
+
+	tests := []struct {
+		name      string
+		edgeStyle string
+		check     func(t *testing.T, points []Point)
+	}{
+		{
+			name:      "straight",
+			edgeStyle: EdgeStyleStraight,
+			check: func(t *testing.T, points []Point) {
+				if len(points) != 2 {
+					t.Errorf("straight edge has %d points, want 2", len(points))
+				}
+			},
+		},
+		{
+			name:      "orthogonal",
+			edgeStyle: EdgeStyleOrthogonal,
+			check: func(t *testing.T, points []Point) {
+				if len(points) != 4 {
+					t.Errorf("orthogonal edge has %d points, want 4", len(points))
+				}
+				if !isAxisAligned(points) {
+					t.Errorf("orthogonal edge has a diagonal segment: %v", points)
+				}
+			},
+		},
+		{
+			name:      "curved",
+			edgeStyle: EdgeStyleCurved,
+			check: func(t *testing.T, points []Point) {
+				if len(points) <= 4 {
+					t.Errorf("curved edge has %d points, want a sampled curve", len(points))
+				}
+				if isAxisAligned(points) {
+					t.Errorf("curved edge should not be axis-aligned: %v", points)
+				}
+			},
+		},
+		{
+			name:      "default is curved",
+			edgeStyle: "",
+			check: func(t *testing.T, points []Point) {
+				if len(points) <= 4 {
+					t.Errorf("default edge has %d points, want a sampled curve", len(points))
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := CalculateImprovedLayoutWithOptions(newTwoNodeGraph(), "TB", 220.0, 160.0, 140.0, 120.0, LayoutOptions{EdgeStyle: tt.edgeStyle})
+
+			if len(layout.Edges) != 1 {
+				t.Fatalf("CalculateImprovedLayoutWithOptions() got %d edges, want 1", len(layout.Edges))
+			}
+			tt.check(t, layout.Edges[0].Points)
+		})
+	}
+}
+
+func TestCalculateImprovedLayoutWithOptions_DeepChain(t *testing.T) {
+	const depth = 25
+
+	// Build a 25-deep dependency chain: node0 -> node1 -> ... -> node24
+	g := &graph.Graph{Nodes: make(map[string]*graph.Node), Edges: []*graph.Edge{}}
+	var prev *graph.Node
+	for i := 0; i < depth; i++ {
+		node := &graph.Node{
+			ID:       fmt.Sprintf("aws_instance.node%d", i),
+			Type:     "aws_instance",
+			Name:     fmt.Sprintf("node%d", i),
+			Provider: "aws",
+		}
+		g.Nodes[node.ID] = node
+		if prev != nil {
+			edge := &graph.Edge{From: prev, To: node, Relationship: "depends_on"}
+			prev.Edges = append(prev.Edges, edge)
+			g.Edges = append(g.Edges, edge)
+		}
+		prev = node
+	}
+
+	tests := []struct {
+		name          string
+		maxLayers     int
+		wantLayers    int
+		wantTruncated bool
+	}{
+		{name: "limit derived from node count", maxLayers: 0, wantLayers: depth, wantTruncated: false},
+		{name: "explicit limit above depth", maxLayers: 30, wantLayers: depth, wantTruncated: false},
+		{name: "limit hit", maxLayers: 20, wantLayers: 20, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := CalculateImprovedLayoutWithOptions(g, "TB", 220.0, 160.0, 140.0, 120.0, LayoutOptions{MaxLayers: tt.maxLayers})
+
+			if len(layout.Nodes) != depth {
+				t.Fatalf("CalculateImprovedLayoutWithOptions() laid out %d nodes, want %d", len(layout.Nodes), depth)
+			}
+
+			layers := make(map[int]bool)
+			for _, node := range layout.Nodes {
+				layers[node.Layer] = true
+			}
+			if len(layers) != tt.wantLayers {
+				t.Errorf("CalculateImprovedLayoutWithOptions() used %d layers, want %d", len(layers), tt.wantLayers)
+			}
+			if layout.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", layout.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestCalculateImprovedLayout_Lanes(t *testing.T) {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws", ResourceType: parser.ResourceTypeNetwork}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{vpc.ID: vpc}, Edges: []*graph.Edge{}}
+	children := []*graph.Node{
+		{ID: "aws_instance.web0", Type: "aws_instance", Name: "web0", Provider: "aws", ResourceType: parser.ResourceTypeCompute},
+		{ID: "aws_instance.web1", Type: "aws_instance", Name: "web1", Provider: "aws", ResourceType: parser.ResourceTypeCompute},
+		{ID: "aws_ebs_volume.disk0", Type: "aws_ebs_volume", Name: "disk0", Provider: "aws", ResourceType: parser.ResourceTypeStorage},
+		{ID: "aws_ebs_volume.disk1", Type: "aws_ebs_volume", Name: "disk1", Provider: "aws", ResourceType: parser.ResourceTypeStorage},
+	}
+	for _, child := range children {
+		g.Nodes[child.ID] = child
+		g.Edges = append(g.Edges, &graph.Edge{From: vpc, To: child, Relationship: "contains"})
+	}
+
+	// gaps returns the space between neighboring nodes of the second layer, left to right
+	gaps := func(layout *Layout) []float64 {
+		layer := make([]*NodeLayout, 0, len(children))
+		for _, child := range children {
+			layer = append(layer, layout.Nodes[child.ID])
+		}
+		sort.Slice(layer, func(i, j int) bool { return layer[i].Position.X < layer[j].Position.X })
+		var result []float64
+		for i := 1; i < len(layer); i++ {
+			result = append(result, layer[i].Position.X-layer[i-1].Position.X-layer[i-1].Width)
+		}
+		return result
+	}
+
+	plain := CalculateImprovedLayoutWithOptions(g, "TB", 220.0, 160.0, 140.0, 120.0, LayoutOptions{})
+	if plain.Lanes != nil {
+		t.Errorf("layout without LaneBy has lanes %v", plain.Lanes)
+	}
+	plainGaps := gaps(plain)
+	for _, gap := range plainGaps {
+		if gap != plainGaps[0] {
+			t.Fatalf("layout without lanes has uneven gaps %v", plainGaps)
+		}
+	}
+
+	layout := CalculateImprovedLayoutWithOptions(g, "TB", 220.0, 160.0, 140.0, 120.0, LayoutOptions{LaneBy: LaneByType})
+	var labels []string
+	for _, lane := range layout.Lanes {
+		labels = append(labels, lane.Label)
+	}
+	if want := []string{"Network", "Compute", "Storage"}; fmt.Sprint(labels) != fmt.Sprint(want) {
+		t.Errorf("lanes = %v, want %v", labels, want)
+	}
+
+	// Compute and storage nodes share the layer but are a lane gap further apart
+	laneGaps := gaps(layout)
+	want := []float64{plainGaps[0], plainGaps[0] + laneGap, plainGaps[0]}
+	if fmt.Sprint(laneGaps) != fmt.Sprint(want) {
+		t.Errorf("gaps between second-layer nodes = %v, want %v", laneGaps, want)
+	}
+
+	for _, lane := range layout.Lanes {
+		for id, node := range layout.Nodes {
+			inLane := node.Position.X >= lane.Start && node.Position.X+node.Width <= lane.End
+			if wantIn := resourceTypeLabel(g.Nodes[id].ResourceType) == lane.Label; inLane != wantIn {
+				t.Errorf("node %s in lane %s = %v, want %v", id, lane.Label, inLane, wantIn)
+			}
+		}
+	}
+}
+
+func TestCalculateImprovedLayout_Cycle(t *testing.T) {
+	// Build a 3-node cycle: a -> b -> c -> a
+	g := &graph.Graph{Nodes: make(map[string]*graph.Node), Edges: []*graph.Edge{}}
+	for _, id := range []string{"a", "b", "c"} {
+		g.Nodes[id] = &graph.Node{ID: id, Type: "aws_instance", Name: id, Provider: "aws"}
+	}
+	for _, pair := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}} {
+		edge := &graph.Edge{From: g.Nodes[pair[0]], To: g.Nodes[pair[1]], Relationship: "depends_on"}
+		g.Nodes[pair[0]].Edges = append(g.Nodes[pair[0]].Edges, edge)
+		g.Edges = append(g.Edges, edge)
+	}
+
+	// The largest edge of the cycle (c->a) is removed, leaving the chain a -> b -> c
+	wantLayers := map[string]int{"a": 0, "b": 1, "c": 2}
+
+	for run := 0; run < 10; run++ {
+		layout := CalculateImprovedLayout(g, "TB", 220.0, 160.0, 140.0, 120.0)
+
+		if len(layout.Nodes) != len(wantLayers) {
+			t.Fatalf("run %d: CalculateImprovedLayout() laid out %d nodes, want %d", run, len(layout.Nodes), len(wantLayers))
+		}
+		for id, want := range wantLayers {
+			if got := layout.Nodes[id].Layer; got != want {
+				t.Errorf("run %d: node %s layer = %d, want %d", run, id, got, want)
+			}
+		}
+
+		if len(layout.Edges) != 3 {
+			t.Fatalf("run %d: CalculateImprovedLayout() routed %d edges, want 3", run, len(layout.Edges))
+		}
+		for _, edgeLayout := range layout.Edges {
+			wantFeedback := edgeLayout.Edge.From.ID == "c" && edgeLayout.Edge.To.ID == "a"
+			if edgeLayout.Feedback != wantFeedback {
+				t.Errorf("run %d: edge %s Feedback = %v, want %v", run, edgeKey(edgeLayout.Edge), edgeLayout.Feedback, wantFeedback)
+			}
+		}
+	}
+}
+
 func TestCalculateImprovedLayout_LayerAssignment(t *testing.T) {
 	// Test topological sorting creates layers
 	vpc := &graph.Node{
@@ -308,3 +555,294 @@ func TestCalculateImprovedLayout_LayerAssignment(t *testing.T) {
 		t.Error("CalculateImprovedLayout() should create multiple layers for dependent nodes")
 	}
 }
+
+func TestResolveOverlaps(t *testing.T) {
+	for _, direction := range []string{"TB", "LR"} {
+		t.Run(direction, func(t *testing.T) {
+			horizontal := direction == "LR"
+			layout := &Layout{Nodes: make(map[string]*NodeLayout), Direction: direction, Width: 800, Height: 800}
+			il := &ImprovedLayout{Layout: layout, nodesByLayer: make(map[int][]*NodeLayout)}
+
+			// Three nodes stacked on almost the same spot in layer 0, one lone node in layer 1
+			place := func(id string, layer int, crossPos, layerPos float64) {
+				node := &NodeLayout{Width: 100, Height: 100, Layer: layer}
+				if horizontal {
+					node.Position = Point{X: layerPos, Y: crossPos}
+				} else {
+					node.Position = Point{X: crossPos, Y: layerPos}
+				}
+				layout.Nodes[id] = node
+				il.nodesByLayer[layer] = append(il.nodesByLayer[layer], node)
+			}
+			place("a", 0, 300, 0)
+			place("b", 0, 310, 0)
+			place("c", 0, 320, 0)
+			place("d", 1, 300, 250)
+
+			layerPos := make(map[string]float64)
+			for id, node := range layout.Nodes {
+				layerPos[id] = node.Position.Y
+				if horizontal {
+					layerPos[id] = node.Position.X
+				}
+			}
+
+			il.resolveOverlaps()
+
+			for id, node := range layout.Nodes {
+				got := node.Position.Y
+				if horizontal {
+					got = node.Position.X
+				}
+				if got != layerPos[id] {
+					t.Errorf("node %s moved out of its layer: layer-axis position %v, want %v", id, got, layerPos[id])
+				}
+			}
+
+			for _, pair := range [][2]string{{"a", "b"}, {"b", "c"}, {"a", "c"}} {
+				n1, n2 := layout.Nodes[pair[0]], layout.Nodes[pair[1]]
+				start1, start2 := n1.Position.X, n2.Position.X
+				if horizontal {
+					start1, start2 = n1.Position.Y, n2.Position.Y
+				}
+				if gap := math.Abs(start2-start1) - 100; gap < overlapMargin {
+					t.Errorf("nodes %s and %s are %v apart, want at least %v", pair[0], pair[1], gap, overlapMargin)
+				}
+			}
+
+			// The lone node is untouched and the layer stays centered on its original span
+			if d := layout.Nodes["d"]; d.Position != (Point{X: 300, Y: 250}) && d.Position != (Point{X: 250, Y: 300}) {
+				t.Errorf("lone node d moved to %v", d.Position)
+			}
+			a, c := layout.Nodes["a"].Position, layout.Nodes["c"].Position
+			center := (a.X + c.X + 100) / 2
+			if horizontal {
+				center = (a.Y + c.Y + 100) / 2
+			}
+			if center != 360 {
+				t.Errorf("layer center = %v, want 360", center)
+			}
+
+			// Layer 0 now spans 200..520, so the cross-axis dimension grows by 100
+			wantWidth, wantHeight := 900.0, 800.0
+			if horizontal {
+				wantWidth, wantHeight = wantHeight, wantWidth
+			}
+			if layout.Width != wantWidth || layout.Height != wantHeight {
+				t.Errorf("layout size = %vx%v, want %vx%v", layout.Width, layout.Height, wantWidth, wantHeight)
+			}
+		})
+	}
+}
+
+func TestCalculateImprovedLayout_RootOrder(t *testing.T) {
+	node := func(id, resourceType, name string, rt parser.ResourceType) *graph.Node {
+		return &graph.Node{ID: id, Type: resourceType, Name: name, Provider: "aws", ResourceType: rt, Edges: []*graph.Edge{}}
+	}
+	// Names sort compute before network, so only the type priority puts the VPC first
+	app := node("aws_instance.app", "aws_instance", "a-app", parser.ResourceTypeCompute)
+	sg := node("aws_security_group.web", "aws_security_group", "m-web", parser.ResourceTypeSecurity)
+	vpc := node("aws_vpc.main", "aws_vpc", "z-main", parser.ResourceTypeNetwork)
+	disk := node("aws_ebs_volume.data", "aws_ebs_volume", "data", parser.ResourceTypeStorage)
+
+	g := &graph.Graph{Nodes: map[string]*graph.Node{}, Edges: []*graph.Edge{}}
+	for _, n := range []*graph.Node{app, sg, vpc, disk} {
+		g.Nodes[n.ID] = n
+	}
+	// Only the compute root has a child; reordering the root layer by barycenter
+	// would pull it ahead of the childless security group
+	edge := &graph.Edge{From: app, To: disk, Relationship: "uses_storage"}
+	app.Edges = append(app.Edges, edge)
+	g.Edges = append(g.Edges, edge)
+
+	for run := 0; run < 5; run++ {
+		layout := CalculateImprovedLayout(g, "TB", 220.0, 160.0, 140.0, 120.0)
+
+		order := []string{vpc.ID, sg.ID, app.ID}
+		for i, id := range order {
+			if layout.Nodes[id].Layer != 0 {
+				t.Fatalf("%s is in layer %d, want root layer 0", id, layout.Nodes[id].Layer)
+			}
+			if i > 0 && layout.Nodes[order[i-1]].Position.X >= layout.Nodes[id].Position.X {
+				t.Errorf("run %d: root %s is not left of %s", run, order[i-1], id)
+			}
+		}
+	}
+}
+
+func TestCalculateRadialLayout(t *testing.T) {
+	node := func(id string) *graph.Node {
+		return &graph.Node{ID: id, Type: "aws_instance", Name: id, Provider: "aws", Edges: []*graph.Edge{}}
+	}
+	center, web, api, db, orphan := node("center"), node("web"), node("api"), node("db"), node("orphan")
+
+	g := &graph.Graph{Nodes: map[string]*graph.Node{}, Edges: []*graph.Edge{}}
+	for _, n := range []*graph.Node{center, web, api, db, orphan} {
+		g.Nodes[n.ID] = n
+	}
+	// Distances ignore direction: web depends on the center, the center on api
+	for _, e := range []*graph.Edge{
+		{From: web, To: center, Relationship: "depends_on"},
+		{From: center, To: api, Relationship: "depends_on"},
+		{From: api, To: db, Relationship: "uses_storage"},
+	} {
+		e.From.Edges = append(e.From.Edges, e)
+		g.Edges = append(g.Edges, e)
+	}
+
+	layout := CalculateRadialLayout(g, center.ID, 220.0, 160.0, 140.0, LayoutOptions{})
+
+	wantLayers := map[string]int{"center": 0, "web": 1, "api": 1, "db": 2, "orphan": 3}
+	for id, want := range wantLayers {
+		if got := layout.Nodes[id].Layer; got != want {
+			t.Errorf("%s is in ring %d, want %d", id, got, want)
+		}
+	}
+
+	centerOf := func(id string) Point {
+		nl := layout.Nodes[id]
+		return Point{X: nl.Position.X + nl.Width/2, Y: nl.Position.Y + nl.Height/2}
+	}
+	radius := func(id string) float64 {
+		c, p := centerOf(center.ID), centerOf(id)
+		return math.Hypot(p.X-c.X, p.Y-c.Y)
+	}
+
+	if math.Abs(radius("web")-radius("api")) > 1e-6 {
+		t.Errorf("distance-1 radii differ: web %v, api %v", radius("web"), radius("api"))
+	}
+	if radius("db") <= radius("web") {
+		t.Errorf("distance-2 radius %v is not outside distance-1 radius %v", radius("db"), radius("web"))
+	}
+	if radius("orphan") <= radius("db") {
+		t.Errorf("unreachable radius %v is not outside distance-2 radius %v", radius("orphan"), radius("db"))
+	}
+	if len(layout.Edges) != 3 {
+		t.Errorf("layout has %d edges, want 3", len(layout.Edges))
+	}
+
+	for _, nl := range layout.Nodes {
+		if nl.Position.X < 0 || nl.Position.Y < 0 || nl.Position.X+nl.Width > layout.Width || nl.Position.Y+nl.Height > layout.Height {
+			t.Errorf("node %s at %+v lies outside the %vx%v layout", nl.Node.ID, nl.Position, layout.Width, layout.Height)
+		}
+	}
+
+	if empty := CalculateRadialLayout(g, "missing", 220.0, 160.0, 140.0, LayoutOptions{}); len(empty.Nodes) != 0 {
+		t.Errorf("layout around a missing center has %d nodes, want 0", len(empty.Nodes))
+	}
+}
+
+func TestRouteEdges_DistributesSourcePoints(t *testing.T) {
+	lb := &graph.Node{ID: "aws_lb.front", Type: "aws_lb", Name: "front", Provider: "aws"}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{lb.ID: lb}, Edges: []*graph.Edge{}}
+	for i := 0; i < 4; i++ {
+		web := &graph.Node{ID: fmt.Sprintf("aws_instance.web%d", i), Type: "aws_instance", Name: fmt.Sprintf("web%d", i), Provider: "aws"}
+		edge := &graph.Edge{From: lb, To: web, Relationship: "routes_to"}
+		lb.Edges = append(lb.Edges, edge)
+		g.Nodes[web.ID] = web
+		g.Edges = append(g.Edges, edge)
+	}
+
+	for _, direction := range []string{"LR", "TB"} {
+		t.Run(direction, func(t *testing.T) {
+			layout := CalculateImprovedLayout(g, direction, 220.0, 160.0, 140.0, 120.0)
+			if len(layout.Edges) != 4 {
+				t.Fatalf("got %d edges, want 4", len(layout.Edges))
+			}
+
+			source := layout.Nodes[lb.ID]
+			starts := make(map[Point]string)
+			for _, edgeLayout := range layout.Edges {
+				start := edgeLayout.Points[0]
+				if other, ok := starts[start]; ok {
+					t.Errorf("edges to %s and %s both leave from %v", other, edgeLayout.Edge.To.ID, start)
+				}
+				starts[start] = edgeLayout.Edge.To.ID
+
+				// Every edge leaves from the source's exit side
+				exit := start.Y == source.Position.Y+source.Height
+				if direction == "LR" {
+					exit = start.X == source.Position.X+source.Width
+				}
+				if !exit {
+					t.Errorf("edge to %s leaves from %v, not the exit side of %+v", edgeLayout.Edge.To.ID, start, source.Position)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteEdges_BundleSharedTargets(t *testing.T) {
+	lb := &graph.Node{ID: "aws_lb.front", Type: "aws_lb", Name: "front", Provider: "aws"}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{lb.ID: lb}, Edges: []*graph.Edge{}}
+	for i := 0; i < 4; i++ {
+		web := &graph.Node{ID: fmt.Sprintf("aws_instance.web%d", i), Type: "aws_instance", Name: fmt.Sprintf("web%d", i), Provider: "aws"}
+		edge := &graph.Edge{From: web, To: lb, Relationship: "registered_with"}
+		web.Edges = append(web.Edges, edge)
+		g.Nodes[web.ID] = web
+		g.Edges = append(g.Edges, edge)
+	}
+
+	for _, direction := range []string{"LR", "TB"} {
+		t.Run(direction, func(t *testing.T) {
+			layout := CalculateImprovedLayoutWithOptions(g, direction, 220.0, 160.0, 140.0, 120.0, LayoutOptions{BundleSharedTargets: true})
+			if len(layout.Edges) != 4 {
+				t.Fatalf("got %d edges, want 4", len(layout.Edges))
+			}
+
+			junction := layout.Edges[0].Junction
+			if junction == nil {
+				t.Fatal("fan-in edges are not routed through a junction")
+			}
+			end := layout.Edges[0].Points[len(layout.Edges[0].Points)-1]
+			target := layout.Nodes[lb.ID]
+			if got := math.Hypot(end.X-junction.X, end.Y-junction.Y); got != junctionDistance {
+				t.Errorf("junction %v is %.2f from the entry point %v, want %.2f", *junction, got, end, junctionDistance)
+			}
+			if junction.X >= target.Position.X && junction.X <= target.Position.X+target.Width &&
+				junction.Y >= target.Position.Y && junction.Y <= target.Position.Y+target.Height {
+				t.Errorf("junction %v lies inside the target %+v", *junction, target.Position)
+			}
+
+			starts := make(map[Point]bool)
+			for _, edgeLayout := range layout.Edges {
+				if edgeLayout.Junction == nil || *edgeLayout.Junction != *junction {
+					t.Errorf("edge from %s uses junction %v, want the shared %v", edgeLayout.Edge.From.ID, edgeLayout.Junction, *junction)
+				}
+				points := edgeLayout.Points
+				if len(points) < 4 || points[len(points)-2] != *junction {
+					t.Errorf("edge from %s does not pass through the junction %v: %v", edgeLayout.Edge.From.ID, *junction, points)
+				}
+				if last := points[len(points)-1]; last != end {
+					t.Errorf("edge from %s ends at %v, want the shared trunk end %v", edgeLayout.Edge.From.ID, last, end)
+				}
+				starts[points[0]] = true
+			}
+			if len(starts) != 4 {
+				t.Errorf("edges leave from %d distinct points, want 4 branches", len(starts))
+			}
+
+			// Without the option every edge keeps its own entry point
+			unbundled := CalculateImprovedLayout(g, direction, 220.0, 160.0, 140.0, 120.0)
+			ends := make(map[Point]bool)
+			for _, edgeLayout := range unbundled.Edges {
+				if edgeLayout.Junction != nil {
+					t.Errorf("edge from %s has a junction without BundleSharedTargets", edgeLayout.Edge.From.ID)
+				}
+				ends[edgeLayout.Points[len(edgeLayout.Points)-1]] = true
+			}
+			if len(ends) != 4 {
+				t.Errorf("unbundled edges enter at %d distinct points, want 4", len(ends))
+			}
+		})
+	}
+
+	svg, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", BundleSharedTargets: true})
+	if err != nil {
+		t.Fatalf("renderGraph failed: %v", err)
+	}
+	assertWellFormedSVG(t, svg)
+	if got := strings.Count(string(svg), `class="junction"`); got != 1 {
+		t.Errorf("SVG draws %d junctions, want 1", got)
+	}
+}
@@ -0,0 +1,234 @@
+package renderer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
+)
+
+// Strategies for handling graphs larger than RenderOptions.MaxNodes
+const (
+	OnExceedError    = "error"    // Fail with an error describing the limit
+	OnExceedCollapse = "collapse" // Collapse leaf nodes into "N more" aggregate nodes
+)
+
+// leafGroup holds the leaf nodes attached to a single parent node
+type leafGroup struct {
+	parent *graph.Node
+	leaves []*graph.Node
+	edges  []*graph.Edge
+}
+
+// applyNodeLimit enforces opts.MaxNodes on the graph.
+// Graphs within the limit (or with no limit set) are returned unchanged.
+// With the collapse strategy, a new graph is returned and g is not modified.
+func applyNodeLimit(g *graph.Graph, opts RenderOptions) (*graph.Graph, error) {
+	if opts.MaxNodes <= 0 || len(g.Nodes) <= opts.MaxNodes {
+		return g, nil
+	}
+
+	switch opts.OnExceed {
+	case OnExceedError:
+		return nil, fmt.Errorf("graph has %d nodes, exceeding the maximum of %d", len(g.Nodes), opts.MaxNodes)
+	case "", OnExceedCollapse:
+		return collapseLeafNodes(g, opts.MaxNodes)
+	default:
+		return nil, fmt.Errorf("unsupported on_exceed strategy: %s (must be %q or %q)", opts.OnExceed, OnExceedError, OnExceedCollapse)
+	}
+}
+
+// collapseLeafNodes replaces groups of leaf nodes (nodes connected to exactly one
+// other node) with a single aggregate node per parent, largest groups first,
+// until the graph has at most maxNodes nodes.
+func collapseLeafNodes(g *graph.Graph, maxNodes int) (*graph.Graph, error) {
+	groups := findLeafGroups(g)
+
+	collapsed := make(map[string]*leafGroup)
+	nodeCount := len(g.Nodes)
+	for _, group := range groups {
+		if nodeCount <= maxNodes {
+			break
+		}
+		collapsed[group.parent.ID] = group
+		nodeCount -= len(group.leaves) - 1
+	}
+
+	if nodeCount > maxNodes {
+		return nil, fmt.Errorf("graph has %d nodes, exceeding the maximum of %d even after collapsing leaf nodes (%d remain)", len(g.Nodes), maxNodes, nodeCount)
+	}
+
+	return buildCollapsedGraph(g, collapsed), nil
+}
+
+// findLeafGroups groups leaf nodes by their single neighbor.
+// Only groups with at least two leaves are returned, since collapsing a single
+// leaf does not reduce the node count. Groups are sorted largest first.
+func findLeafGroups(g *graph.Graph) []*leafGroup {
+	neighbors := make(map[string]map[string]bool)
+	for _, edge := range g.Edges {
+		if edge.From.ID == edge.To.ID {
+			continue
+		}
+		if neighbors[edge.From.ID] == nil {
+			neighbors[edge.From.ID] = make(map[string]bool)
+		}
+		if neighbors[edge.To.ID] == nil {
+			neighbors[edge.To.ID] = make(map[string]bool)
+		}
+		neighbors[edge.From.ID][edge.To.ID] = true
+		neighbors[edge.To.ID][edge.From.ID] = true
+	}
+
+	isLeaf := func(id string) bool {
+		return len(neighbors[id]) == 1
+	}
+
+	byParent := make(map[string]*leafGroup)
+	for _, edge := range g.Edges {
+		var leaf, parent *graph.Node
+		switch {
+		case isLeaf(edge.From.ID) && !isLeaf(edge.To.ID):
+			leaf, parent = edge.From, edge.To
+		case isLeaf(edge.To.ID) && !isLeaf(edge.From.ID):
+			leaf, parent = edge.To, edge.From
+		default:
+			continue
+		}
+
+		group := byParent[parent.ID]
+		if group == nil {
+			group = &leafGroup{parent: parent}
+			byParent[parent.ID] = group
+		}
+		if !containsNode(group.leaves, leaf) {
+			group.leaves = append(group.leaves, leaf)
+		}
+		group.edges = append(group.edges, edge)
+	}
+
+	groups := make([]*leafGroup, 0, len(byParent))
+	for _, group := range byParent {
+		if len(group.leaves) >= 2 {
+			groups = append(groups, group)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].leaves) != len(groups[j].leaves) {
+			return len(groups[i].leaves) > len(groups[j].leaves)
+		}
+		return groups[i].parent.ID < groups[j].parent.ID
+	})
+
+	return groups
+}
+
+// buildCollapsedGraph copies g, replacing each collapsed group's leaves with an aggregate node
+func buildCollapsedGraph(g *graph.Graph, collapsed map[string]*leafGroup) *graph.Graph {
+	removed := make(map[string]bool)
+	for _, group := range collapsed {
+		for _, leaf := range group.leaves {
+			removed[leaf.ID] = true
+		}
+	}
+
+	result := &graph.Graph{
+		Nodes: make(map[string]*graph.Node, len(g.Nodes)),
+		Edges: make([]*graph.Edge, 0, len(g.Edges)),
+	}
+
+	// Shallow-copy surviving nodes so edge lists can be rebuilt without touching g
+	for id, node := range g.Nodes {
+		if removed[id] {
+			continue
+		}
+		nodeCopy := *node
+		nodeCopy.Edges = make([]*graph.Edge, 0, len(node.Edges))
+		result.Nodes[id] = &nodeCopy
+	}
+
+	addEdge := func(from, to *graph.Node, relationship string, metadata map[string]string) {
+		edge := &graph.Edge{
+			From:         from,
+			To:           to,
+			Relationship: relationship,
+			Metadata:     metadata,
+		}
+		result.Edges = append(result.Edges, edge)
+		from.Edges = append(from.Edges, edge)
+	}
+
+	for _, edge := range g.Edges {
+		if removed[edge.From.ID] || removed[edge.To.ID] {
+			continue
+		}
+		addEdge(result.Nodes[edge.From.ID], result.Nodes[edge.To.ID], edge.Relationship, edge.Metadata)
+	}
+
+	// Add one aggregate node per collapsed group, keeping the direction of the original edges
+	parentIDs := make([]string, 0, len(collapsed))
+	for id := range collapsed {
+		parentIDs = append(parentIDs, id)
+	}
+	sort.Strings(parentIDs)
+
+	for _, parentID := range parentIDs {
+		group := collapsed[parentID]
+		aggregate := newAggregateNode(group)
+		result.Nodes[aggregate.ID] = aggregate
+
+		parent := result.Nodes[parentID]
+		first := group.edges[0]
+		count := 0
+		for _, edge := range group.edges {
+			count += edgeCount(edge)
+		}
+		metadata := map[string]string{"count": fmt.Sprintf("%d", count)}
+		if first.To.ID == parentID {
+			addEdge(aggregate, parent, first.Relationship, metadata)
+		} else {
+			addEdge(parent, aggregate, first.Relationship, metadata)
+		}
+	}
+
+	return result
+}
+
+// newAggregateNode creates the "N more" node standing in for a group of leaves.
+// The aggregate keeps the leaves' type when they all share one.
+func newAggregateNode(group *leafGroup) *graph.Node {
+	resourceType := group.leaves[0].Type
+	category := group.leaves[0].ResourceType
+	provider := group.leaves[0].Provider
+	for _, leaf := range group.leaves[1:] {
+		if leaf.Type != resourceType {
+			resourceType = "collapsed"
+			category = parser.ResourceTypeUnknown
+		}
+		if leaf.Provider != provider {
+			provider = group.parent.Provider
+		}
+	}
+
+	return &graph.Node{
+		ID:           group.parent.ID + ".collapsed",
+		Type:         resourceType,
+		Name:         fmt.Sprintf("%d more", len(group.leaves)),
+		Provider:     provider,
+		ResourceType: category,
+		Attributes:   map[string]interface{}{},
+		Edges:        make([]*graph.Edge, 0),
+	}
+}
+
+// containsNode reports whether nodes contains n
+func containsNode(nodes []*graph.Node, n *graph.Node) bool {
+	for _, existing := range nodes {
+		if existing == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
+)
+
+// outputNodeType is the Type of the note nodes drawn for Terraform outputs
+const outputNodeType = "output"
+
+// outputRelationship connects an output note to the resources its value belongs to
+const outputRelationship = "annotates"
+
+// withOutputNodes returns a copy of g with a note node "output.<name>" per output,
+// connected to every node with an identifying attribute equal to the output's value (or
+// to one of its elements, for lists). Sensitive outputs are never matched and their
+// value is not shown.
+func withOutputNodes(g *graph.Graph, outputs []parser.Output) *graph.Graph {
+	result := &graph.Graph{
+		Nodes: make(map[string]*graph.Node, len(g.Nodes)+len(outputs)),
+		Edges: append([]*graph.Edge(nil), g.Edges...),
+	}
+	nodeIDs := make([]string, 0, len(g.Nodes))
+	for id, node := range g.Nodes {
+		result.Nodes[id] = node
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	for _, output := range outputs {
+		note := &graph.Node{
+			ID:         "output." + output.Name,
+			Type:       outputNodeType,
+			Name:       output.Name,
+			Attributes: map[string]interface{}{"value": formatOutputValue(output)},
+		}
+		result.Nodes[note.ID] = note
+
+		values := outputMatchValues(output)
+		if len(values) == 0 {
+			continue
+		}
+		for _, id := range nodeIDs {
+			if !hasAttributeValue(g.Nodes[id], values) {
+				continue
+			}
+			edge := &graph.Edge{From: note, To: g.Nodes[id], Relationship: outputRelationship}
+			note.Edges = append(note.Edges, edge)
+			result.Edges = append(result.Edges, edge)
+		}
+	}
+
+	return result
+}
+
+// formatOutputValue returns the text shown for an output's value: strings as they are,
+// other values as JSON
+func formatOutputValue(output parser.Output) string {
+	if output.Sensitive {
+		return "(sensitive)"
+	}
+	if s, ok := output.Value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(output.Value)
+	if err != nil {
+		return fmt.Sprint(output.Value)
+	}
+	return string(data)
+}
+
+// outputMatchValues returns the non-empty strings of an output's value that identify the
+// resources it refers to
+func outputMatchValues(output parser.Output) map[string]bool {
+	if output.Sensitive {
+		return nil
+	}
+
+	values := make(map[string]bool)
+	add := func(value interface{}) {
+		if s, ok := value.(string); ok && s != "" {
+			values[s] = true
+		}
+	}
+	if list, ok := output.Value.([]interface{}); ok {
+		for _, element := range list {
+			add(element)
+		}
+	} else {
+		add(output.Value)
+	}
+	return values
+}
+
+// identifyingAttributes are the attributes whose values refer to a single resource.
+// Other attributes, such as region or enabled flags, share their values across many
+// resources and would connect an output to all of them.
+var identifyingAttributes = []string{
+	"id", "arn", "self_link", "bucket",
+	"dns_name", "fqdn", "domain_name", "hostname", "endpoint", "address", "url", "invoke_url",
+	"public_ip", "private_ip", "ip_address",
+}
+
+// hasAttributeValue reports whether one of the node's identifying attributes is in values
+func hasAttributeValue(node *graph.Node, values map[string]bool) bool {
+	for _, key := range identifyingAttributes {
+		if s, ok := node.Attributes[key].(string); ok && values[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// renderOutputNode draws an output as a sticky note with a folded corner, showing the
+// output's name above its value (side by side for compact nodes)
+func (r *SVGRenderer) renderOutputNode(node *NodeLayout, x, y float64) {
+	fold := math.Min(18, node.Height/3)
+	name := escapeXML(truncate(node.Node.Name, r.options.MaxLabelLength))
+	value, _ := node.Node.Attributes["value"].(string)
+	value = escapeXML(truncate(value, r.options.MaxTypeLength))
+	centerX, centerY := x+node.Width/2, y+node.Height/2
+
+	r.buf.WriteString(fmt.Sprintf(`
+<!-- Output: %s -->
+<g %s>
+  <path d="M %.2f,%.2f H %.2f L %.2f,%.2f V %.2f H %.2f Z"
+        fill="#fff9db" stroke="#f0c419" stroke-width="1.5" filter="url(#nodeShadow)"/>
+  <path d="M %.2f,%.2f V %.2f H %.2f" fill="#ffec99" stroke="#f0c419" stroke-width="1.5"/>
+`,
+		escapeComment(node.Node.Name),
+		r.nodeGroupAttributes(node),
+		x, y, x+node.Width-fold, x+node.Width, y+fold, y+node.Height, x,
+		x+node.Width-fold, y, y+fold, x+node.Width))
+
+	if r.options.Compact {
+		r.buf.WriteString(fmt.Sprintf(`  <text x="%.2f" y="%.2f" font-family="%s" font-size="12" fill="#2c3e50" text-anchor="middle"><tspan font-weight="600">%s</tspan> = %s</text>
+`, centerX, centerY+4, r.fontFamily(), name, value))
+	} else {
+		r.buf.WriteString(fmt.Sprintf(`  <text x="%.2f" y="%.2f" font-family="%s" font-size="11" fill="#868e96" text-anchor="middle">output</text>
+  <text x="%.2f" y="%.2f" font-family="%s" font-size="14" font-weight="600" fill="#2c3e50" text-anchor="middle">%s</text>
+  <text x="%.2f" y="%.2f" font-family="monospace" font-size="12" fill="#495057" text-anchor="middle">%s</text>
+`, centerX, centerY-22, r.fontFamily(), centerX, centerY, r.fontFamily(), name, centerX, centerY+20, value))
+	}
+
+	r.buf.WriteString("</g>\n")
+}
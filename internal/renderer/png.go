@@ -6,6 +6,7 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"image/jpeg"
 	"image/png"
 	"math"
 	"strings"
@@ -16,7 +17,15 @@ import (
 	"golang.org/x/image/math/fixed"
 )
 
-// PNGRenderer handles PNG generation
+// defaultJPEGQuality is the JPEG quality used when RenderOptions.JPEGQuality is unset
+const defaultJPEGQuality = 95
+
+// validJPEGQuality reports whether quality is unset (0) or within JPEG's 1-100 range
+func validJPEGQuality(quality int) bool {
+	return quality >= 0 && quality <= 100
+}
+
+// PNGRenderer handles PNG generation, or JPEG when RenderOptions.Format is "jpg" or "jpeg"
 type PNGRenderer struct {
 	img     *image.RGBA
 	options RenderOptions
@@ -31,10 +40,24 @@ func NewPNGRenderer(opts RenderOptions) *PNGRenderer {
 
 // Render generates PNG from the layout
 func (r *PNGRenderer) Render(layout *Layout, g *graph.Graph) ([]byte, error) {
+	r.RenderImage(layout, g)
+
+	return r.encode()
+}
+
+// RenderImage draws the layout and returns the image without encoding it
+func (r *PNGRenderer) RenderImage(layout *Layout, g *graph.Graph) *image.RGBA {
 	// Add padding
 	padding := 50.0
 	width := int(layout.Width + 2*padding)
 	height := int(layout.Height + 2*padding)
+	if len(g.Nodes) == 0 {
+		width = int(math.Max(float64(width), emptyDiagramWidth))
+		height = int(math.Max(float64(height), emptyDiagramHeight))
+	}
+	if r.options.ShowSummary {
+		height += int(summaryHeight)
+	}
 
 	// Create image
 	r.img = image.NewRGBA(image.Rect(0, 0, width, height))
@@ -47,6 +70,10 @@ func (r *PNGRenderer) Render(layout *Layout, g *graph.Graph) ([]byte, error) {
 		r.drawTitle(r.options.Title, width, int(padding))
 	}
 
+	if len(g.Nodes) == 0 {
+		r.drawText(emptyDiagramMessage, width/2, height/2, color.Gray{Y: 0x6c})
+	}
+
 	// Render edges first (so they appear below nodes)
 	for _, edgeLayout := range layout.Edges {
 		r.renderEdge(edgeLayout, padding)
@@ -61,10 +88,34 @@ func (r *PNGRenderer) Render(layout *Layout, g *graph.Graph) ([]byte, error) {
 		}
 	}
 
-	// Encode to PNG
+	// Summary footer sits in the reserved space above the bottom padding
+	if r.options.ShowSummary {
+		r.drawText(formatSummary(g), width/2, height-int(padding), color.Gray{Y: 0x6c})
+	}
+
+	return r.img
+}
+
+// encode encodes the drawn image as JPEG or PNG, depending on the format option
+func (r *PNGRenderer) encode() ([]byte, error) {
 	buf := &bytes.Buffer{}
-	if err := png.Encode(buf, r.img); err != nil {
-		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	switch strings.ToLower(r.options.Format) {
+	case "jpg", "jpeg":
+		if !validJPEGQuality(r.options.JPEGQuality) {
+			return nil, fmt.Errorf("invalid JPEG quality: %d (must be between 1 and 100)", r.options.JPEGQuality)
+		}
+		quality := r.options.JPEGQuality
+		if quality == 0 {
+			quality = defaultJPEGQuality
+		}
+		if err := jpeg.Encode(buf, r.img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+	default:
+		encoder := &png.Encoder{CompressionLevel: r.options.PNGCompression}
+		if err := encoder.Encode(buf, r.img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
 	}
 
 	return buf.Bytes(), nil
@@ -149,8 +200,8 @@ func (r *PNGRenderer) renderEdge(edge *EdgeLayout, padding float64) {
 	)
 
 	// Draw edge label if present
-	if r.options.IncludeLabels {
-		label := formatEdgeLabel(edge.Edge)
+	if r.options.IncludeLabels && shouldLabelEdge(edge.Edge, r.options.LabelRelationships) {
+		label := formatEdgeLabel(edge.Edge, r.options.RelationshipLabels)
 		if label != "" {
 			midIdx := len(edge.Points) / 2
 			midX := int(edge.Points[midIdx].X + padding)
@@ -163,12 +214,12 @@ func (r *PNGRenderer) renderEdge(edge *EdgeLayout, padding float64) {
 // drawNodeLabel draws the node label text
 func (r *PNGRenderer) drawNodeLabel(node *graph.Node, centerX, centerY int) {
 	// Node name
-	name := truncate(node.Name, 20)
+	name := truncate(node.Name, r.options.MaxLabelLength)
 	r.drawText(name, centerX, centerY-10, color.White)
 
 	// Resource type
 	typeName := getResourceTypeName(node.Type)
-	typeName = truncate(typeName, 25)
+	typeName = truncate(typeName, r.options.MaxTypeLength)
 	r.drawText(typeName, centerX, centerY+5, color.RGBA{200, 200, 200, 255})
 }
 
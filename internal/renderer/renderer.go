@@ -5,22 +5,203 @@ package renderer
 
 import (
 	"context"
+	"image"
+	"image/png"
+	"log/slog"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
 )
 
 // RenderOptions contains configuration for rendering
 type RenderOptions struct {
-	Format        string // "svg" (only SVG is supported)
-	Direction     string // "TB", "LR", "BT", "RL"
-	IncludeLabels bool
-	Title         string
-	UseIcons      bool // Enable icon rendering (if available)
+	Format          string // "svg", or "png", "jpg" or "jpeg" drawn by the raster renderer
+	Direction       string // "TB", "LR", "BT", "RL"
+	EdgeStyle       string // "curved" (default), "straight", or "orthogonal"
+	IncludeLabels   bool
+	Title           string
+	UseIcons        bool   // Enable icon rendering (if available)
+	ShowBadges      bool   // Show a region/size badge under the type label (requires IncludeLabels)
+	ShowEdgeSource  bool   // Append the attribute that created each edge to its label (requires IncludeLabels)
+	ShowSummary     bool   // Print a resource/connection/provider count footer below the diagram
+	GroupByProvider bool   // Draw a brand-tinted container behind each provider's nodes (SVG only)
+	ShowMinimap     bool   // Draw a scaled-down overview of all nodes in the bottom-right corner (SVG only)
+	ColorByRegion   bool   // Color nodes by region/location/zone instead of resource type and draw a region legend (SVG only)
+	FontFamily      string // CSS font-family for all SVG text (default "'Segoe UI', Arial, sans-serif")
+	FontURL         string // Web font embedded via @font-face for the first FontFamily entry (requires FontFamily)
+	Accessible      bool   // Add ARIA roles and labels plus a document <title>/<desc> summary (SVG only)
+	Compact         bool   // Draw small single-line nodes with tight spacing, e.g. for thumbnails (ShowBadges is ignored)
+	ResponsiveSVG   bool   // Size the root <svg> as width="100%" height="auto" so it scales to its container (viewBox is kept)
+	ShowSourceFiles bool   // Add the file:line declaring each resource as a data-source attribute and tooltip (config mode, SVG only)
+
+	// MaxLabelLength and MaxTypeLength truncate node names and resource type names to
+	// this many characters, ending them with "..." (0 = no truncation). Callers usually
+	// start from DefaultMaxLabelLength and DefaultMaxTypeLength, which fit default nodes.
+	MaxLabelLength int
+	MaxTypeLength  int
+
+	// NodeLabelTemplate replaces the node name in labels with this text/template, executed
+	// against the node (.Name, .Type, .Provider, .Attributes). The type line is kept, and
+	// invalid templates are logged and fall back to the node name.
+	NodeLabelTemplate string
+
+	// LabelRelationships restricts edge labels to edges with one of these relationships;
+	// all edges are still drawn (empty = label every edge)
+	LabelRelationships []string
+
+	// RelationshipLabels maps relationships to the text shown for them in edge labels,
+	// e.g. {"protects": "secures"}; LabelRelationships and all other logic keep the
+	// original relationship names
+	RelationshipLabels map[string]string
+
+	// GroupByTag draws a labeled cluster behind the nodes sharing each value of this tag
+	// key, read from the "tags" (or GCP "labels") attribute, e.g. "Environment" (SVG only)
+	GroupByTag string
+
+	// FilterByTag keeps only the nodes carrying every one of these tag key/value pairs,
+	// e.g. {"Team": "payments"}, applied before AggregateIdentical and MaxNodes
+	FilterByTag map[string]string
+
+	// HighlightUnknown draws nodes of ResourceTypeUnknown, i.e. resource types without a
+	// category, gray with a dashed border and a "?" marker, to reveal coverage gaps (SVG only)
+	HighlightUnknown bool
+
+	// EmphasizeType renders nodes of this category at full opacity and dims all other
+	// nodes and the edges between them (ResourceTypeUnknown, the zero value, disables it)
+	EmphasizeType parser.ResourceType
+
+	// HighlightPath draws the nodes and edges of the shortest path between these two node
+	// IDs (see graph.Graph.ShortestPath) bold in an accent color and dims all others,
+	// taking priority over EmphasizeType; nothing is highlighted when the nodes are not
+	// connected (SVG only)
+	HighlightPath [2]string
+
+	// Logger receives structured debug events from the render phases, such as the
+	// layout dimensions (nil = discard)
+	Logger *slog.Logger
+
+	// SkipCreateDirs fails the export when the output directory does not exist
+	// instead of creating it (and any missing parents)
+	SkipCreateDirs bool
+
+	// NestContainers draws members of "contains"/"member_of" relationships (e.g. a subnet
+	// in its VPC) inside their container's box and leaves out the containment edges
+	NestContainers bool
+
+	// MaxLayers limits the depth of the layout; deeper resources share the last layer (0 = unlimited)
+	MaxLayers int
+
+	// LayoutAlgorithm selects LayoutAlgorithmLayered (default) or LayoutAlgorithmRadial,
+	// which draws LayoutCenter in the middle and every other resource on a ring by its
+	// distance from it (Direction, MaxLayers and NestContainers are ignored)
+	LayoutAlgorithm string
+	LayoutCenter    string
+
+	// SimpleEdgeThreshold is the number of edges above which curved edges are drawn as
+	// simple lines to keep large diagrams small and fast (0 = DefaultSimpleEdgeThreshold,
+	// negative = always curved); EdgeStyleStraight simplifies edges of any graph
+	SimpleEdgeThreshold int
+
+	// BundleSharedTargets draws the edges of three or more sources into the same side of a
+	// resource (e.g. instances behind a load balancer) as branches meeting at a junction
+	// near it, joined into a single trunk
+	BundleSharedTargets bool
+
+	// LaneBy groups the nodes of every layer into swim lanes running along the layers,
+	// one per resource category (LaneByType) or provider (LaneByProvider), spaced apart
+	// and, in SVG output, separated by labeled dividers ("" or LaneByNone = no lanes;
+	// ignored with NestContainers and radial layouts)
+	LaneBy string
+
+	// AggregateIdentical collapses sibling nodes of the same type that share exactly the
+	// same edges into a single counted node (e.g. "droplet ×50"), applied before MaxNodes
+	AggregateIdentical bool
+
+	// ShowOutputs draws each of Outputs, e.g. from parser.ParseStateOutputs, as a note
+	// connected to the resources whose identifying attributes (id, ARN, DNS name, IP
+	// address, ...) hold its value (SVG draws notes as such; other renderers as plain
+	// nodes). Notes are added after MaxNodes is applied.
+	ShowOutputs bool
+	Outputs     []parser.Output
+
+	// MaxNodes limits the number of rendered nodes (0 = unlimited)
+	MaxNodes int
+	// OnExceed selects how graphs over MaxNodes are handled: "collapse" (default) or "error"
+	OnExceed string
+
+	// JPEGQuality is the quality, from 1 to 100, of JPEG output of the raster renderer
+	// (0 = 95)
+	JPEGQuality int
+	// PNGCompression is the compression level of PNG output of the raster renderer
+	// (the zero value is png.DefaultCompression)
+	PNGCompression png.CompressionLevel
+}
+
+// logger returns opts.Logger, or a logger that discards all events when it is nil
+func (opts RenderOptions) logger() *slog.Logger {
+	return loggerOrDiscard(opts.Logger)
+}
+
+// loggerOrDiscard returns logger, or a logger that discards all events when it is nil
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return logger
 }
 
+// summaryHeight is the extra canvas height reserved for the summary footer
+const summaryHeight = 30.0
+
+// Default node label truncation, sized to fit the default node width
+const (
+	DefaultMaxLabelLength = 25
+	DefaultMaxTypeLength  = 30
+)
+
+// Placeholder shown when a graph has no nodes, so an empty result is clearly intentional
+const (
+	emptyDiagramMessage = "No resources to display"
+	emptyDiagramWidth   = 600.0 // Minimum canvas width for the placeholder
+	emptyDiagramHeight  = 300.0 // Minimum canvas height for the placeholder
+)
+
 // RenderDiagram generates a visual diagram from the resource graph.
 // It respects the provided context for cancellation.
 func RenderDiagram(ctx context.Context, g *graph.Graph, outputPath string, opts RenderOptions) error {
 	// Use the new export system for all formats
 	return ExportDiagram(ctx, g, outputPath, opts)
 }
+
+// RenderDiagramBytes generates a visual diagram from the resource graph and returns
+// it in memory instead of writing it to a file.
+func RenderDiagramBytes(ctx context.Context, g *graph.Graph, opts RenderOptions) ([]byte, error) {
+	return renderGraph(ctx, g, opts)
+}
+
+// RenderDiagramBytesWithLayout renders like RenderDiagramBytes and also returns the
+// layout the diagram was drawn from, e.g. for tools that annotate the SVG afterwards.
+// See Layout for the coordinate system.
+func RenderDiagramBytesWithLayout(ctx context.Context, g *graph.Graph, opts RenderOptions) ([]byte, *Layout, error) {
+	return renderGraphWithLayout(ctx, g, opts)
+}
+
+// RenderImage lays out and draws the resource graph as an in-memory raster image, e.g.
+// for embedding in reports, without encoding or writing it. opts.Format is ignored.
+func RenderImage(ctx context.Context, g *graph.Graph, opts RenderOptions) (image.Image, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if err := checkRenderOptions(opts); err != nil {
+		return nil, err
+	}
+
+	g, layout, err := layoutGraph(ctx, g, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPNGRenderer(opts).RenderImage(layout, g), nil
+}
@@ -1,9 +1,20 @@
 package renderer
 
 import (
+	"bytes"
 	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
@@ -338,8 +349,12 @@ func TestRenderDiagram_InvalidOutputPath(t *testing.T) {
 		Edges: []*graph.Edge{},
 	}
 
-	// Try to write to a directory that doesn't exist and can't be created
-	outputPath := "/nonexistent/directory/diagram.svg"
+	// Try to write to a directory that can't be created: its parent is a regular file
+	blocker := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(blocker, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	outputPath := filepath.Join(blocker, "directory", "diagram.svg")
 
 	ctx := context.Background()
 	opts := RenderOptions{
@@ -352,3 +367,1837 @@ func TestRenderDiagram_InvalidOutputPath(t *testing.T) {
 		t.Error("RenderDiagram() with invalid output path should return error")
 	}
 }
+
+func TestRenderDiagram_CreatesOutputDir(t *testing.T) {
+	g := newStarGraph(1)
+	outputPath := filepath.Join(t.TempDir(), "nested", "does", "not", "exist", "diagram.svg")
+	opts := RenderOptions{Format: "svg", Direction: "TB"}
+
+	// Creation disabled: the missing directory is an error
+	skipOpts := opts
+	skipOpts.SkipCreateDirs = true
+	if err := RenderDiagram(context.Background(), g, outputPath, skipOpts); err == nil {
+		t.Error("RenderDiagram() with SkipCreateDirs should fail for a missing directory")
+	}
+
+	if err := RenderDiagram(context.Background(), g, outputPath, opts); err != nil {
+		t.Fatalf("RenderDiagram() error = %v", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("RenderDiagram() did not write the diagram: %v", err)
+	}
+	if !strings.Contains(string(data), "<svg") {
+		t.Error("written file is not an SVG document")
+	}
+}
+
+// newStarGraph builds a graph with one VPC and the given number of instances attached to it
+func newStarGraph(instances int) *graph.Graph {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws"}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{vpc.ID: vpc},
+		Edges: []*graph.Edge{},
+	}
+
+	for i := 0; i < instances; i++ {
+		node := &graph.Node{
+			ID:       fmt.Sprintf("aws_instance.web%d", i),
+			Type:     "aws_instance",
+			Name:     fmt.Sprintf("web%d", i),
+			Provider: "aws",
+		}
+		edge := &graph.Edge{From: node, To: vpc, Relationship: "member_of"}
+		node.Edges = []*graph.Edge{edge}
+		g.Nodes[node.ID] = node
+		g.Edges = append(g.Edges, edge)
+	}
+
+	return g
+}
+
+func TestRenderDiagram_MaxNodes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("error strategy", func(t *testing.T) {
+		g := newStarGraph(10)
+		outputPath := filepath.Join(t.TempDir(), "diagram.svg")
+
+		err := RenderDiagram(ctx, g, outputPath, RenderOptions{
+			Format:   "svg",
+			MaxNodes: 5,
+			OnExceed: OnExceedError,
+		})
+		if err == nil {
+			t.Fatal("RenderDiagram() over MaxNodes with error strategy should return error")
+		}
+		if !strings.Contains(err.Error(), "exceeding the maximum of 5") {
+			t.Errorf("error = %v, want message mentioning the limit", err)
+		}
+		if _, statErr := os.Stat(outputPath); !os.IsNotExist(statErr) {
+			t.Error("no diagram should be written when the limit is exceeded")
+		}
+	})
+
+	t.Run("collapse strategy", func(t *testing.T) {
+		g := newStarGraph(10)
+
+		limited, err := applyNodeLimit(g, RenderOptions{MaxNodes: 5, OnExceed: OnExceedCollapse})
+		if err != nil {
+			t.Fatalf("applyNodeLimit() error = %v", err)
+		}
+		if len(limited.Nodes) > 5 {
+			t.Errorf("collapsed graph has %d nodes, want at most 5", len(limited.Nodes))
+		}
+
+		aggregate := limited.Nodes["aws_vpc.main.collapsed"]
+		if aggregate == nil {
+			t.Fatal("expected aggregate node for leaves of aws_vpc.main")
+		}
+		if aggregate.Name != "10 more" {
+			t.Errorf("aggregate Name = %q, want %q", aggregate.Name, "10 more")
+		}
+		if len(limited.Edges) != 1 || limited.Edges[0].From != aggregate || limited.Edges[0].To.ID != "aws_vpc.main" {
+			t.Errorf("expected single edge from aggregate to aws_vpc.main, got %d edges", len(limited.Edges))
+		}
+
+		// The input graph must not be modified
+		if len(g.Nodes) != 11 || len(g.Edges) != 10 {
+			t.Errorf("input graph was modified: %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+		}
+
+		outputPath := filepath.Join(t.TempDir(), "diagram.svg")
+		if err := RenderDiagram(ctx, g, outputPath, RenderOptions{Format: "svg", IncludeLabels: true, MaxNodes: 5}); err != nil {
+			t.Fatalf("RenderDiagram() with collapse strategy error = %v", err)
+		}
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output file: %v", err)
+		}
+		if !strings.Contains(string(content), "10 more") {
+			t.Error("rendered SVG should contain the aggregate node label")
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		g := newStarGraph(3)
+
+		limited, err := applyNodeLimit(g, RenderOptions{MaxNodes: 5, OnExceed: OnExceedError})
+		if err != nil {
+			t.Fatalf("applyNodeLimit() error = %v", err)
+		}
+		if limited != g {
+			t.Error("graph within the limit should be returned unchanged")
+		}
+	})
+}
+
+func TestRenderDiagram_AggregateIdentical(t *testing.T) {
+	vpc := &graph.Node{ID: "digitalocean_vpc.main", Type: "digitalocean_vpc", Name: "main", Provider: "digitalocean", ResourceType: parser.ResourceTypeNetwork}
+	lb := &graph.Node{ID: "digitalocean_loadbalancer.web", Type: "digitalocean_loadbalancer", Name: "web", Provider: "digitalocean", ResourceType: parser.ResourceTypeLoadBalancer}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{vpc.ID: vpc, lb.ID: lb},
+		Edges: []*graph.Edge{{From: lb, To: vpc, Relationship: "member_of"}},
+	}
+	for i := 0; i < 5; i++ {
+		droplet := &graph.Node{
+			ID:           fmt.Sprintf("digitalocean_droplet.web%d", i),
+			Type:         "digitalocean_droplet",
+			Name:         fmt.Sprintf("web%d", i),
+			Provider:     "digitalocean",
+			ResourceType: parser.ResourceTypeCompute,
+		}
+		g.Nodes[droplet.ID] = droplet
+		g.Edges = append(g.Edges, &graph.Edge{From: droplet, To: vpc, Relationship: "member_of"})
+	}
+
+	aggregated := aggregateIdenticalNodes(g)
+
+	// The load balancer shares the droplets' edge but not their type, so it stays
+	if len(aggregated.Nodes) != 3 {
+		t.Fatalf("aggregated graph has %d nodes, want 3", len(aggregated.Nodes))
+	}
+	aggregate := aggregated.Nodes["digitalocean_droplet.web0.aggregate"]
+	if aggregate == nil {
+		t.Fatal("expected aggregate node for the droplets")
+	}
+	if aggregate.Name != "droplet ×5" {
+		t.Errorf("aggregate Name = %q, want %q", aggregate.Name, "droplet ×5")
+	}
+	if aggregate.ResourceType != parser.ResourceTypeCompute {
+		t.Errorf("aggregate ResourceType = %v, want ResourceTypeCompute", aggregate.ResourceType)
+	}
+	if len(aggregated.Edges) != 2 {
+		t.Errorf("aggregated graph has %d edges, want 2", len(aggregated.Edges))
+	}
+	if len(aggregate.Edges) != 1 || aggregate.Edges[0].To.ID != vpc.ID || aggregate.Edges[0].Relationship != "member_of" {
+		t.Fatalf("expected a single member_of edge from the aggregate to %s", vpc.ID)
+	}
+	if count := aggregate.Edges[0].Metadata["count"]; count != "5" {
+		t.Errorf("aggregate edge count = %q, want %q", count, "5")
+	}
+	for _, edge := range aggregated.Edges {
+		if edge.From.ID == lb.ID && edge.Metadata["count"] != "" {
+			t.Errorf("unmerged edge has count %q, want none", edge.Metadata["count"])
+		}
+	}
+
+	// The input graph must not be modified
+	if len(g.Nodes) != 7 || len(g.Edges) != 6 {
+		t.Errorf("input graph was modified: %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+
+	// Count instances share a name, which labels the aggregate
+	stars := newStarGraph(3)
+	for _, node := range stars.Nodes {
+		node.Name = "web"
+	}
+	if aggregate := aggregateIdenticalNodes(stars).Nodes["aws_instance.web0.aggregate"]; aggregate == nil || aggregate.Name != "web ×3" {
+		t.Errorf("aggregate of count instances = %v, want a node named %q", aggregate, "web ×3")
+	}
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", IncludeLabels: true, AggregateIdentical: true})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	if !strings.Contains(string(data), "droplet ×5") {
+		t.Error("rendered SVG should contain the aggregate node label")
+	}
+	if !strings.Contains(string(data), "member_of ×5") {
+		t.Error("rendered SVG should label the merged edge with its connection count")
+	}
+
+	data, err = renderGraph(context.Background(), g, RenderOptions{Format: "svg", IncludeLabels: true})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	if strings.Contains(string(data), "×5") {
+		t.Error("nodes should not be aggregated unless AggregateIdentical is set")
+	}
+}
+
+func TestGenerateFromResources(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_vpc.main",
+			Type:       "aws_vpc",
+			Name:       "main",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "vpc-123"},
+		},
+		{
+			ID:           "aws_instance.web",
+			Type:         "aws_instance",
+			Name:         "web",
+			Provider:     "aws",
+			Attributes:   map[string]interface{}{"id": "i-123"},
+			Dependencies: []string{"aws_vpc.main"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{
+			name:   "SVG format",
+			format: "svg",
+		},
+		{
+			name:    "unsupported format",
+			format:  "gif",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateFromResources(context.Background(), resources, RenderOptions{
+				Format:        tt.format,
+				Direction:     "TB",
+				IncludeLabels: true,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateFromResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if data != nil {
+					t.Error("GenerateFromResources() should return no data on error")
+				}
+				return
+			}
+
+			svg := string(data)
+			if !strings.HasPrefix(strings.TrimSpace(svg), "<") || !strings.Contains(svg, "<svg") {
+				t.Error("GenerateFromResources() did not return SVG content")
+			}
+			if !strings.Contains(svg, "web") {
+				t.Error("rendered SVG should contain the resource name")
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_ShowBadges(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:       "aws_instance.web",
+			Type:     "aws_instance",
+			Name:     "web",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":            "i-123",
+				"region":        "eu-west-1",
+				"instance_type": "t3.micro",
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		showBadges bool
+		wantBadge  bool
+	}{
+		{name: "badges enabled", showBadges: true, wantBadge: true},
+		{name: "badges disabled", showBadges: false, wantBadge: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateFromResources(context.Background(), resources, RenderOptions{
+				Format:        "svg",
+				Direction:     "TB",
+				IncludeLabels: true,
+				ShowBadges:    tt.showBadges,
+			})
+			if err != nil {
+				t.Fatalf("GenerateFromResources() error = %v", err)
+			}
+
+			svg := string(data)
+			if got := strings.Contains(svg, "eu-west-1 | t3.micro"); got != tt.wantBadge {
+				t.Errorf("badge text present = %v, want %v", got, tt.wantBadge)
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_EmphasizeType(t *testing.T) {
+	resources := []parser.Resource{
+		{
+			ID:         "aws_security_group.web",
+			Type:       "aws_security_group",
+			Name:       "web",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "sg-web"},
+		},
+		{
+			ID:       "aws_instance.web",
+			Type:     "aws_instance",
+			Name:     "web",
+			Provider: "aws",
+			Attributes: map[string]interface{}{
+				"id":                     "i-web",
+				"vpc_security_group_ids": []interface{}{"sg-web"},
+			},
+		},
+		{
+			ID:         "aws_s3_bucket.logs",
+			Type:       "aws_s3_bucket",
+			Name:       "logs",
+			Provider:   "aws",
+			Attributes: map[string]interface{}{"id": "logs"},
+		},
+	}
+
+	// Captures whether each node group is dimmed, keyed by its gradient (node ID)
+	nodeGroup := regexp.MustCompile(`<g class="node( dimmed)?"[^>]*>\s*<rect[^>]*fill="url\(#grad_([^)]+)\)"`)
+
+	tests := []struct {
+		name       string
+		emphasize  parser.ResourceType
+		wantDimmed map[string]bool
+	}{
+		{
+			name:      "emphasize security",
+			emphasize: parser.ResourceTypeSecurity,
+			wantDimmed: map[string]bool{
+				"aws_security_group_web": false,
+				"aws_instance_web":       true,
+				"aws_s3_bucket_logs":     true,
+			},
+		},
+		{
+			name:      "no emphasis",
+			emphasize: parser.ResourceTypeUnknown,
+			wantDimmed: map[string]bool{
+				"aws_security_group_web": false,
+				"aws_instance_web":       false,
+				"aws_s3_bucket_logs":     false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateFromResources(context.Background(), resources, RenderOptions{
+				Format:        "svg",
+				Direction:     "TB",
+				IncludeLabels: true,
+				EmphasizeType: tt.emphasize,
+			})
+			if err != nil {
+				t.Fatalf("GenerateFromResources() error = %v", err)
+			}
+
+			svg := string(data)
+			matches := nodeGroup.FindAllStringSubmatch(svg, -1)
+			if len(matches) != len(tt.wantDimmed) {
+				t.Fatalf("found %d node groups, want %d", len(matches), len(tt.wantDimmed))
+			}
+			for _, m := range matches {
+				dimmed, id := m[1] != "", m[2]
+				if dimmed != tt.wantDimmed[id] {
+					t.Errorf("node %s dimmed = %v, want %v", id, dimmed, tt.wantDimmed[id])
+				}
+			}
+
+			// The security group -> instance edge touches an emphasized node and stays visible
+			if strings.Contains(svg, `class="edge dimmed"`) {
+				t.Error("edges connected to an emphasized node should not be dimmed")
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_ShowSummary(t *testing.T) {
+	g := newStarGraph(2)
+	bucket := &graph.Node{ID: "google_storage_bucket.logs", Type: "google_storage_bucket", Name: "logs", Provider: "gcp"}
+	g.Nodes[bucket.ID] = bucket
+
+	svgHeight := regexp.MustCompile(`<svg[^>]*height="([0-9]+)"`)
+	render := func(showSummary bool) (string, int) {
+		t.Helper()
+		data, err := renderGraph(context.Background(), g, RenderOptions{
+			Format:      "svg",
+			Direction:   "TB",
+			ShowSummary: showSummary,
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+
+		svg := string(data)
+		match := svgHeight.FindStringSubmatch(svg)
+		if match == nil {
+			t.Fatal("rendered SVG has no height attribute")
+		}
+		height, _ := strconv.Atoi(match[1])
+		return svg, height
+	}
+
+	withSummary, heightWith := render(true)
+	withoutSummary, heightWithout := render(false)
+
+	const want = "4 resources, 2 connections across 2 providers"
+	if !strings.Contains(withSummary, want) {
+		t.Errorf("summary footer missing %q", want)
+	}
+	if strings.Contains(withoutSummary, `class="summary"`) {
+		t.Error("summary footer should not be rendered when ShowSummary is false")
+	}
+	if heightWith != heightWithout+int(summaryHeight) {
+		t.Errorf("canvas height with summary = %d, want %d", heightWith, heightWithout+int(summaryHeight))
+	}
+}
+
+func TestRenderDiagram_GroupByProvider(t *testing.T) {
+	g := newStarGraph(2)
+	app := &graph.Node{ID: "azurerm_linux_web_app.api", Type: "azurerm_linux_web_app", Name: "api", Provider: "azure"}
+	g.Nodes[app.ID] = app
+
+	cluster := regexp.MustCompile(`<g class="cluster" data-provider="([^"]+)">\s*<rect[^>]*fill="([^"]+)"`)
+	render := func(groupByProvider bool) string {
+		t.Helper()
+		data, err := renderGraph(context.Background(), g, RenderOptions{
+			Format:          "svg",
+			Direction:       "TB",
+			GroupByProvider: groupByProvider,
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		return string(data)
+	}
+
+	tints := make(map[string]string)
+	for _, match := range cluster.FindAllStringSubmatch(render(true), -1) {
+		tints[match[1]] = match[2]
+	}
+	if tints["aws"] != "#FF9900" {
+		t.Errorf("aws cluster fill = %q, want %q", tints["aws"], "#FF9900")
+	}
+	if tints["azure"] != "#0078D4" {
+		t.Errorf("azure cluster fill = %q, want %q", tints["azure"], "#0078D4")
+	}
+	if len(tints) != 2 {
+		t.Errorf("rendered %d provider clusters, want 2", len(tints))
+	}
+
+	if strings.Contains(render(false), `class="cluster"`) {
+		t.Error("provider clusters should not be rendered when GroupByProvider is false")
+	}
+}
+
+func TestExportDiagramWithLayout(t *testing.T) {
+	g := newStarGraph(3)
+	opts := RenderOptions{Format: "svg", Direction: "LR", IncludeLabels: true}
+
+	layout := ComputeLayout(g, opts)
+	positions := make(map[string]Point, len(layout.Nodes))
+	for id, nodeLayout := range layout.Nodes {
+		positions[id] = nodeLayout.Position
+	}
+
+	// Render the same layout as SVG and PNG
+	outputPath := filepath.Join(t.TempDir(), "diagram.svg")
+	if err := ExportDiagramWithLayout(context.Background(), g, layout, outputPath, opts); err != nil {
+		t.Fatalf("ExportDiagramWithLayout() error = %v", err)
+	}
+	pngData, err := NewPNGRenderer(opts).Render(layout, g)
+	if err != nil {
+		t.Fatalf("PNGRenderer.Render() error = %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(pngData)); err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+
+	svgData, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read SVG: %v", err)
+	}
+	svg := string(svgData)
+
+	const padding = 50.0
+	for id, nodeLayout := range layout.Nodes {
+		if nodeLayout.Position != positions[id] {
+			t.Errorf("node %s moved from %v to %v while rendering", id, positions[id], nodeLayout.Position)
+		}
+		card := fmt.Sprintf(`<rect x="%.2f" y="%.2f"`, positions[id].X+padding, positions[id].Y+padding)
+		if !strings.Contains(svg, card) {
+			t.Errorf("SVG does not draw node %s at its layout position %v", id, positions[id])
+		}
+	}
+
+	// Options are still validated against the precomputed layout
+	opts.Format = "gif"
+	if err := ExportDiagramWithLayout(context.Background(), g, layout, outputPath, opts); err == nil {
+		t.Error("ExportDiagramWithLayout() should reject unsupported formats")
+	}
+}
+
+func TestPNGRenderer_EncodingOptions(t *testing.T) {
+	g := newStarGraph(3)
+	opts := RenderOptions{Format: "jpeg", Direction: "TB", IncludeLabels: true}
+	layout := ComputeLayout(g, opts)
+
+	render := func(opts RenderOptions) []byte {
+		t.Helper()
+		data, err := NewPNGRenderer(opts).Render(layout, g)
+		if err != nil {
+			t.Fatalf("PNGRenderer.Render() error = %v", err)
+		}
+		return data
+	}
+
+	defaultJPEG := render(opts)
+	if _, err := jpeg.Decode(bytes.NewReader(defaultJPEG)); err != nil {
+		t.Fatalf("Failed to decode JPEG: %v", err)
+	}
+	opts.JPEGQuality = 10
+	if lowJPEG := render(opts); len(lowJPEG) >= len(defaultJPEG) {
+		t.Errorf("JPEG at quality 10 is %d bytes, want smaller than the default's %d", len(lowJPEG), len(defaultJPEG))
+	}
+
+	opts.JPEGQuality = 101
+	if _, err := NewPNGRenderer(opts).Render(layout, g); err == nil {
+		t.Error("PNGRenderer.Render() should reject JPEG quality 101")
+	}
+	if _, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", JPEGQuality: -1}); err == nil {
+		t.Error("renderGraph() should reject JPEG quality -1")
+	}
+
+	pngOpts := RenderOptions{Format: "png", Direction: "TB", IncludeLabels: true}
+	defaultPNG := render(pngOpts)
+	pngOpts.PNGCompression = png.NoCompression
+	rawPNG := render(pngOpts)
+	if _, err := png.Decode(bytes.NewReader(rawPNG)); err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if len(rawPNG) <= len(defaultPNG) {
+		t.Errorf("PNG without compression is %d bytes, want larger than the default's %d", len(rawPNG), len(defaultPNG))
+	}
+
+	// The export path renders raster formats with the same encoding options
+	exported, err := renderGraph(context.Background(), g, RenderOptions{Format: "jpg", Direction: "TB", IncludeLabels: true, JPEGQuality: 10})
+	if err != nil {
+		t.Fatalf("renderGraph() as JPEG error = %v", err)
+	}
+	if !bytes.Equal(exported, render(RenderOptions{Format: "jpg", Direction: "TB", IncludeLabels: true, JPEGQuality: 10})) {
+		t.Error("renderGraph() as JPEG differs from the raster renderer's output at the same quality")
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "diagram.png")
+	if err := ExportDiagram(context.Background(), g, outputPath, RenderOptions{Format: "png", Direction: "TB", IncludeLabels: true, PNGCompression: png.NoCompression}); err != nil {
+		t.Fatalf("ExportDiagram() as PNG error = %v", err)
+	}
+	written, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read exported PNG: %v", err)
+	}
+	if !bytes.Equal(written, rawPNG) {
+		t.Error("ExportDiagram() as PNG differs from the raster renderer's output without compression")
+	}
+}
+
+func TestRenderImage(t *testing.T) {
+	g := newStarGraph(3)
+	opts := RenderOptions{Direction: "TB", IncludeLabels: true}
+
+	img, err := RenderImage(context.Background(), g, opts)
+	if err != nil {
+		t.Fatalf("RenderImage() error = %v", err)
+	}
+	layout := ComputeLayout(g, opts)
+	bounds := img.Bounds()
+	if bounds.Min != (image.Point{}) || bounds.Dx() < int(layout.Width) || bounds.Dy() < int(layout.Height) {
+		t.Errorf("image bounds = %v, want origin-based and at least %.0fx%.0f", bounds, layout.Width, layout.Height)
+	}
+	if _, ok := img.(*image.RGBA); !ok {
+		t.Errorf("RenderImage() returned %T, want *image.RGBA", img)
+	}
+
+	if _, err := RenderImage(context.Background(), g, RenderOptions{EdgeStyle: "wavy"}); err == nil {
+		t.Error("RenderImage() should reject invalid options")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RenderImage(ctx, g, opts); err == nil {
+		t.Error("RenderImage() should fail with a cancelled context")
+	}
+}
+
+func TestRenderDiagram_MutualEdges(t *testing.T) {
+	east := &graph.Node{ID: "aws_vpc.east", Type: "aws_vpc", Name: "east", Provider: "aws"}
+	west := &graph.Node{ID: "aws_vpc.west", Type: "aws_vpc", Name: "west", Provider: "aws"}
+	subnet := &graph.Node{ID: "aws_subnet.app", Type: "aws_subnet", Name: "app", Provider: "aws"}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{east.ID: east, west.ID: west, subnet.ID: subnet},
+		Edges: []*graph.Edge{},
+	}
+	for _, e := range []*graph.Edge{
+		{From: east, To: west, Relationship: "peers_with"},
+		{From: west, To: east, Relationship: "peers_with"},
+		{From: subnet, To: east, Relationship: "member_of"},
+	} {
+		e.From.Edges = append(e.From.Edges, e)
+		g.Edges = append(g.Edges, e)
+	}
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB"})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	svg := string(data)
+
+	if got := strings.Count(svg, "<!-- Edge connection -->"); got != 2 {
+		t.Errorf("rendered %d edges, want 2 (peering pair merged, plus the subnet edge)", got)
+	}
+	if got := strings.Count(svg, `marker-start="url(#arrowhead-start)"`); got != 1 {
+		t.Errorf("rendered %d double-headed edges, want 1", got)
+	}
+	if !strings.Contains(svg, `<marker id="arrowhead-start"`) {
+		t.Error("arrowhead-start marker is not defined")
+	}
+
+	// The pair forms a cycle; the merged line keeps the edge that was not removed to break it
+	if regexp.MustCompile(`stroke-dasharray="6,4"\s+fill="none" marker-start`).MatchString(svg) {
+		t.Error("double-headed edge should not be drawn dashed")
+	}
+}
+
+func TestRenderDiagram_PeeringEdges(t *testing.T) {
+	peering := &graph.Node{ID: "aws_vpc_peering_connection.east_west", Type: "aws_vpc_peering_connection", Name: "east_west", Provider: "aws"}
+	east := &graph.Node{ID: "aws_vpc.east", Type: "aws_vpc", Name: "east", Provider: "aws"}
+	west := &graph.Node{ID: "aws_vpc.west", Type: "aws_vpc", Name: "west", Provider: "aws"}
+	subnet := &graph.Node{ID: "aws_subnet.app", Type: "aws_subnet", Name: "app", Provider: "aws"}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{peering.ID: peering, east.ID: east, west.ID: west, subnet.ID: subnet},
+		Edges: []*graph.Edge{},
+	}
+	for _, e := range []*graph.Edge{
+		{From: peering, To: east, Relationship: "peers_with"},
+		{From: peering, To: west, Relationship: "peers_with"},
+		{From: subnet, To: east, Relationship: "member_of"},
+	} {
+		e.From.Edges = append(e.From.Edges, e)
+		g.Edges = append(g.Edges, e)
+	}
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB"})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+
+	// Single peering edges are drawn double-headed; other relationships keep one arrowhead
+	if got := strings.Count(string(data), `marker-start="url(#arrowhead-start)"`); got != 2 {
+		t.Errorf("rendered %d double-headed edges, want 2 (one per peered VPC)", got)
+	}
+}
+
+func TestRenderDiagram_LabelRelationships(t *testing.T) {
+	sg := &graph.Node{ID: "aws_security_group.web", Type: "aws_security_group", Name: "web", Provider: "aws"}
+	lb := &graph.Node{ID: "aws_lb.front", Type: "aws_lb", Name: "front", Provider: "aws"}
+	instance := &graph.Node{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws"}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{sg.ID: sg, lb.ID: lb, instance.ID: instance},
+		Edges: []*graph.Edge{},
+	}
+	for _, e := range []*graph.Edge{
+		{From: sg, To: instance, Relationship: "protects", Metadata: map[string]string{"port": "443", "protocol": "tcp"}},
+		{From: lb, To: instance, Relationship: "routes_to", Metadata: map[string]string{"port": "8080", "protocol": "http"}},
+	} {
+		e.From.Edges = append(e.From.Edges, e)
+		g.Edges = append(g.Edges, e)
+	}
+
+	tests := []struct {
+		name               string
+		relationships      []string
+		relationshipLabels map[string]string
+		wantLabels         []string
+		wantNoLabels       []string
+	}{
+		{
+			name:       "empty list labels every edge",
+			wantLabels: []string{"protects :443 tcp", "routes_to :8080 http"},
+		},
+		{
+			name:          "only protects",
+			relationships: []string{"protects"},
+			wantLabels:    []string{"protects :443 tcp"},
+			wantNoLabels:  []string{"routes_to :8080 http"},
+		},
+		{
+			name:               "relabeled protects is still filtered by its relationship",
+			relationships:      []string{"protects"},
+			relationshipLabels: map[string]string{"protects": "secures"},
+			wantLabels:         []string{"secures :443 tcp"},
+			wantNoLabels:       []string{"protects :443 tcp", "routes_to :8080 http"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := renderGraph(context.Background(), g, RenderOptions{
+				Format:             "svg",
+				Direction:          "TB",
+				IncludeLabels:      true,
+				LabelRelationships: tt.relationships,
+				RelationshipLabels: tt.relationshipLabels,
+			})
+			if err != nil {
+				t.Fatalf("renderGraph() error = %v", err)
+			}
+			svg := string(data)
+
+			if got := strings.Count(svg, "<!-- Edge connection -->"); got != 2 {
+				t.Errorf("rendered %d edges, want 2", got)
+			}
+			if got := strings.Count(svg, "<!-- Edge label text -->"); got != len(tt.wantLabels) {
+				t.Errorf("rendered %d edge labels, want %d", got, len(tt.wantLabels))
+			}
+			for _, label := range tt.wantLabels {
+				if !strings.Contains(svg, label) {
+					t.Errorf("SVG does not contain edge label %q", label)
+				}
+			}
+			for _, label := range tt.wantNoLabels {
+				if strings.Contains(svg, label) {
+					t.Errorf("SVG contains edge label %q, want it filtered out", label)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_ShowMinimap(t *testing.T) {
+	g := newStarGraph(3)
+
+	render := func(showMinimap bool) string {
+		t.Helper()
+		data, err := renderGraph(context.Background(), g, RenderOptions{
+			Format:      "svg",
+			Direction:   "TB",
+			ShowMinimap: showMinimap,
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		return string(data)
+	}
+
+	svg := render(true)
+	if !strings.Contains(svg, `<g class="minimap">`) {
+		t.Fatal("minimap group missing when ShowMinimap is true")
+	}
+
+	rect := func(class string) *regexp.Regexp {
+		return regexp.MustCompile(`<rect class="` + class + `" x="([0-9.]+)" y="([0-9.]+)" width="([0-9.]+)" height="([0-9.]+)"`)
+	}
+	parse := func(match []string) (x, y, w, h float64) {
+		x, _ = strconv.ParseFloat(match[1], 64)
+		y, _ = strconv.ParseFloat(match[2], 64)
+		w, _ = strconv.ParseFloat(match[3], 64)
+		h, _ = strconv.ParseFloat(match[4], 64)
+		return x, y, w, h
+	}
+
+	frame := rect("minimap-frame").FindStringSubmatch(svg)
+	if frame == nil {
+		t.Fatal("minimap frame missing")
+	}
+	fx, fy, fw, fh := parse(frame)
+	if fw > minimapMaxWidth || fh > minimapMaxHeight {
+		t.Errorf("minimap frame is %.2fx%.2f, want at most %.0fx%.0f", fw, fh, minimapMaxWidth, minimapMaxHeight)
+	}
+
+	nodes := rect("minimap-node").FindAllStringSubmatch(svg, -1)
+	if len(nodes) != len(g.Nodes) {
+		t.Errorf("minimap has %d node rects, want %d", len(nodes), len(g.Nodes))
+	}
+	for _, node := range nodes {
+		x, y, w, h := parse(node)
+		if x < fx || y < fy || x+w > fx+fw+0.01 || y+h > fy+fh+0.01 {
+			t.Errorf("minimap node %v lies outside the frame %v", node[1:], frame[1:])
+		}
+	}
+
+	if strings.Contains(render(false), `class="minimap"`) {
+		t.Error("minimap should not be rendered when ShowMinimap is false")
+	}
+}
+
+func TestRenderDiagram_MaxLabelLength(t *testing.T) {
+	node := &graph.Node{
+		ID:           "aws_instance.web",
+		Type:         "aws_instance",
+		Name:         "payments-api-web-server-primary-eu",
+		Provider:     "aws",
+		ResourceType: parser.ResourceTypeCompute,
+	}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{node.ID: node}, Edges: []*graph.Edge{}}
+	typeName := getResourceTypeName(node.Type)
+
+	tests := []struct {
+		name           string
+		maxLabelLength int
+		maxTypeLength  int
+		wantName       string
+		wantType       string
+	}{
+		{name: "defaults", maxLabelLength: DefaultMaxLabelLength, maxTypeLength: DefaultMaxTypeLength, wantName: ">payments-api-web-serve...</text>", wantType: ">" + typeName + "</text>"},
+		{name: "custom lengths", maxLabelLength: 12, maxTypeLength: 4, wantName: ">payments-...</text>", wantType: ">" + typeName[:1] + "...</text>"},
+		{name: "no truncation", wantName: ">payments-api-web-server-primary-eu</text>", wantType: ">" + typeName + "</text>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := renderGraph(context.Background(), g, RenderOptions{
+				Format:         "svg",
+				Direction:      "TB",
+				IncludeLabels:  true,
+				MaxLabelLength: tt.maxLabelLength,
+				MaxTypeLength:  tt.maxTypeLength,
+			})
+			if err != nil {
+				t.Fatalf("renderGraph() error = %v", err)
+			}
+			svg := string(data)
+
+			if !strings.Contains(svg, tt.wantName) {
+				t.Errorf("SVG does not contain node label %q", tt.wantName)
+			}
+			if !strings.Contains(svg, tt.wantType) {
+				t.Errorf("SVG does not contain type label %q", tt.wantType)
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_NodeLabelTemplate(t *testing.T) {
+	node := &graph.Node{
+		ID:           "aws_instance.web",
+		Type:         "aws_instance",
+		Name:         "web",
+		Provider:     "aws",
+		ResourceType: parser.ResourceTypeCompute,
+		Attributes:   map[string]interface{}{"instance_type": "t2.micro"},
+	}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{node.ID: node}, Edges: []*graph.Edge{}}
+
+	tests := []struct {
+		name      string
+		template  string
+		wantLabel string
+	}{
+		{name: "name and attribute", template: `{{.Name}} ({{index .Attributes "instance_type"}})`, wantLabel: ">web (t2.micro)</text>"},
+		{name: "no template", template: "", wantLabel: ">web</text>"},
+		{name: "invalid template falls back", template: "{{.Name", wantLabel: ">web</text>"},
+		{name: "execution error falls back", template: "{{.Missing}}", wantLabel: ">web</text>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := renderGraph(context.Background(), g, RenderOptions{
+				Format:            "svg",
+				Direction:         "TB",
+				IncludeLabels:     true,
+				NodeLabelTemplate: tt.template,
+			})
+			if err != nil {
+				t.Fatalf("renderGraph() error = %v", err)
+			}
+			svg := string(data)
+
+			if !strings.Contains(svg, tt.wantLabel) {
+				t.Errorf("SVG does not contain node label %q", tt.wantLabel)
+			}
+			// The resource type line is kept below the templated label
+			if !strings.Contains(svg, ">"+getResourceTypeName(node.Type)+"</text>") {
+				t.Error("SVG does not contain the resource type label")
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_Accessible(t *testing.T) {
+	web := &graph.Node{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws", ResourceType: parser.ResourceTypeCompute}
+	sg := &graph.Node{ID: "aws_security_group.web", Type: "aws_security_group", Name: "web", Provider: "aws", ResourceType: parser.ResourceTypeSecurity}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{web.ID: web, sg.ID: sg},
+		Edges: []*graph.Edge{{From: sg, To: web, Relationship: "protects"}},
+	}
+
+	render := func(accessible bool) string {
+		t.Helper()
+		data, err := renderGraph(context.Background(), g, RenderOptions{
+			Format:     "svg",
+			Direction:  "TB",
+			Title:      "Web tier",
+			Accessible: accessible,
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		return string(data)
+	}
+
+	svg := render(true)
+	assertWellFormedSVG(t, []byte(svg))
+	for _, want := range []string{
+		`role="img" aria-labelledby="diagram-title diagram-desc">`,
+		`<title id="diagram-title">Web tier</title>`,
+		`<desc id="diagram-desc">2 resources, 1 connection across 1 provider</desc>`,
+		`aria-label="web (aws_instance)"`,
+		`aria-label="web (aws_security_group)"`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("accessible SVG does not contain %q", want)
+		}
+	}
+
+	if plain := render(false); strings.Contains(plain, "aria-") || strings.Contains(plain, "<desc") {
+		t.Error("SVG contains accessibility attributes without Accessible")
+	}
+}
+
+func TestRenderDiagram_NodeDataAttributes(t *testing.T) {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws", ResourceType: parser.ResourceTypeNetwork}
+	droplet := &graph.Node{ID: "digitalocean_droplet.web", Type: "digitalocean_droplet", Name: "web", Provider: "digitalocean", ResourceType: parser.ResourceTypeCompute}
+	edge := &graph.Edge{From: droplet, To: vpc, Relationship: "depends_on"}
+	droplet.Edges = []*graph.Edge{edge}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{vpc.ID: vpc, droplet.ID: droplet},
+		Edges: []*graph.Edge{edge},
+	}
+
+	data, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB"})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	svg := string(data)
+	assertWellFormedSVG(t, data)
+
+	if layout.Nodes[vpc.ID].Layer == layout.Nodes[droplet.ID].Layer {
+		t.Fatalf("nodes share layer %d, want a two-layer graph", layout.Nodes[vpc.ID].Layer)
+	}
+	for _, node := range []*graph.Node{vpc, droplet} {
+		want := fmt.Sprintf(`data-layer="%d" data-type="%s" data-provider="%s"`, layout.Nodes[node.ID].Layer, node.Type, node.Provider)
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG does not contain %q for %s", want, node.ID)
+		}
+	}
+}
+
+func TestRenderDiagram_ResponsiveSVG(t *testing.T) {
+	g := newStarGraph(3)
+
+	render := func(responsive bool) string {
+		t.Helper()
+		data, err := renderGraph(context.Background(), g, RenderOptions{
+			Format:        "svg",
+			Direction:     "TB",
+			ResponsiveSVG: responsive,
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		return string(data)
+	}
+	rootSize := regexp.MustCompile(`width="([^"]+)" height="([^"]+)" viewBox="0 0 ([^"]+) ([^"]+)"`)
+
+	fixed := rootSize.FindStringSubmatch(render(false))
+	if fixed == nil || fixed[1] != fixed[3] || fixed[2] != fixed[4] {
+		t.Fatalf("default SVG root size = %v, want pixel width/height matching the viewBox", fixed)
+	}
+
+	svg := render(true)
+	assertWellFormedSVG(t, []byte(svg))
+	responsive := rootSize.FindStringSubmatch(svg)
+	if responsive == nil || responsive[1] != "100%" || responsive[2] != "auto" {
+		t.Fatalf("responsive SVG root size = %v, want width=\"100%%\" height=\"auto\"", responsive)
+	}
+	if responsive[3] != fixed[3] || responsive[4] != fixed[4] {
+		t.Errorf("responsive viewBox = %s %s, want %s %s", responsive[3], responsive[4], fixed[3], fixed[4])
+	}
+}
+
+// assertWellFormedSVG fails the test unless data parses as well-formed XML
+func assertWellFormedSVG(t *testing.T, data []byte) {
+	t.Helper()
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("SVG is not well-formed XML: %v", err)
+		}
+	}
+}
+
+func TestRenderDiagram_SpecialCharacters(t *testing.T) {
+	const name = `a&b <c> "d" 'e' -- f` + "\x01"
+	web := &graph.Node{
+		ID:           `aws_instance.web["a&b <c>"]`,
+		Type:         "aws_instance",
+		Name:         name,
+		Provider:     "aws",
+		ResourceType: parser.ResourceTypeCompute,
+		Attributes:   map[string]interface{}{"region": `eu-west-1 & "more"`, "instance_type": "<t2.micro>"},
+	}
+	sg := &graph.Node{
+		ID:           `aws_security_group.web["it's"]`,
+		Type:         "aws_security_group",
+		Name:         `sg--"quoted"`,
+		Provider:     "aws",
+		ResourceType: parser.ResourceTypeSecurity,
+		Attributes:   map[string]interface{}{},
+	}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{web.ID: web, sg.ID: sg},
+		Edges: []*graph.Edge{{
+			From:         sg,
+			To:           web,
+			Relationship: "protects <&>",
+			Metadata:     map[string]string{"port": `"443"`, "protocol": "<tcp>", "via": "vpc_security_group_ids & more"},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		opts RenderOptions
+	}{
+		{name: "labels", opts: RenderOptions{IncludeLabels: true}},
+		{
+			name: "all features",
+			opts: RenderOptions{
+				IncludeLabels:     true,
+				UseIcons:          true,
+				ShowBadges:        true,
+				ShowEdgeSource:    true,
+				ShowSummary:       true,
+				GroupByProvider:   true,
+				ShowMinimap:       true,
+				Accessible:        true,
+				Title:             `<Prod> & "Staging"`,
+				FontFamily:        `"Fancy ]]> & Co", sans-serif`,
+				FontURL:           "https://fonts.example.com/font.woff2?a=1&b=2",
+				NodeLabelTemplate: `{{.Name}} <{{.Type}}>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = "svg"
+			tt.opts.Direction = "TB"
+			data, err := renderGraph(context.Background(), g, tt.opts)
+			if err != nil {
+				t.Fatalf("renderGraph() error = %v", err)
+			}
+			assertWellFormedSVG(t, data)
+
+			if !strings.Contains(string(data), "a&amp;b &lt;c&gt; &#34;d&#34; &#39;e&#39;") {
+				t.Error("SVG does not contain the escaped node name")
+			}
+		})
+	}
+}
+
+func TestRenderDiagram_ColorByRegion(t *testing.T) {
+	nodeStroke := regexp.MustCompile(`fill="url\(#grad_[^"]*\)"\s+stroke="(#[0-9A-F]{6})"`)
+	g := &graph.Graph{Nodes: map[string]*graph.Node{}, Edges: []*graph.Edge{}}
+	for id, region := range map[string]string{"aws_instance.east": "us-east-1", "aws_instance.west": "eu-west-1"} {
+		g.Nodes[id] = &graph.Node{
+			ID:           id,
+			Type:         "aws_instance",
+			Name:         strings.TrimPrefix(id, "aws_instance."),
+			Provider:     "aws",
+			ResourceType: parser.ResourceTypeCompute,
+			Attributes:   map[string]interface{}{"region": region},
+		}
+	}
+	g.Nodes["aws_s3_bucket.global"] = &graph.Node{ID: "aws_s3_bucket.global", Type: "aws_s3_bucket", Name: "global", Provider: "aws", ResourceType: parser.ResourceTypeStorage}
+
+	render := func(colorByRegion bool) (string, map[string]bool) {
+		t.Helper()
+		data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", ColorByRegion: colorByRegion})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		colors := make(map[string]bool)
+		for _, match := range nodeStroke.FindAllStringSubmatch(string(data), -1) {
+			colors[match[1]] = true
+		}
+		return string(data), colors
+	}
+
+	// Two regions plus the neutral color of the bucket without a region
+	svg, colors := render(true)
+	assertWellFormedSVG(t, []byte(svg))
+	if len(colors) != 3 {
+		t.Errorf("ColorByRegion rendered %d distinct node colors %v, want 3", len(colors), colors)
+	}
+	if !colors[darkenColor(noRegionColor, 20)] {
+		t.Error("node without a region should use the neutral color")
+	}
+	for _, label := range []string{">eu-west-1</text>", ">us-east-1</text>", ">" + noRegionLabel + "</text>"} {
+		if !strings.Contains(svg, label) {
+			t.Errorf("region legend does not contain %q", label)
+		}
+	}
+
+	// By default both instances share their resource type's color
+	svg, colors = render(false)
+	if len(colors) != 2 {
+		t.Errorf("default coloring rendered %d distinct node colors %v, want 2", len(colors), colors)
+	}
+	if strings.Contains(svg, `class="legend"`) {
+		t.Error("region legend should only be drawn with ColorByRegion")
+	}
+}
+
+func TestRenderDiagram_FontFamily(t *testing.T) {
+	const customFont = "Inter, Helvetica, sans-serif"
+	textElement := regexp.MustCompile(`<text[^>]*font-family="([^"]*)"[^>]*>([^<]*)</text>`)
+
+	newGraph := func() *graph.Graph {
+		g := newStarGraph(1)
+		g.Edges[0].Metadata = map[string]string{"port": "443"}
+		return g
+	}
+
+	t.Run("custom font family", func(t *testing.T) {
+		data, err := renderGraph(context.Background(), newGraph(), RenderOptions{
+			Format:        "svg",
+			Direction:     "TB",
+			IncludeLabels: true,
+			Title:         "Corporate Infrastructure",
+			FontFamily:    customFont,
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+
+		svg := string(data)
+		if strings.Contains(svg, defaultFontFamily) {
+			t.Error("default font family should not be used when FontFamily is set")
+		}
+
+		texts := make(map[string]bool)
+		for _, m := range textElement.FindAllStringSubmatch(svg, -1) {
+			if m[1] != customFont {
+				t.Errorf("text %q uses font-family %q, want %q", m[2], m[1], customFont)
+			}
+			texts[m[2]] = true
+		}
+		for _, want := range []string{"Corporate Infrastructure", "web0", "member_of :443"} {
+			if !texts[want] {
+				t.Errorf("expected text %q rendered with the custom font", want)
+			}
+		}
+	})
+
+	t.Run("embedded web font", func(t *testing.T) {
+		data, err := renderGraph(context.Background(), newGraph(), RenderOptions{
+			Format:     "svg",
+			FontFamily: customFont,
+			FontURL:    "https://fonts.example.com/inter.woff2",
+		})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+
+		want := `@font-face { font-family: "Inter"; src: url("https://fonts.example.com/inter.woff2"); }`
+		if !strings.Contains(string(data), want) {
+			t.Errorf("rendered SVG missing %q", want)
+		}
+	})
+
+	t.Run("font URL without family", func(t *testing.T) {
+		_, err := renderGraph(context.Background(), newGraph(), RenderOptions{
+			Format:  "svg",
+			FontURL: "https://fonts.example.com/inter.woff2",
+		})
+		if err == nil {
+			t.Error("renderGraph() with FontURL but no FontFamily should return error")
+		}
+	})
+}
+
+func TestRenderDiagram_EmptyGraphPlaceholder(t *testing.T) {
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{},
+		Edges: []*graph.Edge{},
+	}
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{
+		Format: "svg",
+		Title:  "Empty Infrastructure",
+	})
+	if err != nil {
+		t.Fatalf("renderGraph() with empty graph error = %v", err)
+	}
+
+	svg := string(data)
+	if !strings.Contains(svg, emptyDiagramMessage) {
+		t.Error("empty diagram should contain the placeholder message")
+	}
+	if !strings.Contains(svg, "Empty Infrastructure") {
+		t.Error("empty diagram should still contain the title")
+	}
+	if !strings.Contains(svg, `width="600" height="300"`) {
+		t.Error("empty diagram should use the minimum placeholder canvas size")
+	}
+
+	// The PNG renderer handles empty graphs the same way
+	layout := CalculateImprovedLayout(g, "TB", 220, 160, 140, 120)
+	pngData, err := NewPNGRenderer(RenderOptions{}).Render(layout, g)
+	if err != nil {
+		t.Fatalf("PNGRenderer.Render() with empty graph error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() < 600 || bounds.Dy() < 300 {
+		t.Errorf("empty PNG size = %dx%d, want at least 600x300", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestRenderDiagram_NestContainers(t *testing.T) {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws", ResourceType: parser.ResourceTypeNetwork}
+	subnet := &graph.Node{ID: "aws_subnet.private", Type: "aws_subnet", Name: "private", Provider: "aws", ResourceType: parser.ResourceTypeNetwork}
+	instance := &graph.Node{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws", ResourceType: parser.ResourceTypeCompute}
+	bucket := &graph.Node{ID: "aws_s3_bucket.assets", Type: "aws_s3_bucket", Name: "assets", Provider: "aws", ResourceType: parser.ResourceTypeStorage}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{vpc.ID: vpc, subnet.ID: subnet, instance.ID: instance, bucket.ID: bucket},
+		Edges: []*graph.Edge{
+			{From: vpc, To: subnet, Relationship: "contains"},
+			{From: instance, To: subnet, Relationship: "member_of"},
+			{From: instance, To: bucket, Relationship: "uses_storage"},
+		},
+	}
+
+	data, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", IncludeLabels: true, NestContainers: true})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	assertWellFormedSVG(t, data)
+
+	within := func(inner, outer *NodeLayout) bool {
+		return inner.Position.X >= outer.Position.X && inner.Position.Y >= outer.Position.Y &&
+			inner.Position.X+inner.Width <= outer.Position.X+outer.Width &&
+			inner.Position.Y+inner.Height <= outer.Position.Y+outer.Height
+	}
+	vpcBox, subnetBox, instanceBox, bucketBox := layout.Nodes[vpc.ID], layout.Nodes[subnet.ID], layout.Nodes[instance.ID], layout.Nodes[bucket.ID]
+	if !within(instanceBox, subnetBox) {
+		t.Errorf("instance box %+v is not within the subnet box %+v", *instanceBox, *subnetBox)
+	}
+	if !within(subnetBox, vpcBox) {
+		t.Errorf("subnet box %+v is not within the VPC box %+v", *subnetBox, *vpcBox)
+	}
+	if within(bucketBox, vpcBox) || bucketBox.Parent != "" {
+		t.Errorf("bucket box %+v is nested, want it outside the VPC", *bucketBox)
+	}
+	if instanceBox.Parent != subnet.ID || subnetBox.Parent != vpc.ID || vpcBox.Parent != "" {
+		t.Errorf("Parent = %q, %q, %q; want %q, %q, top level", instanceBox.Parent, subnetBox.Parent, vpcBox.Parent, subnet.ID, vpc.ID)
+	}
+
+	// Containment arrows are suppressed; other edges are still drawn
+	if len(layout.Edges) != 1 || layout.Edges[0].Edge.Relationship != "uses_storage" {
+		t.Errorf("layout has %d edges, want only the uses_storage edge", len(layout.Edges))
+	}
+	if count := strings.Count(string(data), `data-container="true"`); count != 2 {
+		t.Errorf("SVG has %d containers, want 2", count)
+	}
+
+	// Without the option the containment edges are drawn as arrows
+	_, layout, err = RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg"})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	if len(layout.Edges) != 3 || layout.Nodes[instance.ID].Parent != "" {
+		t.Errorf("layout without NestContainers has %d edges, want 3 and no nesting", len(layout.Edges))
+	}
+}
+
+func TestRenderDiagram_Compact(t *testing.T) {
+	g := newStarGraph(6)
+
+	_, defaultLayout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	data, compactLayout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true, Compact: true})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	assertWellFormedSVG(t, data)
+
+	defaultArea := defaultLayout.Width * defaultLayout.Height
+	compactArea := compactLayout.Width * compactLayout.Height
+	if compactArea > defaultArea/3 {
+		t.Errorf("compact canvas %.0fx%.0f is not substantially smaller than the default %.0fx%.0f",
+			compactLayout.Width, compactLayout.Height, defaultLayout.Width, defaultLayout.Height)
+	}
+
+	// Nodes must not overlap
+	nodes := make([]*NodeLayout, 0, len(compactLayout.Nodes))
+	for _, node := range compactLayout.Nodes {
+		nodes = append(nodes, node)
+	}
+	for i, a := range nodes {
+		for _, b := range nodes[i+1:] {
+			if a.Position.X < b.Position.X+b.Width && b.Position.X < a.Position.X+a.Width &&
+				a.Position.Y < b.Position.Y+b.Height && b.Position.Y < a.Position.Y+a.Height {
+				t.Errorf("nodes %s and %s overlap", a.Node.ID, b.Node.ID)
+			}
+		}
+	}
+
+	// Labels fit on a single line without the type line
+	svg := string(data)
+	if !strings.Contains(svg, ">web0</text>") {
+		t.Error("compact SVG is missing the node label")
+	}
+	if strings.Contains(svg, "Instance</text>") {
+		t.Error("compact SVG should not render the type line")
+	}
+}
+
+// newTaggedGraph returns instances tagged with Environment and Team, plus a GCP bucket
+// whose labels carry the same keys and an untagged VPC
+func newTaggedGraph() *graph.Graph {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws"}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{vpc.ID: vpc}}
+
+	tagged := []struct {
+		id, env, team string
+	}{
+		{"aws_instance.api", "prod", "payments"},
+		{"aws_instance.worker", "prod", "search"},
+		{"aws_instance.staging", "staging", "payments"},
+	}
+	for _, tt := range tagged {
+		node := &graph.Node{ID: tt.id, Type: "aws_instance", Name: strings.TrimPrefix(tt.id, "aws_instance."), Provider: "aws",
+			Attributes: map[string]interface{}{"tags": map[string]interface{}{"Environment": tt.env, "Team": tt.team}}}
+		edge := &graph.Edge{From: node, To: vpc, Relationship: "member_of"}
+		node.Edges = []*graph.Edge{edge}
+		g.Nodes[node.ID] = node
+		g.Edges = append(g.Edges, edge)
+	}
+
+	bucket := &graph.Node{ID: "google_storage_bucket.assets", Type: "google_storage_bucket", Name: "assets", Provider: "gcp",
+		Attributes: map[string]interface{}{"labels": map[string]interface{}{"Environment": "prod", "Team": "payments"}}}
+	g.Nodes[bucket.ID] = bucket
+
+	return g
+}
+
+func TestRenderDiagram_GroupByTag(t *testing.T) {
+	g := newTaggedGraph()
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true, GroupByTag: "Environment"})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	assertWellFormedSVG(t, data)
+
+	cluster := regexp.MustCompile(`<g class="cluster" data-tag="([^"]+)">\s*<rect[^>]*fill="([^"]+)"`)
+	tints := make(map[string]string)
+	for _, match := range cluster.FindAllStringSubmatch(string(data), -1) {
+		tints[match[1]] = match[2]
+	}
+	if len(tints) != 2 || tints["prod"] == "" || tints["staging"] == "" {
+		t.Errorf("rendered tag clusters %v, want prod and staging", tints)
+	}
+	if tints["prod"] == tints["staging"] {
+		t.Errorf("prod and staging clusters share the tint %s", tints["prod"])
+	}
+	if !strings.Contains(string(data), ">Environment=prod</text>") {
+		t.Error("tag cluster is missing its key=value label")
+	}
+}
+
+func TestRenderDiagram_FilterByTag(t *testing.T) {
+	g := newTaggedGraph()
+
+	_, layout, err := renderGraphWithLayout(context.Background(), g, RenderOptions{
+		Format:      "svg",
+		Direction:   "TB",
+		FilterByTag: map[string]string{"Environment": "prod", "Team": "payments"},
+	})
+	if err != nil {
+		t.Fatalf("renderGraphWithLayout() error = %v", err)
+	}
+
+	want := []string{"aws_instance.api", "google_storage_bucket.assets"}
+	if len(layout.Nodes) != len(want) {
+		t.Errorf("filtered layout has %d nodes, want %d", len(layout.Nodes), len(want))
+	}
+	for _, id := range want {
+		if layout.Nodes[id] == nil {
+			t.Errorf("node %s matching every tag was filtered out", id)
+		}
+	}
+	// The untagged VPC is dropped along with the edge to it
+	if len(layout.Edges) != 0 {
+		t.Errorf("filtered layout has %d edges, want 0", len(layout.Edges))
+	}
+
+	// The input graph must not be modified
+	if len(g.Nodes) != 5 || len(g.Edges) != 3 {
+		t.Errorf("input graph was modified: %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+}
+
+func TestRenderDiagram_RadialLayout(t *testing.T) {
+	g := newStarGraph(3)
+	center := "aws_vpc.main"
+
+	_, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", LayoutAlgorithm: LayoutAlgorithmRadial, LayoutCenter: center})
+	if err != nil {
+		t.Fatalf("radial render failed: %v", err)
+	}
+	for id, nl := range layout.Nodes {
+		want := 1
+		if id == center {
+			want = 0
+		}
+		if nl.Layer != want {
+			t.Errorf("%s is in ring %d, want %d", id, nl.Layer, want)
+		}
+	}
+
+	for name, opts := range map[string]RenderOptions{
+		"unknown algorithm": {Format: "svg", LayoutAlgorithm: "circular"},
+		"no center":         {Format: "svg", LayoutAlgorithm: LayoutAlgorithmRadial},
+		"missing center":    {Format: "svg", LayoutAlgorithm: LayoutAlgorithmRadial, LayoutCenter: "aws_vpc.none"},
+	} {
+		if _, _, err := RenderDiagramBytesWithLayout(context.Background(), g, opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRenderDiagram_Lanes(t *testing.T) {
+	g := newStarGraph(2)
+	for _, node := range g.Nodes {
+		node.ResourceType = parser.GetResourceType(node.Type)
+	}
+	db := &graph.Node{ID: "aws_db_instance.main", Type: "aws_db_instance", Name: "main", Provider: "aws", ResourceType: parser.ResourceTypeDatabase}
+	g.Nodes[db.ID] = db
+	g.Edges = append(g.Edges, &graph.Edge{From: db, To: g.Nodes["aws_vpc.main"], Relationship: "member_of"})
+
+	for _, direction := range []string{"TB", "LR"} {
+		data, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: direction, IncludeLabels: true, LaneBy: LaneByType})
+		if err != nil {
+			t.Fatalf("%s: RenderDiagramBytesWithLayout() error = %v", direction, err)
+		}
+		svg := string(data)
+		assertWellFormedSVG(t, data)
+
+		if len(layout.Lanes) != 3 {
+			t.Errorf("%s: layout has %d lanes, want 3", direction, len(layout.Lanes))
+		}
+		if !strings.Contains(svg, "<!-- Lanes -->") || strings.Count(svg, `stroke-dasharray="6 6"`) < 2 {
+			t.Errorf("%s: SVG should draw dividers between the 3 lanes", direction)
+		}
+		for _, label := range []string{"Network", "Compute", "Database"} {
+			if !strings.Contains(svg, ">"+label+"</text>") {
+				t.Errorf("%s: SVG does not contain lane label %q", direction, label)
+			}
+		}
+	}
+
+	data, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", LaneBy: LaneByNone})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	if layout.Lanes != nil || strings.Contains(string(data), "<!-- Lanes -->") {
+		t.Error("LaneByNone should not draw lanes")
+	}
+
+	if _, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", LaneBy: "region"}); err == nil {
+		t.Error("renderGraph() should reject an unknown lane grouping")
+	}
+}
+
+func TestRenderDiagram_ShowOutputs(t *testing.T) {
+	lb := &graph.Node{
+		ID:           "aws_lb.web",
+		Type:         "aws_lb",
+		Name:         "web",
+		Provider:     "aws",
+		ResourceType: parser.ResourceTypeLoadBalancer,
+		Attributes:   map[string]interface{}{"id": "arn:aws:elasticloadbalancing:lb/web", "dns_name": "web-123.elb.amazonaws.com", "region": "us-east-1"},
+	}
+	db := &graph.Node{
+		ID:           "aws_db_instance.main",
+		Type:         "aws_db_instance",
+		Name:         "main",
+		Provider:     "aws",
+		ResourceType: parser.ResourceTypeDatabase,
+		Attributes:   map[string]interface{}{"id": "db-1", "password": "hunter2", "region": "us-east-1", "multi_az": "true"},
+	}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{lb.ID: lb, db.ID: db}, Edges: []*graph.Edge{}}
+	outputs := []parser.Output{
+		{Name: "lb_dns", Value: "web-123.elb.amazonaws.com"},
+		{Name: "db_password", Value: "hunter2", Sensitive: true},
+		{Name: "replicas", Value: 3.0},
+		// Shared by every resource, so not a reference to any of them
+		{Name: "region", Value: "us-east-1"},
+		{Name: "multi_az", Value: "true"},
+	}
+
+	data, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true, ShowOutputs: true, Outputs: outputs})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	svg := string(data)
+	assertWellFormedSVG(t, data)
+
+	for _, output := range outputs {
+		if layout.Nodes["output."+output.Name] == nil {
+			t.Errorf("layout has no note node for output %s", output.Name)
+		}
+		if !strings.Contains(svg, "<!-- Output: "+output.Name+" -->") {
+			t.Errorf("SVG does not draw output %s as a note", output.Name)
+		}
+	}
+	for _, text := range []string{">web-123.elb.amazonaws.com</text>", ">(sensitive)</text>", ">3</text>"} {
+		if !strings.Contains(svg, text) {
+			t.Errorf("SVG does not contain output value %q", text)
+		}
+	}
+	if strings.Contains(svg, "hunter2") {
+		t.Error("SVG should not show the value of a sensitive output")
+	}
+
+	// Only the load balancer DNS name refers to a resource; region and flag values match
+	// attributes that don't identify one
+	var edges []string
+	for _, edgeLayout := range layout.Edges {
+		edges = append(edges, edgeLayout.Edge.From.ID+" "+edgeLayout.Edge.Relationship+" "+edgeLayout.Edge.To.ID)
+	}
+	if want := []string{"output.lb_dns annotates aws_lb.web"}; fmt.Sprint(edges) != fmt.Sprint(want) {
+		t.Errorf("edges = %v, want %v", edges, want)
+	}
+	if len(g.Nodes) != 2 || len(g.Edges) != 0 {
+		t.Errorf("input graph was modified: %d nodes, %d edges", len(g.Nodes), len(g.Edges))
+	}
+
+	data, err = renderGraph(context.Background(), g, RenderOptions{Format: "svg", IncludeLabels: true, Outputs: outputs})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	if strings.Contains(string(data), "<!-- Output:") {
+		t.Error("outputs should not be drawn unless ShowOutputs is set")
+	}
+}
+
+// newMeshGraph returns a graph of hubs VPCs and leaves instances, each instance a member of
+// two neighboring VPCs
+func newMeshGraph(hubs, leaves int) *graph.Graph {
+	g := &graph.Graph{Nodes: make(map[string]*graph.Node), Edges: []*graph.Edge{}}
+	vpcs := make([]*graph.Node, hubs)
+	for i := range vpcs {
+		vpcs[i] = &graph.Node{ID: fmt.Sprintf("aws_vpc.hub%d", i), Type: "aws_vpc", Name: fmt.Sprintf("hub%d", i), Provider: "aws", ResourceType: parser.ResourceTypeNetwork}
+		g.Nodes[vpcs[i].ID] = vpcs[i]
+	}
+	for i := 0; i < leaves; i++ {
+		node := &graph.Node{ID: fmt.Sprintf("aws_instance.web%d", i), Type: "aws_instance", Name: fmt.Sprintf("web%d", i), Provider: "aws", ResourceType: parser.ResourceTypeCompute}
+		g.Nodes[node.ID] = node
+		for _, vpc := range []*graph.Node{vpcs[i%hubs], vpcs[(i+1)%hubs]} {
+			edge := &graph.Edge{From: node, To: vpc, Relationship: "member_of"}
+			node.Edges = append(node.Edges, edge)
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+	return g
+}
+
+func TestRenderDiagram_SimpleEdges(t *testing.T) {
+	g := newMeshGraph(4, 40)
+	maxPoints := func(layout *Layout) int {
+		most := 0
+		for _, edgeLayout := range layout.Edges {
+			most = max(most, len(edgeLayout.Points))
+		}
+		return most
+	}
+
+	curved, curvedLayout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB"})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	if maxPoints(curvedLayout) <= 3 {
+		t.Fatal("graphs under the default threshold should keep Bezier curves")
+	}
+
+	simple, simpleLayout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", SimpleEdgeThreshold: 50})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	if got := maxPoints(simpleLayout); got > 3 {
+		t.Errorf("simple edges have up to %d points, want at most 3", got)
+	}
+	if len(simpleLayout.Edges) != len(g.Edges) {
+		t.Errorf("simple layout has %d edges, want %d", len(simpleLayout.Edges), len(g.Edges))
+	}
+	if len(simple) >= len(curved) {
+		t.Errorf("SVG with simple edges is %d bytes, want smaller than the curved %d", len(simple), len(curved))
+	}
+	assertWellFormedSVG(t, simple)
+
+	// A threshold at or above the edge count keeps the curves
+	_, layout, err := RenderDiagramBytesWithLayout(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", SimpleEdgeThreshold: len(g.Edges)})
+	if err != nil {
+		t.Fatalf("RenderDiagramBytesWithLayout() error = %v", err)
+	}
+	if maxPoints(layout) <= 3 {
+		t.Error("edges should stay curved when the edge count does not exceed the threshold")
+	}
+}
+
+// BenchmarkRenderDiagram_LargeGraph compares curved edges with the simple edges drawn by
+// default above DefaultSimpleEdgeThreshold, reporting the SVG size of each
+func BenchmarkRenderDiagram_LargeGraph(b *testing.B) {
+	g := newMeshGraph(10, 600)
+	ctx := context.Background()
+
+	for _, bm := range []struct {
+		name      string
+		threshold int
+	}{
+		{name: "curved", threshold: -1},
+		{name: "simple", threshold: 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			opts := RenderOptions{Format: "svg", Direction: "TB", SimpleEdgeThreshold: bm.threshold}
+			var size int
+			for i := 0; i < b.N; i++ {
+				data, err := renderGraph(ctx, g, opts)
+				if err != nil {
+					b.Fatalf("renderGraph() error = %v", err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "svg-bytes")
+		})
+	}
+}
+
+func TestRenderDiagram_HighlightUnknown(t *testing.T) {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws", ResourceType: parser.ResourceTypeNetwork}
+	widget := &graph.Node{ID: "acme_widget.thing", Type: "acme_widget", Name: "thing", Provider: "acme", ResourceType: parser.GetResourceType("acme_widget")}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{vpc.ID: vpc, widget.ID: widget},
+		Edges: []*graph.Edge{{From: widget, To: vpc, Relationship: "member_of"}},
+	}
+
+	for _, compact := range []bool{false, true} {
+		data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true, Compact: compact, HighlightUnknown: true})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		svg := string(data)
+		assertWellFormedSVG(t, data)
+
+		if n := strings.Count(svg, "<!-- Unknown resource type -->"); n != 1 {
+			t.Errorf("compact=%v: SVG has %d unknown markers, want 1", compact, n)
+		}
+		if n := strings.Count(svg, `stroke-dasharray="6,4"`); n != 1 {
+			t.Errorf("compact=%v: SVG has %d dashed borders, want 1 for the unknown node", compact, n)
+		}
+		if !strings.Contains(svg, `stroke="`+darkenColor(unknownNodeColor, 20)+`"`) {
+			t.Errorf("compact=%v: unknown node should have a gray border", compact)
+		}
+	}
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	if strings.Contains(string(data), "<!-- Unknown resource type -->") || strings.Contains(string(data), `stroke-dasharray="6,4"`) {
+		t.Error("unknown nodes should only be highlighted with HighlightUnknown")
+	}
+}
+
+func TestRenderDiagram_HighlightPath(t *testing.T) {
+	node := func(id string, rt parser.ResourceType) *graph.Node {
+		typ, name, _ := strings.Cut(id, ".")
+		return &graph.Node{ID: id, Type: typ, Name: name, Provider: "aws", ResourceType: rt}
+	}
+	lb := node("aws_lb.main", parser.ResourceTypeLoadBalancer)
+	web := node("aws_instance.web", parser.ResourceTypeCompute)
+	worker := node("aws_instance.worker", parser.ResourceTypeCompute)
+	queue := node("aws_sqs_queue.jobs", parser.ResourceTypeUnknown)
+	db := node("aws_db_instance.main", parser.ResourceTypeDatabase)
+	logs := node("aws_s3_bucket.logs", parser.ResourceTypeStorage)
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{lb.ID: lb, web.ID: web, worker.ID: worker, queue.ID: queue, db.ID: db, logs.ID: logs},
+		Edges: []*graph.Edge{
+			{From: lb, To: worker, Relationship: "routes_to"},
+			{From: worker, To: queue, Relationship: "depends_on"},
+			{From: queue, To: db, Relationship: "depends_on"},
+			{From: lb, To: web, Relationship: "routes_to"},
+			{From: db, To: web, Relationship: "depends_on"},
+		},
+	}
+
+	// Captures whether each node group is dimmed, keyed by its gradient (node ID)
+	nodeGroup := regexp.MustCompile(`<g class="node( dimmed)?"[^>]*>\s*<rect[^>]*fill="url\(#grad_([^)]+)\)"`)
+	pathEdge := `stroke="` + pathHighlightColor + `" stroke-width="3.0"`
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", HighlightPath: [2]string{"aws_lb.main", "aws_db_instance.main"}})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	svg := string(data)
+	assertWellFormedSVG(t, data)
+
+	wantDimmed := map[string]bool{
+		"aws_lb_main":          false,
+		"aws_instance_web":     false,
+		"aws_db_instance_main": false,
+		"aws_instance_worker":  true,
+		"aws_sqs_queue_jobs":   true,
+		"aws_s3_bucket_logs":   true,
+	}
+	matches := nodeGroup.FindAllStringSubmatch(svg, -1)
+	if len(matches) != len(wantDimmed) {
+		t.Fatalf("found %d node groups, want %d", len(matches), len(wantDimmed))
+	}
+	for _, m := range matches {
+		if dimmed, id := m[1] != "", m[2]; dimmed != wantDimmed[id] {
+			t.Errorf("node %s dimmed = %v, want %v", id, dimmed, wantDimmed[id])
+		}
+	}
+	// The path's two edges are bold, including the one pointing against the path
+	if n := strings.Count(svg, pathEdge); n != 2 {
+		t.Errorf("SVG has %d highlighted edges, want 2", n)
+	}
+	if n := strings.Count(svg, `<g class="edge dimmed"`); n != 3 {
+		t.Errorf("SVG has %d dimmed edges, want 3", n)
+	}
+	if n := strings.Count(svg, `stroke="`+pathHighlightColor+`" stroke-width="2.5"`); n != 3 {
+		t.Errorf("SVG has %d nodes with the path border, want 3", n)
+	}
+
+	// Unconnected nodes highlight nothing
+	data, err = renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", HighlightPath: [2]string{"aws_lb.main", "aws_s3_bucket.logs"}})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	if strings.Contains(string(data), "dimmed") || strings.Contains(string(data), pathHighlightColor) {
+		t.Error("nothing should be highlighted when no path connects the nodes")
+	}
+
+	if _, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", HighlightPath: [2]string{"aws_lb.main", ""}}); err == nil {
+		t.Error("renderGraph() with a single highlight path node should fail")
+	}
+}
+
+func TestRenderDiagram_ShowSourceFiles(t *testing.T) {
+	vpc := &graph.Node{ID: "aws_vpc.main", Type: "aws_vpc", Name: "main", Provider: "aws", ResourceType: parser.ResourceTypeNetwork,
+		SourceFile: "network/vpc.tf", SourceLine: 12}
+	web := &graph.Node{ID: "aws_instance.web", Type: "aws_instance", Name: "web", Provider: "aws", ResourceType: parser.ResourceTypeCompute}
+	g := &graph.Graph{
+		Nodes: map[string]*graph.Node{vpc.ID: vpc, web.ID: web},
+		Edges: []*graph.Edge{{From: web, To: vpc, Relationship: "member_of"}},
+	}
+
+	for _, compact := range []bool{false, true} {
+		data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true, Compact: compact, ShowSourceFiles: true})
+		if err != nil {
+			t.Fatalf("renderGraph() error = %v", err)
+		}
+		svg := string(data)
+		assertWellFormedSVG(t, data)
+
+		if n := strings.Count(svg, `data-source="network/vpc.tf:12"`); n != 1 {
+			t.Errorf("compact=%v: SVG has %d data-source attributes for the VPC, want 1", compact, n)
+		}
+		if n := strings.Count(svg, "<title>network/vpc.tf:12</title>"); n != 1 {
+			t.Errorf("compact=%v: SVG has %d source tooltips for the VPC, want 1", compact, n)
+		}
+		if n := strings.Count(svg, "data-source="); n != 1 {
+			t.Errorf("compact=%v: SVG has %d data-source attributes, want 1 (nodes without a source file have none)", compact, n)
+		}
+	}
+
+	data, err := renderGraph(context.Background(), g, RenderOptions{Format: "svg", Direction: "TB", IncludeLabels: true})
+	if err != nil {
+		t.Fatalf("renderGraph() error = %v", err)
+	}
+	if strings.Contains(string(data), "data-source=") || strings.Contains(string(data), "<title>") {
+		t.Error("source locations should only be shown with ShowSourceFiles")
+	}
+}
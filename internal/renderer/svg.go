@@ -3,76 +3,199 @@ package renderer
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/xml"
 	"fmt"
-	"html"
+	"math"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/ankek/terraform-provider-cartography/internal/graph"
+	"github.com/ankek/terraform-provider-cartography/internal/parser"
 )
 
+// SVGPadding is the margin added around the layout on every side of the SVG canvas;
+// layout coordinates are shifted by it when drawn
+const SVGPadding = 50.0
+
 // SVGRenderer handles SVG generation
 type SVGRenderer struct {
-	buf     *bytes.Buffer
-	options RenderOptions
+	buf           *bytes.Buffer
+	options       RenderOptions
+	labelTemplate *template.Template // Parsed NodeLabelTemplate (nil = node name)
+	regionColors  map[string]string  // Node color per region with ColorByRegion (nil = color by type)
+	path          *highlightedPath   // Nodes and edges of HighlightPath (nil = no path highlighted)
 }
 
-// NewSVGRenderer creates a new SVG renderer
+// NewSVGRenderer creates a new SVG renderer.
+// An invalid NodeLabelTemplate is logged and node names are used instead.
 func NewSVGRenderer(opts RenderOptions) *SVGRenderer {
-	return &SVGRenderer{
+	r := &SVGRenderer{
 		buf:     &bytes.Buffer{},
 		options: opts,
 	}
+
+	if opts.NodeLabelTemplate != "" {
+		tmpl, err := template.New("node-label").Parse(opts.NodeLabelTemplate)
+		if err != nil {
+			opts.logger().Warn("invalid node label template; using node names", "error", err)
+		} else {
+			r.labelTemplate = tmpl
+		}
+	}
+
+	return r
 }
 
 // Render generates SVG from the layout
 func (r *SVGRenderer) Render(layout *Layout, g *graph.Graph) ([]byte, error) {
 	// Add padding
-	padding := 50.0
+	padding := SVGPadding
 	width := layout.Width + 2*padding
 	height := layout.Height + 2*padding
+	if len(g.Nodes) == 0 {
+		width = math.Max(width, emptyDiagramWidth)
+		height = math.Max(height, emptyDiagramHeight)
+	}
+	if r.options.ShowSummary {
+		height += summaryHeight
+	}
+	showMinimap := r.options.ShowMinimap && len(g.Nodes) > 0
+	minimapScale := 0.0
+	minimapSpace := 0.0
+	if showMinimap {
+		minimapScale = math.Min(minimapMaxWidth/layout.Width, minimapMaxHeight/layout.Height)
+		minimapSpace = layout.Height*minimapScale + minimapMargin
+		height += minimapSpace
+	}
+	if ends := r.options.HighlightPath; ends != [2]string{} {
+		r.path = findHighlightedPath(g, ends)
+		if r.path == nil {
+			r.options.logger().Warn("no path to highlight; rendering without it", "from", ends[0], "to", ends[1])
+		}
+	}
+	var legend []regionLegendEntry
+	var legendOffsets []Point
+	if r.options.ColorByRegion {
+		r.regionColors = assignRegionColors(g)
+		legend = r.regionLegend(g)
+		if len(legend) > 0 {
+			var legendHeight float64
+			legendOffsets, legendHeight = layoutLegend(legend, width-2*padding)
+			height += legendHeight + legendMargin
+		}
+	}
 
 	// Start SVG
-	r.writeHeader(width, height)
+	r.writeHeader(width, height, g)
+	if r.options.FontURL != "" {
+		r.writeFontFace()
+	}
 
 	// Add title if present
 	if r.options.Title != "" {
 		r.writeTitle(r.options.Title, width, padding)
 	}
 
-	// Render edges first (so they appear below nodes)
-	for _, edgeLayout := range layout.Edges {
-		r.renderEdge(edgeLayout, padding)
+	if len(g.Nodes) == 0 {
+		r.writePlaceholder(width, height)
 	}
 
-	// Render nodes
-	for nodeID, nodeLayout := range layout.Nodes {
+	// Lanes, then provider and tag clusters, sit behind everything else
+	if len(layout.Lanes) > 0 {
+		r.renderLanes(layout, padding)
+	}
+	if r.options.GroupByProvider {
+		r.renderProviderClusters(layout, g, padding)
+	}
+	if r.options.GroupByTag != "" {
+		r.renderTagClusters(layout, g, padding)
+	}
+
+	// Render edges first (so they appear below nodes); reciprocal pairs share one line
+	edges, bidirectional := pairMutualEdges(layout.Edges)
+	for _, edgeLayout := range edges {
+		r.renderEdge(edgeLayout, padding, bidirectional[edgeLayout] || bidirectionalRelationships[edgeLayout.Edge.Relationship])
+	}
+	r.renderJunctions(edges, padding)
+
+	// Render nodes, containers before the members drawn inside them
+	containers := make(map[string]bool)
+	for _, nodeLayout := range layout.Nodes {
+		if nodeLayout.Parent != "" {
+			containers[nodeLayout.Parent] = true
+		}
+	}
+	for _, nodeID := range nodesInDrawOrder(layout) {
+		nodeLayout := layout.Nodes[nodeID]
 		node := g.Nodes[nodeID]
-		if node != nil {
-			nodeLayout.Node = node
+		if node == nil {
+			continue
+		}
+		nodeLayout.Node = node
+		if containers[nodeID] {
+			r.renderContainer(nodeLayout, padding)
+		} else {
 			r.renderNode(nodeLayout, padding)
 		}
 	}
 
+	// Minimap sits right-aligned in the reserved space below the diagram
+	if showMinimap {
+		minimapX := width - padding - layout.Width*minimapScale
+		minimapY := layout.Height + padding + minimapMargin
+		r.renderMinimap(layout, g, minimapX, minimapY, minimapScale)
+	}
+
+	// Region legend sits left-aligned below the diagram and minimap
+	if len(legend) > 0 {
+		r.renderRegionLegend(legend, legendOffsets, padding, layout.Height+padding+minimapSpace+legendMargin)
+	}
+
+	// Summary footer sits in the reserved space above the bottom padding
+	if r.options.ShowSummary {
+		r.writeSummary(formatSummary(g), width/2, height-padding)
+	}
+
 	// Close SVG
 	r.buf.WriteString("</svg>")
 
 	return r.buf.Bytes(), nil
 }
 
-// writeHeader writes the SVG header with professional styling
-func (r *SVGRenderer) writeHeader(width, height float64) {
+// writeHeader writes the SVG header with professional styling.
+// With RenderOptions.Accessible, the document is labelled by a <title> and <desc> summarizing g.
+func (r *SVGRenderer) writeHeader(width, height float64, g *graph.Graph) {
 	// Write directly to buffer to avoid double allocation
 	r.buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
 <svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"
-     width="`)
-	r.buf.WriteString(formatFloat(width))
-	r.buf.WriteString(`" height="`)
-	r.buf.WriteString(formatFloat(height))
+     `)
+	if r.options.ResponsiveSVG {
+		// The viewBox alone fixes the aspect ratio; the container decides the size
+		r.buf.WriteString(`width="100%" height="auto`)
+	} else {
+		r.buf.WriteString(`width="`)
+		r.buf.WriteString(formatFloat(width))
+		r.buf.WriteString(`" height="`)
+		r.buf.WriteString(formatFloat(height))
+	}
 	r.buf.WriteString(`" viewBox="0 0 `)
 	r.buf.WriteString(formatFloat(width))
 	r.buf.WriteByte(' ')
 	r.buf.WriteString(formatFloat(height))
-	r.buf.WriteString(`">
+	r.buf.WriteByte('"')
+	if r.options.Accessible {
+		r.buf.WriteString(` role="img" aria-labelledby="diagram-title diagram-desc">
+<title id="diagram-title">`)
+		r.buf.WriteString(escapeXML(r.documentTitle()))
+		r.buf.WriteString(`</title>
+<desc id="diagram-desc">`)
+		r.buf.WriteString(escapeXML(formatSummary(g)))
+		r.buf.WriteString(`</desc>`)
+	} else {
+		r.buf.WriteByte('>')
+	}
+	r.buf.WriteString(`
 <defs>
   <!-- Gradient for background -->
   <linearGradient id="bgGradient" x1="0%" y1="0%" x2="0%" y2="100%">
@@ -109,6 +232,11 @@ func (r *SVGRenderer) writeHeader(width, height float64) {
     <path d="M1,1 L1,7 L7,4 z" fill="#495057" stroke="white" stroke-width="0.8" stroke-linejoin="miter"/>
   </marker>
 
+  <!-- Mirrored arrowhead for the start of bidirectional edges -->
+  <marker id="arrowhead-start" markerWidth="8" markerHeight="8" refX="1" refY="4" orient="auto">
+    <path d="M7,1 L7,7 L1,4 z" fill="#495057" stroke="white" stroke-width="0.8" stroke-linejoin="miter"/>
+  </marker>
+
   <!-- Glow effect for icons -->
   <filter id="iconGlow">
     <feGaussianBlur stdDeviation="2" result="coloredBlur"/>
@@ -132,6 +260,420 @@ func (r *SVGRenderer) writeHeader(width, height float64) {
 `)
 }
 
+// defaultDocumentTitle is the accessible document title used when RenderOptions.Title is empty
+const defaultDocumentTitle = "Infrastructure diagram"
+
+// documentTitle returns the accessible title of the SVG document
+func (r *SVGRenderer) documentTitle() string {
+	if r.options.Title == "" {
+		return defaultDocumentTitle
+	}
+	return r.options.Title
+}
+
+// defaultFontFamily is used for all text unless RenderOptions.FontFamily is set
+const defaultFontFamily = "'Segoe UI', Arial, sans-serif"
+
+// fontFamily returns the font-family attribute value for text elements
+func (r *SVGRenderer) fontFamily() string {
+	if r.options.FontFamily == "" {
+		return defaultFontFamily
+	}
+	return escapeXML(r.options.FontFamily)
+}
+
+// writeFontFace embeds RenderOptions.FontURL as a web font named after the first FontFamily entry
+func (r *SVGRenderer) writeFontFace() {
+	name, _, _ := strings.Cut(r.options.FontFamily, ",")
+	name = strings.Trim(strings.TrimSpace(name), `'"`)
+
+	r.buf.WriteString(fmt.Sprintf(`
+<defs>
+  <style type="text/css"><![CDATA[
+    @font-face { font-family: "%s"; src: url("%s"); }
+  ]]></style>
+</defs>
+`, escapeCDATA(name), r.options.FontURL))
+}
+
+// writePlaceholder writes a centered message for diagrams without resources
+func (r *SVGRenderer) writePlaceholder(width, height float64) {
+	r.buf.WriteString(fmt.Sprintf(`
+<!-- Empty diagram placeholder -->
+<text x="%.0f" y="%.0f"
+      font-family="%s"
+      font-size="20" fill="#6c757d" text-anchor="middle">%s</text>
+`, width/2, height/2, r.fontFamily(), escapeXML(emptyDiagramMessage)))
+}
+
+// writeSummary writes the summary footer centered at x with its baseline at y
+func (r *SVGRenderer) writeSummary(summary string, x, y float64) {
+	r.buf.WriteString(fmt.Sprintf(`
+<!-- Summary footer -->
+<text class="summary" x="%.0f" y="%.0f"
+      font-family="%s"
+      font-size="13" fill="#6c757d" text-anchor="middle">%s</text>
+`, x, y, r.fontFamily(), escapeXML(summary)))
+}
+
+// Minimap bounds and the gap between it and the diagram
+const (
+	minimapMaxWidth  = 200.0
+	minimapMaxHeight = 150.0
+	minimapMargin    = 20.0
+)
+
+// renderMinimap draws the node bounding boxes, scaled by scale, with no labels
+func (r *SVGRenderer) renderMinimap(layout *Layout, g *graph.Graph, x, y, scale float64) {
+	r.buf.WriteString(fmt.Sprintf(`
+<!-- Minimap overview -->
+<g class="minimap">
+  <rect class="minimap-frame" x="%.2f" y="%.2f" width="%.2f" height="%.2f"
+        fill="white" fill-opacity="0.85" stroke="#adb5bd" stroke-width="1"/>
+`, x, y, layout.Width*scale, layout.Height*scale))
+
+	nodeIDs := make([]string, 0, len(layout.Nodes))
+	for nodeID := range layout.Nodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+
+	for _, nodeID := range nodeIDs {
+		node := g.Nodes[nodeID]
+		if node == nil {
+			continue
+		}
+		nodeLayout := layout.Nodes[nodeID]
+		r.buf.WriteString(fmt.Sprintf(`  <rect class="minimap-node" x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>
+`, x+nodeLayout.Position.X*scale, y+nodeLayout.Position.Y*scale,
+			nodeLayout.Width*scale, nodeLayout.Height*scale, r.accentColor(node)))
+	}
+
+	r.buf.WriteString("</g>\n")
+}
+
+// Region legend swatch size, row height and the gap between it and the content above
+const (
+	legendSwatchSize = 12.0
+	legendRowHeight  = 20.0
+	legendMargin     = 20.0
+)
+
+// regionLegendEntry is a color swatch and its label in the region legend
+type regionLegendEntry struct {
+	label string
+	color string
+}
+
+// noRegionLabel labels the legend entry of nodes without a region
+const noRegionLabel = "no region"
+
+// regionColor returns the color of a node's region, or noRegionColor when it has none
+func (r *SVGRenderer) regionColor(node *graph.Node) string {
+	if color, ok := r.regionColors[nodeRegion(node)]; ok {
+		return color
+	}
+	return noRegionColor
+}
+
+// unknownNodeColor is the fill color of uncategorized nodes with RenderOptions.HighlightUnknown
+const unknownNodeColor = "#ADB5BD"
+
+// highlightsUnknown reports whether a node is drawn in the uncategorized style of
+// RenderOptions.HighlightUnknown
+func (r *SVGRenderer) highlightsUnknown(node *graph.Node) bool {
+	return r.options.HighlightUnknown && node.ResourceType == parser.ResourceTypeUnknown
+}
+
+// borderDash returns the stroke-dasharray attribute of a node's border: dashed for
+// highlighted uncategorized nodes, otherwise empty (solid)
+func (r *SVGRenderer) borderDash(node *graph.Node) string {
+	if r.highlightsUnknown(node) {
+		return ` stroke-dasharray="6,4"`
+	}
+	return ""
+}
+
+// renderUnknownMarker draws the "?" badge in the top-right corner of an uncategorized node
+func (r *SVGRenderer) renderUnknownMarker(node *NodeLayout, x, y float64) {
+	cx, cy := x+node.Width-14, y+14
+	r.buf.WriteString(fmt.Sprintf(`  <!-- Unknown resource type -->
+  <g class="unknown-marker">
+    <circle cx="%.2f" cy="%.2f" r="9" fill="%s"/>
+    <text x="%.2f" y="%.2f" font-family="%s" font-size="12" font-weight="700" fill="white" text-anchor="middle">?</text>
+  </g>
+`, cx, cy, darkenColor(unknownNodeColor, 30), cx, cy+4, r.fontFamily()))
+}
+
+// sourceLocation returns "file:line" for a node parsed from configuration when
+// ShowSourceFiles is set, otherwise ""
+func (r *SVGRenderer) sourceLocation(node *graph.Node) string {
+	if !r.options.ShowSourceFiles || node.SourceFile == "" {
+		return ""
+	}
+	if node.SourceLine > 0 {
+		return fmt.Sprintf("%s:%d", node.SourceFile, node.SourceLine)
+	}
+	return node.SourceFile
+}
+
+// renderSourceTooltip adds a <title> to the current node group showing where its
+// resource is declared, shown by browsers on hover
+func (r *SVGRenderer) renderSourceTooltip(node *graph.Node) {
+	if source := r.sourceLocation(node); source != "" {
+		r.buf.WriteString(fmt.Sprintf("  <title>%s</title>\n", escapeXML(source)))
+	}
+}
+
+// nodeColor returns the fill color of a node: gray for highlighted uncategorized nodes,
+// by region with ColorByRegion, otherwise by type
+func (r *SVGRenderer) nodeColor(node *graph.Node) string {
+	if r.highlightsUnknown(node) {
+		return unknownNodeColor
+	}
+	if r.regionColors != nil {
+		return r.regionColor(node)
+	}
+	return getNodeColor(node)
+}
+
+// accentColor returns the border/accent color of a node: the path color for nodes on
+// HighlightPath, gray for highlighted uncategorized nodes, by region with
+// ColorByRegion, otherwise by type
+func (r *SVGRenderer) accentColor(node *graph.Node) string {
+	if r.path != nil && r.path.nodes[node.ID] {
+		return pathHighlightColor
+	}
+	if r.highlightsUnknown(node) {
+		return darkenColor(unknownNodeColor, 20)
+	}
+	if r.regionColors != nil {
+		return darkenColor(r.regionColor(node), 20)
+	}
+	return getAccentColor(node)
+}
+
+// regionLegend returns one entry per region, sorted by name, followed by an entry for
+// nodes without a region when there are any. It is empty when no node has a region.
+func (r *SVGRenderer) regionLegend(g *graph.Graph) []regionLegendEntry {
+	if len(r.regionColors) == 0 {
+		return nil
+	}
+
+	regions := make([]string, 0, len(r.regionColors))
+	for region := range r.regionColors {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	entries := make([]regionLegendEntry, 0, len(regions)+1)
+	for _, region := range regions {
+		entries = append(entries, regionLegendEntry{label: region, color: r.regionColors[region]})
+	}
+	for _, node := range g.Nodes {
+		if nodeRegion(node) == "" {
+			entries = append(entries, regionLegendEntry{label: noRegionLabel, color: noRegionColor})
+			break
+		}
+	}
+	return entries
+}
+
+// layoutLegend wraps legend entries into rows no wider than maxWidth, returning each
+// entry's offset from the legend's top-left corner and the total legend height
+func layoutLegend(entries []regionLegendEntry, maxWidth float64) ([]Point, float64) {
+	offsets := make([]Point, len(entries))
+	x, y := 0.0, 0.0
+	for i, entry := range entries {
+		// Approximate label width for an 11px font, plus the swatch and spacing
+		itemWidth := legendSwatchSize + 6 + float64(len([]rune(entry.label)))*6.5 + 16
+		if x > 0 && x+itemWidth > maxWidth {
+			x = 0
+			y += legendRowHeight
+		}
+		offsets[i] = Point{X: x, Y: y}
+		x += itemWidth
+	}
+	return offsets, y + legendRowHeight
+}
+
+// renderRegionLegend draws the region legend with its top-left corner at x, y
+func (r *SVGRenderer) renderRegionLegend(entries []regionLegendEntry, offsets []Point, x, y float64) {
+	r.buf.WriteString(`
+<!-- Region legend -->
+<g class="legend">
+`)
+	for i, entry := range entries {
+		itemX, itemY := x+offsets[i].X, y+offsets[i].Y
+		r.buf.WriteString(fmt.Sprintf(`  <rect x="%.2f" y="%.2f" width="%.0f" height="%.0f" rx="3" ry="3" fill="%s" stroke="%s" stroke-width="1"/>
+  <text x="%.2f" y="%.2f" font-family="%s" font-size="11" fill="#495057">%s</text>
+`, itemX, itemY, legendSwatchSize, legendSwatchSize, entry.color, darkenColor(entry.color, 20),
+			itemX+legendSwatchSize+6, itemY+legendSwatchSize-2, r.fontFamily(), escapeXML(entry.label)))
+	}
+	r.buf.WriteString("</g>\n")
+}
+
+// nodesInDrawOrder returns the IDs of the laid-out nodes sorted by nesting depth, so
+// containers are drawn before their members, then by ID
+func nodesInDrawOrder(layout *Layout) []string {
+	depth := func(id string) int {
+		d := 0
+		for node := layout.Nodes[id]; node != nil && node.Parent != ""; node = layout.Nodes[node.Parent] {
+			d++
+		}
+		return d
+	}
+
+	ids := make([]string, 0, len(layout.Nodes))
+	depths := make(map[string]int, len(layout.Nodes))
+	for id := range layout.Nodes {
+		ids = append(ids, id)
+		depths[id] = depth(id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if depths[ids[i]] != depths[ids[j]] {
+			return depths[ids[i]] < depths[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// renderContainer renders a node with nested members as a tinted box with its label in
+// the header band; the members are drawn on top of it
+func (r *SVGRenderer) renderContainer(node *NodeLayout, padding float64) {
+	x := node.Position.X + padding
+	y := node.Position.Y + padding
+	color := r.nodeColor(node.Node)
+
+	r.buf.WriteString(fmt.Sprintf(`
+<!-- Container: %s -->
+<g %s data-container="true">
+  <rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" rx="16" ry="16"
+        fill="%s" fill-opacity="0.08" stroke="%s" stroke-width="2"/>
+`, escapeComment(node.Node.Name), r.nodeGroupAttributes(node),
+		x, y, node.Width, node.Height,
+		color, r.accentColor(node.Node)))
+
+	if r.options.IncludeLabels {
+		r.buf.WriteString(fmt.Sprintf(`  <text x="%.2f" y="%.2f" font-family="%s" font-size="14" font-weight="600" fill="#2c3e50">%s</text>
+  <text x="%.2f" y="%.2f" font-family="%s" font-size="11" fill="#6c757d">%s</text>
+`, x+containerPadding, y+20, r.fontFamily(), escapeXML(truncate(r.nodeLabel(node.Node), 40)),
+			x+containerPadding, y+34, r.fontFamily(), escapeXML(getResourceTypeName(node.Node.Type))))
+	}
+
+	r.renderSourceTooltip(node.Node)
+	r.buf.WriteString("</g>\n")
+}
+
+// clusterPadding is the space between a cluster's border and its nodes
+const clusterPadding = 20.0
+
+// renderProviderClusters draws one tinted rectangle around the nodes of each provider
+func (r *SVGRenderer) renderProviderClusters(layout *Layout, g *graph.Graph, padding float64) {
+	r.renderClusters(layout, g, padding, "provider",
+		func(node *graph.Node) string { return node.Provider },
+		getProviderTint,
+		strings.ToUpper)
+}
+
+// renderTagClusters draws one tinted rectangle around the nodes sharing each value of
+// the GroupByTag tag, labeled "key=value"; untagged nodes are not grouped
+func (r *SVGRenderer) renderTagClusters(layout *Layout, g *graph.Graph, padding float64) {
+	key := r.options.GroupByTag
+	groupOf := func(node *graph.Node) string { return nodeTag(node, key) }
+	tints := assignGroupColors(g, groupOf)
+	r.renderClusters(layout, g, padding, "tag", groupOf,
+		func(value string) string { return tints[value] },
+		func(value string) string { return key + "=" + value })
+}
+
+// renderClusters draws a tinted, labeled rectangle around the laid-out nodes of each
+// non-empty group returned by groupOf, in sorted group order. The group is recorded in
+// the data-<kind> attribute of the cluster.
+func (r *SVGRenderer) renderClusters(layout *Layout, g *graph.Graph, padding float64, kind string,
+	groupOf func(*graph.Node) string, tintOf func(string) string, labelOf func(string) string) {
+	type bounds struct{ minX, minY, maxX, maxY float64 }
+
+	clusters := make(map[string]*bounds)
+	for nodeID, nodeLayout := range layout.Nodes {
+		node := g.Nodes[nodeID]
+		if node == nil {
+			continue
+		}
+		group := groupOf(node)
+		if group == "" {
+			continue
+		}
+		x, y := nodeLayout.Position.X, nodeLayout.Position.Y
+		b, ok := clusters[group]
+		if !ok {
+			clusters[group] = &bounds{x, y, x + nodeLayout.Width, y + nodeLayout.Height}
+			continue
+		}
+		b.minX = math.Min(b.minX, x)
+		b.minY = math.Min(b.minY, y)
+		b.maxX = math.Max(b.maxX, x+nodeLayout.Width)
+		b.maxY = math.Max(b.maxY, y+nodeLayout.Height)
+	}
+
+	groups := make([]string, 0, len(clusters))
+	for group := range clusters {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		b := clusters[group]
+		tint := tintOf(group)
+		x := b.minX + padding - clusterPadding
+		y := b.minY + padding - clusterPadding
+		r.buf.WriteString(fmt.Sprintf(`
+<!-- Cluster: %s -->
+<g class="cluster" data-%s="%s">
+  <rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" rx="16" ry="16"
+        fill="%s" fill-opacity="0.08" stroke="%s" stroke-opacity="0.4" stroke-width="1.5"/>
+  <text x="%.2f" y="%.2f" font-family="%s" font-size="12" font-weight="600" fill="%s">%s</text>
+</g>
+`, escapeComment(group), kind, escapeXML(group),
+			x, y, b.maxX-b.minX+2*clusterPadding, b.maxY-b.minY+2*clusterPadding,
+			tint, tint,
+			x+10, y+16, r.fontFamily(), darkenColor(tint, 30), escapeXML(labelOf(group))))
+	}
+}
+
+// escapeXML escapes s for SVG text content and attribute values. Unlike html.EscapeString
+// it also replaces characters that are not allowed in XML, such as control characters.
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// escapeComment makes s safe inside an XML comment, which may not contain "--"
+func escapeComment(s string) string {
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	return s
+}
+
+// escapeCDATA splits any "]]>" in s so it cannot terminate a CDATA section early
+func escapeCDATA(s string) string {
+	return strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>")
+}
+
+// sanitizeID replaces every character that is not valid in an XML ID (and url(#...)
+// references) with an underscore; for_each keys such as web["a b"] become web__a_b__
+func sanitizeID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
 // formatFloat efficiently formats a float to string without unnecessary precision
 func formatFloat(f float64) string {
 	// Use strconv for better performance than Sprintf
@@ -160,17 +702,30 @@ func (r *SVGRenderer) writeTitle(title string, width, padding float64) {
       rx="8" ry="8" fill="white" opacity="0.9"
       stroke="#0066cc" stroke-width="2" filter="url(#nodeShadow)"/>
 <text x="%.0f" y="%.0f"
-      font-family="'Segoe UI', Arial, sans-serif"
+      font-family="%s"
       font-size="24" font-weight="600"
       fill="#2c3e50" text-anchor="middle">%s</text>
-`, boxX, boxY, titleWidth, titleHeight, centerX, titleY, html.EscapeString(title)))
+`, boxX, boxY, titleWidth, titleHeight, centerX, titleY, r.fontFamily(), escapeXML(title)))
 }
 
+// Node dimensions and spacing of RenderOptions.Compact layouts
+const (
+	compactNodeWidth  = 150.0
+	compactNodeHeight = 44.0
+	compactSpacing    = 40.0
+	compactIconSize   = 24.0
+)
+
 // renderNode renders a node
 func (r *SVGRenderer) renderNode(node *NodeLayout, padding float64) {
 	x := node.Position.X + padding
 	y := node.Position.Y + padding
 
+	if node.Node.Type == outputNodeType {
+		r.renderOutputNode(node, x, y)
+		return
+	}
+
 	// Try to get icon if enabled
 	iconData := ""
 	if r.options.UseIcons {
@@ -185,7 +740,9 @@ func (r *SVGRenderer) renderNode(node *NodeLayout, padding float64) {
 	}
 
 	// Render with or without icon
-	if iconData != "" {
+	if r.options.Compact {
+		r.renderCompactNode(node, x, y, iconData)
+	} else if iconData != "" {
 		r.renderNodeWithIcon(node, x, y, iconData)
 	} else {
 		r.renderNodeWithoutIcon(node, x, y)
@@ -221,18 +778,18 @@ func embedIconData(data []byte, path string) string {
 
 // renderNodeWithIcon renders a node with an embedded icon and modern styling
 func (r *SVGRenderer) renderNodeWithIcon(node *NodeLayout, x, y float64, iconData string) {
-	// Get accent color based on resource type
-	accentColor := getAccentColor(node.Node)
+	// Get accent color based on resource type (or region)
+	accentColor := r.accentColor(node.Node)
 
 	// Card-style background with gradient and shadow
 	r.buf.WriteString(fmt.Sprintf(`
 <!-- Node: %s -->
-<g class="node">
+<g %s>
   <!-- Card background -->
   <rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"
         rx="14" ry="14"
         fill="url(#nodeGradient)"
-        stroke="%s" stroke-width="3"
+        stroke="%s" stroke-width="3"%s
         filter="url(#nodeShadow)"/>
 
   <!-- Accent bar at top -->
@@ -244,9 +801,10 @@ func (r *SVGRenderer) renderNodeWithIcon(node *NodeLayout, x, y float64, iconDat
   <image x="%.2f" y="%.2f" width="%.2f" height="%.2f"
          xlink:href="%s" preserveAspectRatio="xMidYMid meet"/>
 `,
-		node.Node.Name,
+		escapeComment(node.Node.Name),
+		r.nodeGroupAttributes(node),
 		x, y, node.Width, node.Height,
-		accentColor,
+		accentColor, r.borderDash(node.Node),
 		x, y, node.Width,
 		accentColor,
 		x+node.Width/2-32, y+60-32, 64.0, 64.0,
@@ -258,16 +816,20 @@ func (r *SVGRenderer) renderNodeWithIcon(node *NodeLayout, x, y float64, iconDat
 		r.renderNodeLabel(node.Node, x+node.Width/2, labelY, node.Width)
 	}
 
+	if r.highlightsUnknown(node.Node) {
+		r.renderUnknownMarker(node, x, y)
+	}
+	r.renderSourceTooltip(node.Node)
 	r.buf.WriteString("</g>\n")
 }
 
 // renderNodeWithoutIcon renders a node without an icon with modern gradient styling
 func (r *SVGRenderer) renderNodeWithoutIcon(node *NodeLayout, x, y float64) {
-	color := getNodeColor(node.Node)
-	accentColor := getAccentColor(node.Node)
+	color := r.nodeColor(node.Node)
+	accentColor := r.accentColor(node.Node)
 
 	// Create a gradient ID for this node
-	gradientID := fmt.Sprintf("grad_%s", strings.ReplaceAll(node.Node.ID, ".", "_"))
+	gradientID := "grad_" + sanitizeID(node.Node.ID)
 
 	// Add gradient definition
 	r.buf.WriteString(fmt.Sprintf(`
@@ -281,16 +843,17 @@ func (r *SVGRenderer) renderNodeWithoutIcon(node *NodeLayout, x, y float64) {
 
 	// Card with gradient and shadow
 	r.buf.WriteString(fmt.Sprintf(`
-<g class="node">
+<g %s>
   <rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"
         rx="12" ry="12"
         fill="url(#%s)"
-        stroke="%s" stroke-width="2.5"
+        stroke="%s" stroke-width="2.5"%s
         filter="url(#nodeShadow)"/>
 `,
+		r.nodeGroupAttributes(node),
 		x, y, node.Width, node.Height,
 		gradientID,
-		accentColor))
+		accentColor, r.borderDash(node.Node)))
 
 	// Label centered in box with better contrast
 	if r.options.IncludeLabels {
@@ -298,36 +861,222 @@ func (r *SVGRenderer) renderNodeWithoutIcon(node *NodeLayout, x, y float64) {
 		r.renderNodeLabel(node.Node, x+node.Width/2, centerY, node.Width)
 	}
 
+	if r.highlightsUnknown(node.Node) {
+		r.renderUnknownMarker(node, x, y)
+	}
+	r.renderSourceTooltip(node.Node)
+	r.buf.WriteString("</g>\n")
+}
+
+// renderCompactNode renders a node as a small card with an optional icon at the left
+// and a single-line label
+func (r *SVGRenderer) renderCompactNode(node *NodeLayout, x, y float64, iconData string) {
+	accentColor := r.accentColor(node.Node)
+
+	r.buf.WriteString(fmt.Sprintf(`
+<!-- Node: %s -->
+<g %s>
+  <rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"
+        rx="8" ry="8"
+        fill="%s"
+        stroke="%s" stroke-width="1.5"%s/>
+`,
+		escapeComment(node.Node.Name),
+		r.nodeGroupAttributes(node),
+		x, y, node.Width, node.Height,
+		lightenColor(r.nodeColor(node.Node), 60),
+		accentColor, r.borderDash(node.Node)))
+
+	// The label is centered in the space right of the icon
+	textLeft, maxChars := x, 20
+	if iconData != "" {
+		r.buf.WriteString(fmt.Sprintf(`  <image x="%.2f" y="%.2f" width="%.2f" height="%.2f"
+         xlink:href="%s" preserveAspectRatio="xMidYMid meet"/>
+`, x+8, y+(node.Height-compactIconSize)/2, compactIconSize, compactIconSize, iconData))
+		textLeft, maxChars = x+compactIconSize+8, 16
+	}
+
+	if r.options.IncludeLabels {
+		r.buf.WriteString(fmt.Sprintf(`  <text x="%.2f" y="%.2f" font-family="%s"
+        font-size="11" font-weight="600" fill="#2c3e50"
+        text-anchor="middle">%s</text>
+`, (textLeft+x+node.Width)/2, y+node.Height/2+4, r.fontFamily(), escapeXML(truncate(r.nodeLabel(node.Node), maxChars))))
+	}
+
+	if r.highlightsUnknown(node.Node) {
+		r.renderUnknownMarker(node, x, y)
+	}
+	r.renderSourceTooltip(node.Node)
 	r.buf.WriteString("</g>\n")
 }
 
+// dimmedOpacity is the group opacity of nodes and edges outside RenderOptions.EmphasizeType
+// or RenderOptions.HighlightPath
+const dimmedOpacity = 0.25
+
+// isEmphasized reports whether a node is on the highlighted path or, without one,
+// matches RenderOptions.EmphasizeType. Every node is emphasized when neither is set.
+func (r *SVGRenderer) isEmphasized(node *graph.Node) bool {
+	if r.path != nil {
+		return r.path.nodes[node.ID]
+	}
+	if r.options.EmphasizeType == parser.ResourceTypeUnknown {
+		return true
+	}
+	return node.ResourceType == r.options.EmphasizeType
+}
+
+// groupAttributes returns the class (and opacity, when dimmed) attributes of a node or edge group
+func (r *SVGRenderer) groupAttributes(class string, emphasized bool) string {
+	if emphasized {
+		return fmt.Sprintf(`class="%s"`, class)
+	}
+	return fmt.Sprintf(`class="%s dimmed" opacity="%.2f"`, class, dimmedOpacity)
+}
+
+// nodeGroupAttributes returns the attributes of a node group, including data-layer,
+// data-type and data-provider for styling by CSS or scripts (plus data-source with
+// ShowSourceFiles); with RenderOptions.Accessible the group is exposed as an image
+// labelled by the node name and type
+func (r *SVGRenderer) nodeGroupAttributes(nodeLayout *NodeLayout) string {
+	node := nodeLayout.Node
+	attrs := fmt.Sprintf(`%s data-layer="%d" data-type="%s" data-provider="%s"`,
+		r.groupAttributes("node", r.isEmphasized(node)), nodeLayout.Layer, escapeXML(node.Type), escapeXML(node.Provider))
+	if source := r.sourceLocation(node); source != "" {
+		attrs += fmt.Sprintf(` data-source="%s"`, escapeXML(source))
+	}
+	if !r.options.Accessible {
+		return attrs
+	}
+	label := fmt.Sprintf("%s (%s)", node.Name, node.Type)
+	return fmt.Sprintf(`%s role="img" aria-label="%s"`, attrs, escapeXML(label))
+}
+
 // renderNodeLabel renders the node label text with professional typography
 func (r *SVGRenderer) renderNodeLabel(node *graph.Node, x, y, maxWidth float64) {
 	// Node name with shadow for better readability
-	name := truncate(node.Name, 25)
+	name := truncate(r.nodeLabel(node), r.options.MaxLabelLength)
 	r.buf.WriteString(fmt.Sprintf(`
   <!-- Label shadow for better readability -->
-  <text x="%.2f" y="%.2f" font-family="'Segoe UI', Arial, sans-serif"
+  <text x="%.2f" y="%.2f" font-family="%s"
         font-size="14" font-weight="600" fill="black" opacity="0.1"
         text-anchor="middle">%s</text>
   <!-- Main label -->
-  <text x="%.2f" y="%.2f" font-family="'Segoe UI', Arial, sans-serif"
+  <text x="%.2f" y="%.2f" font-family="%s"
         font-size="14" font-weight="600" fill="#2c3e50"
         text-anchor="middle">%s</text>
-`, x+1, y+1, html.EscapeString(name), x, y, html.EscapeString(name)))
+`, x+1, y+1, r.fontFamily(), escapeXML(name), x, y, r.fontFamily(), escapeXML(name)))
 
 	// Resource type with subtle styling
 	typeName := getResourceTypeName(node.Type)
-	typeName = truncate(typeName, 30)
+	typeName = truncate(typeName, r.options.MaxTypeLength)
 	r.buf.WriteString(fmt.Sprintf(`
-  <text x="%.2f" y="%.2f" font-family="'Segoe UI', Arial, sans-serif"
+  <text x="%.2f" y="%.2f" font-family="%s"
         font-size="11" fill="#6c757d" opacity="0.9"
         text-anchor="middle">%s</text>
-`, x, y+18, html.EscapeString(typeName)))
+`, x, y+18, r.fontFamily(), escapeXML(typeName)))
+
+	if r.options.ShowBadges {
+		r.renderNodeBadge(node, x, y+26)
+	}
+}
+
+// nodeLabel returns the main label for a node: NodeLabelTemplate executed against the
+// node when set, otherwise the node name. Execution errors are logged and fall back
+// to the node name.
+func (r *SVGRenderer) nodeLabel(node *graph.Node) string {
+	if r.labelTemplate == nil {
+		return node.Name
+	}
+
+	var label strings.Builder
+	if err := r.labelTemplate.Execute(&label, node); err != nil {
+		r.options.logger().Warn("node label template failed; using node name", "node", node.ID, "error", err)
+		return node.Name
+	}
+	return label.String()
+}
+
+// badgeHeight is the height of the metadata pill rendered under node labels
+const badgeHeight = 16.0
+
+// renderNodeBadge renders a compact pill with region/size metadata, centered at x with its top at y
+func (r *SVGRenderer) renderNodeBadge(node *graph.Node, x, y float64) {
+	text := truncate(formatNodeBadge(node), 32)
+	if text == "" {
+		return
+	}
+
+	// Approximate text width for a 10px font
+	width := float64(len([]rune(text)))*5.8 + 14
+	r.buf.WriteString(fmt.Sprintf(`
+  <!-- Metadata badge -->
+  <g class="badge">
+    <rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"
+          rx="%.2f" ry="%.2f" fill="#e9ecef" stroke="#ced4da" stroke-width="1"/>
+    <text x="%.2f" y="%.2f" font-family="%s"
+          font-size="10" fill="#495057" text-anchor="middle">%s</text>
+  </g>
+`, x-width/2, y, width, badgeHeight,
+		badgeHeight/2, badgeHeight/2,
+		x, y+badgeHeight-4.5, r.fontFamily(), escapeXML(text)))
+}
+
+// bidirectionalRelationships are symmetric relationships drawn with an arrowhead at
+// both ends even though the graph holds a single edge (e.g. VPC peering)
+var bidirectionalRelationships = map[string]bool{
+	"peers_with": true,
 }
 
-// renderEdge renders an edge between nodes with modern styling and curved lines
-func (r *SVGRenderer) renderEdge(edge *EdgeLayout, padding float64) {
+// pairMutualEdges returns the edges to draw, collapsing each reciprocal pair (A->B and
+// B->A) into a single edge reported in bidirectional. The pair's non-feedback edge is
+// kept so the merged line isn't drawn dashed. The layout itself is not modified.
+func pairMutualEdges(edges []*EdgeLayout) ([]*EdgeLayout, map[*EdgeLayout]bool) {
+	type endpoints struct{ from, to string }
+
+	// First edge in each direction; further parallel edges stay one-way
+	byEndpoints := make(map[endpoints]*EdgeLayout)
+	for _, edge := range edges {
+		key := endpoints{edge.Edge.From.ID, edge.Edge.To.ID}
+		if _, ok := byEndpoints[key]; !ok {
+			byEndpoints[key] = edge
+		}
+	}
+
+	bidirectional := make(map[*EdgeLayout]bool)
+	dropped := make(map[*EdgeLayout]bool)
+	for _, edge := range edges {
+		if bidirectional[edge] || dropped[edge] {
+			continue
+		}
+		reverse := byEndpoints[endpoints{edge.Edge.To.ID, edge.Edge.From.ID}]
+		if reverse == nil || reverse == edge || bidirectional[reverse] || dropped[reverse] {
+			continue
+		}
+
+		keep, drop := edge, reverse
+		if keep.Feedback && !drop.Feedback {
+			keep, drop = drop, keep
+		}
+		bidirectional[keep] = true
+		dropped[drop] = true
+	}
+
+	if len(dropped) == 0 {
+		return edges, bidirectional
+	}
+	drawn := make([]*EdgeLayout, 0, len(edges)-len(dropped))
+	for _, edge := range edges {
+		if !dropped[edge] {
+			drawn = append(drawn, edge)
+		}
+	}
+	return drawn, bidirectional
+}
+
+// renderEdge renders an edge between nodes with modern styling and curved lines.
+// Bidirectional edges get an arrowhead at both ends.
+func (r *SVGRenderer) renderEdge(edge *EdgeLayout, padding float64, bidirectional bool) {
 	if len(edge.Points) < 2 {
 		return
 	}
@@ -369,10 +1118,31 @@ func (r *SVGRenderer) renderEdge(edge *EdgeLayout, padding float64) {
 		}
 	}
 
+	// Edges touching an emphasized node stay at full opacity; with a highlighted path,
+	// only its edges do, drawn bold in the path color
+	edgeEmphasized := r.isEmphasized(edge.Edge.From) || r.isEmphasized(edge.Edge.To)
+	stroke, strokeWidth := "#495057", 1.5
+	if r.path != nil {
+		edgeEmphasized = r.path.hasEdge(edge.Edge)
+		if edgeEmphasized {
+			stroke, strokeWidth = pathHighlightColor, 3.0
+		}
+	}
+
+	// Edges removed to break a cycle, and those of output notes, are drawn dashed
+	dash := ""
+	if edge.Feedback || edge.Edge.From.Type == outputNodeType {
+		dash = ` stroke-dasharray="6,4"`
+	}
+	markerStart := ""
+	if bidirectional {
+		markerStart = ` marker-start="url(#arrowhead-start)"`
+	}
+
 	// Draw path with compact, professional styling
 	r.buf.WriteString(fmt.Sprintf(`
 <!-- Edge connection -->
-<g class="edge">
+<g %s>
   <!-- White outline for contrast against background -->
   <path d="%s" stroke="white" stroke-width="3.5" opacity="0.7"
         fill="none" stroke-linecap="round" stroke-linejoin="round"/>
@@ -380,14 +1150,17 @@ func (r *SVGRenderer) renderEdge(edge *EdgeLayout, padding float64) {
   <path d="%s" stroke="#000000" stroke-width="2.5" opacity="0.12"
         fill="none" stroke-linecap="round" stroke-linejoin="round"/>
   <!-- Main connection line with enhanced visibility -->
-  <path d="%s" stroke="#495057" stroke-width="1.5"
-        fill="none" marker-end="url(#arrowhead-outlined)"
+  <path d="%s" stroke="%s" stroke-width="%.1f"%s
+        fill="none"%s marker-end="url(#arrowhead-outlined)"
         stroke-linecap="round" stroke-linejoin="round" opacity="0.85"/>
-`, pathData, pathData, pathData))
+`, r.groupAttributes("edge", edgeEmphasized), pathData, pathData, pathData, stroke, strokeWidth, dash, markerStart))
 
 	// Add edge label if present
-	if r.options.IncludeLabels {
-		label := formatEdgeLabel(edge.Edge)
+	if r.options.IncludeLabels && shouldLabelEdge(edge.Edge, r.options.LabelRelationships) {
+		label := formatEdgeLabel(edge.Edge, r.options.RelationshipLabels)
+		if r.options.ShowEdgeSource {
+			label = appendEdgeSource(label, edge.Edge)
+		}
 		if label != "" {
 			// Position label at midpoint
 			midIdx := len(edge.Points) / 2
@@ -405,11 +1178,11 @@ func (r *SVGRenderer) renderEdge(edge *EdgeLayout, padding float64) {
         rx="4" ry="4" fill="white" opacity="0.95"
         stroke="#6c757d" stroke-width="1"/>
   <!-- Edge label text -->
-  <text x="%.2f" y="%.2f" font-family="'Segoe UI', Arial, sans-serif"
+  <text x="%.2f" y="%.2f" font-family="%s"
         font-size="10" font-weight="500" fill="#495057"
         text-anchor="middle">%s</text>
 `, labelX-labelWidth/2, labelY-16, labelWidth, labelHeight,
-				labelX, labelY, html.EscapeString(label)))
+				labelX, labelY, r.fontFamily(), escapeXML(label)))
 		}
 	}
 
@@ -20,7 +20,7 @@ func ValidateOutputPath(outputPath string) error {
 	cleanPath := filepath.Clean(outputPath)
 
 	// Check for path traversal attempts
-	if strings.Contains(cleanPath, "..") {
+	if hasTraversal(outputPath) {
 		return fmt.Errorf("path traversal detected in output path: %s", outputPath)
 	}
 
@@ -59,6 +59,51 @@ func ValidateOutputPath(outputPath string) error {
 	return nil
 }
 
+// CheckOutputPath validates an output path without touching the filesystem, so it can
+// run before there is anything to write
+// Returns error if the path is empty or contains path traversal attempts
+func CheckOutputPath(outputPath string) error {
+	if outputPath == "" {
+		return fmt.Errorf("output path cannot be empty")
+	}
+
+	if hasTraversal(outputPath) {
+		return fmt.Errorf("path traversal detected in output path: %s", outputPath)
+	}
+
+	return nil
+}
+
+// EnsureOutputDir creates the parent directory of an output path, including any missing
+// ancestors, so the diagram can be written to a new location.
+// Returns error if the path contains path traversal attempts or the directory cannot be created.
+func EnsureOutputDir(outputPath string) error {
+	if err := CheckOutputPath(outputPath); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filepath.Clean(outputPath))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", dir, err)
+	}
+
+	return nil
+}
+
+// hasTraversal reports whether a path contains ".." components. The raw path is checked
+// as well as the cleaned one, since cleaning an absolute path silently drops them.
+func hasTraversal(path string) bool {
+	if strings.Contains(filepath.Clean(path), "..") {
+		return true
+	}
+	for _, element := range strings.Split(filepath.ToSlash(path), "/") {
+		if element == ".." {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateInputPath validates an input path (state or config directory)
 // Returns error if path doesn't exist or is not accessible
 func ValidateInputPath(inputPath string, mustBeDir bool) error {
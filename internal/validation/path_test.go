@@ -134,6 +134,93 @@ func TestValidateInputPath(t *testing.T) {
 	}
 }
 
+func TestCheckOutputPath(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "nested non-existent directory", path: filepath.Join(tmpDir, "a", "b", "diagram.svg")},
+		{name: "relative path", path: "diagrams/diagram.svg"},
+		{name: "empty path", path: "", wantErr: true},
+		{name: "path traversal attempt with ..", path: tmpDir + "/../escape/diagram.svg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckOutputPath(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("CheckOutputPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Nothing is created
+	if _, err := os.Stat(filepath.Join(tmpDir, "a")); !os.IsNotExist(err) {
+		t.Errorf("CheckOutputPath() touched the filesystem: %v", err)
+	}
+}
+
+func TestEnsureOutputDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	regularFile := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(regularFile, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantDir string
+		wantErr bool
+	}{
+		{
+			name:    "nested non-existent directory",
+			path:    filepath.Join(tmpDir, "a", "b", "c", "diagram.svg"),
+			wantDir: filepath.Join(tmpDir, "a", "b", "c"),
+		},
+		{
+			name:    "existing directory",
+			path:    filepath.Join(tmpDir, "diagram.svg"),
+			wantDir: tmpDir,
+		},
+		{
+			name:    "empty path",
+			path:    "",
+			wantErr: true,
+		},
+		{
+			name:    "path traversal attempt with ..",
+			path:    tmpDir + "/../escape/diagram.svg",
+			wantErr: true,
+		},
+		{
+			name:    "parent is a regular file",
+			path:    filepath.Join(regularFile, "sub", "diagram.svg"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := EnsureOutputDir(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnsureOutputDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			info, err := os.Stat(tt.wantDir)
+			if err != nil || !info.IsDir() {
+				t.Errorf("EnsureOutputDir() did not create %s: %v", tt.wantDir, err)
+			}
+		})
+	}
+}
+
 func TestValidateOutputPath_Permissions(t *testing.T) {
 	if os.Getuid() == 0 {
 		t.Skip("Skipping permission test when running as root")